@@ -26,6 +26,7 @@ var addCmd = &cobra.Command{
 
 type addCmdConfig struct {
 	force   bool
+	preview bool
 	prompt  bool
 	options chezmoi.AddOptions
 }
@@ -34,6 +35,7 @@ func init() {
 	rootCmd.AddCommand(addCmd)
 
 	persistentFlags := addCmd.PersistentFlags()
+	persistentFlags.BoolVar(&config.add.options.Archive, "archive", false, "add directories as encrypted archives")
 	persistentFlags.BoolVarP(&config.add.options.Empty, "empty", "e", false, "add empty files")
 	persistentFlags.BoolVar(&config.add.options.Encrypt, "encrypt", false, "encrypt files")
 	persistentFlags.BoolVarP(&config.add.force, "force", "f", false, "overwrite source state, even if template would be lost")
@@ -42,14 +44,42 @@ func init() {
 	persistentFlags.BoolVarP(&config.add.options.Recursive, "recursive", "r", false, "recurse in to subdirectories")
 	persistentFlags.BoolVarP(&config.add.options.Template, "template", "T", false, "add files as templates")
 	persistentFlags.BoolVarP(&config.add.options.AutoTemplate, "autotemplate", "a", false, "auto generate the template when adding files as templates")
+	persistentFlags.IntVar(&config.add.options.AutoTemplateMinLength, "autotemplate-min-length", 0, "minimum length of values replaced by --autotemplate")
+	persistentFlags.BoolVar(&config.add.preview, "preview", false, "preview and confirm substitutions made by --autotemplate")
 
 	markRemainingZshCompPositionalArgumentsAsFiles(addCmd, 1)
 }
 
 func (c *Config) runAddCmd(cmd *cobra.Command, args []string) (err error) {
+	// Make --archive imply --encrypt. Archives include all subdirectories, so
+	// do not recurse.
+	if c.add.options.Archive {
+		c.add.options.Encrypt = true
+		c.add.options.Recursive = false
+	}
+
 	// Make --autotemplate imply --template.
 	if c.add.options.AutoTemplate {
 		c.add.options.Template = true
+		if c.add.preview {
+			c.add.options.AutoTemplateConfirm = c.previewAutoTemplate
+		}
+	}
+
+	secretsFound, err := c.getSecretsFoundFunc()
+	if err != nil {
+		return err
+	}
+	if secretsFound != nil {
+		// Record the targets with possible secrets that have already been
+		// reported so that they are not reported again on autocommit.
+		c.add.options.SecretsFound = func(targetName string, findings []chezmoi.SecretFinding) error {
+			if c.secretsReported == nil {
+				c.secretsReported = make(map[string]bool)
+			}
+			c.secretsReported[targetName] = true
+			return secretsFound(targetName, findings)
+		}
 	}
 
 	ts, err := c.getTargetState(nil)
@@ -150,3 +180,21 @@ func (c *Config) runAddCmd(cmd *cobra.Command, args []string) (err error) {
 	}
 	return nil
 }
+
+// previewAutoTemplate prints the substitutions made when automatically
+// generating the template for targetName and prompts the user to confirm them.
+func (c *Config) previewAutoTemplate(targetName string, substitutions []chezmoi.AutoTemplateSubstitution) (bool, error) {
+	if len(substitutions) == 0 {
+		fmt.Fprintf(c.Stdout, "%s: no substitutions\n", targetName)
+	} else {
+		fmt.Fprintf(c.Stdout, "%s:\n", targetName)
+		for _, s := range substitutions {
+			fmt.Fprintf(c.Stdout, "  %q -> %s (%d)\n", s.Value, s.Replacement, s.Count)
+		}
+	}
+	choice, err := c.prompt(fmt.Sprintf("Add %s", targetName), "yn")
+	if err != nil {
+		return false, err
+	}
+	return choice == 'y', nil
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
@@ -562,6 +563,81 @@ func TestAddCommand(t *testing.T) {
 	}
 }
 
+func TestAddAutoTemplatePreview(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		gitconfig      string
+		stdinStr       string
+		expectedStdout string
+		tests          []vfst.Test
+	}{
+		{
+			name:           "yes",
+			gitconfig:      "[user]\n\tname = John Smith\n\temail = john.smith@company.com\n",
+			stdinStr:       "y\n",
+			expectedStdout: ".gitconfig:\n  \"john.smith@company.com\" -> {{ .email }} (1)\n  \"John Smith\" -> {{ .name }} (1)\n",
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_gitconfig.tmpl",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("[user]\n\tname = {{ .name }}\n\temail = {{ .email }}\n"),
+				),
+			},
+		},
+		{
+			name:           "no",
+			gitconfig:      "[user]\n\tname = John Smith\n\temail = john.smith@company.com\n",
+			stdinStr:       "n\n",
+			expectedStdout: ".gitconfig:\n  \"john.smith@company.com\" -> {{ .email }} (1)\n  \"John Smith\" -> {{ .name }} (1)\n",
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_gitconfig.tmpl",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
+		{
+			name:           "delimiters",
+			gitconfig:      "# chezmoi:template:left-delimiter=[[ right-delimiter=]]\n[user]\n\tname = John Smith\n",
+			stdinStr:       "y\n",
+			expectedStdout: ".gitconfig:\n  \"John Smith\" -> [[ .name ]] (1)\n",
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_gitconfig.tmpl",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("# chezmoi:template:left-delimiter=[[ right-delimiter=]]\n[user]\n\tname = [[ .name ]]\n"),
+				),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user":                      &vfst.Dir{Perm: 0o755},
+				"/home/user/.local/share/chezmoi": &vfst.Dir{Perm: 0o700},
+				"/home/user/.gitconfig":           tc.gitconfig,
+			})
+			require.NoError(t, err)
+			defer cleanup()
+			stdout := &strings.Builder{}
+			c := newTestConfig(
+				fs,
+				withData(map[string]interface{}{
+					"name":  "John Smith",
+					"email": "john.smith@company.com",
+				}),
+				withAddCmdConfig(addCmdConfig{
+					preview: true,
+					options: chezmoi.AddOptions{
+						AutoTemplate: true,
+					},
+				}),
+				withStdin(strings.NewReader(tc.stdinStr)),
+				withStdout(stdout),
+			)
+			assert.NoError(t, c.runAddCmd(&cobra.Command{}, []string{"/home/user/.gitconfig"}))
+			assert.Equal(t, tc.expectedStdout, stdout.String())
+			vfst.RunTests(t, fs, "", tc.tests)
+		})
+	}
+}
+
 func TestIssue192(t *testing.T) {
 	root := []interface{}{
 		map[string]interface{}{
@@ -24,7 +24,7 @@ func (c *Config) runApplyCmd(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	defer persistentState.Close()
+	defer c.closePersistentState()
 
 	return c.applyArgs(args, persistentState)
 }
@@ -8,6 +8,10 @@ import (
 	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
+type catCmdConfig struct {
+	trace bool
+}
+
 var catCmd = &cobra.Command{
 	Use:     "cat targets...",
 	Args:    cobra.MinimumNArgs(1),
@@ -21,10 +25,26 @@ var catCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(catCmd)
 
+	persistentFlags := catCmd.PersistentFlags()
+	persistentFlags.BoolVar(&config.cat.trace, "trace", false, "write a trace of data and functions used to stderr")
+
 	markRemainingZshCompPositionalArgumentsAsFiles(catCmd, 1)
 }
 
-func (c *Config) runCatCmd(cmd *cobra.Command, args []string) error {
+func (c *Config) runCatCmd(cmd *cobra.Command, args []string) (err error) {
+	// Prevent templates from changing state, for example with vaultWrite.
+	c.mutator = chezmoi.NullMutator{}
+	if c.Debug {
+		c.mutator = chezmoi.NewDebugMutator(c.mutator, c.getRedactor())
+	}
+	if c.cat.trace {
+		c.startTemplateTrace()
+		defer func() {
+			if traceErr := c.writeTemplateTrace(c.Stderr); err == nil {
+				err = traceErr
+			}
+		}()
+	}
 	ts, err := c.getTargetState(nil)
 	if err != nil {
 		return err
@@ -35,6 +55,14 @@ func (c *Config) runCatCmd(cmd *cobra.Command, args []string) error {
 	}
 	for i, entry := range entries {
 		switch entry := entry.(type) {
+		case *chezmoi.Block:
+			contents, err := entry.Contents()
+			if err != nil {
+				return err
+			}
+			if _, err := c.Stdout.Write(contents); err != nil {
+				return err
+			}
 		case *chezmoi.File:
 			contents, err := entry.Contents()
 			if err != nil {
@@ -50,7 +78,7 @@ func (c *Config) runCatCmd(cmd *cobra.Command, args []string) error {
 			}
 			fmt.Println(linkname)
 		default:
-			return fmt.Errorf("%s: not a file or symlink", args[i])
+			return fmt.Errorf("%s: not a block, file, or symlink", args[i])
 		}
 	}
 	return nil
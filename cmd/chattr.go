@@ -70,10 +70,23 @@ func (c *Config) runChattrCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	updates := make(map[string]func() error)
-	for _, entry := range entries {
+	for i, entry := range entries {
+		if chezmoi.InArchive(entry) {
+			return fmt.Errorf("%s: in encrypted archive", args[i+1])
+		}
 		dir, oldBase := filepath.Split(entry.SourceName())
 		oldpath := filepath.Join(ts.SourceDir, dir, oldBase)
 		switch entry := entry.(type) {
+		case *chezmoi.Block:
+			ba := chezmoi.ParseBlockAttributes(oldBase)
+			ba.Template = ams.template.modify(entry.Template)
+			newBase := ba.SourceName()
+			if newBase != oldBase {
+				newpath := filepath.Join(ts.SourceDir, dir, newBase)
+				updates[oldpath] = func() error {
+					return c.mutator.Rename(oldpath, newpath)
+				}
+			}
 		case *chezmoi.Dir:
 			da := chezmoi.ParseDirAttributes(oldBase)
 			da.Exact = ams.exact.modify(entry.Exact)
@@ -100,6 +113,9 @@ func (c *Config) runChattrCmd(cmd *cobra.Command, args []string) error {
 			}
 			fa.Mode = mode
 			fa.Encrypted = ams.encrypt.modify(entry.Encrypted)
+			if entry.Sops && fa.Encrypted {
+				return fmt.Errorf("%s: already encrypted with sops", entry.TargetName())
+			}
 			fa.Empty = ams.empty.modify(entry.Empty)
 			fa.Template = ams.template.modify(entry.Template)
 			newpath := filepath.Join(ts.SourceDir, dir, fa.SourceName())
@@ -110,9 +126,9 @@ func (c *Config) runChattrCmd(cmd *cobra.Command, args []string) error {
 				}
 				var newContents []byte
 				if fa.Encrypted {
-					newContents, err = ts.GPG.Encrypt(entry.TargetName(), oldContents)
+					newContents, err = ts.Encryption.Encrypt(entry.TargetName(), oldContents)
 				} else {
-					newContents, err = ts.GPG.Decrypt(entry.TargetName(), oldContents)
+					newContents, err = ts.Encryption.Decrypt(entry.TargetName(), oldContents)
 				}
 				if err != nil {
 					return err
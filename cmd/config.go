@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"os/user"
@@ -23,6 +24,7 @@ import (
 	vfs "github.com/twpayne/go-vfs"
 	xdg "github.com/twpayne/go-xdg/v3"
 	bolt "go.etcd.io/bbolt"
+	"golang.org/x/crypto/ssh/terminal"
 	yaml "gopkg.in/yaml.v2"
 
 	"github.com/twpayne/chezmoi/internal/chezmoi"
@@ -60,8 +62,11 @@ type Config struct {
 	Verbose           bool
 	Color             string
 	Debug             bool
+	Encryption        string
+	Age               chezmoi.AgeEncryption
 	GPG               chezmoi.GPG
 	GPGRecipient      string
+	Sops              chezmoi.Sops
 	SourceVCS         sourceVCSConfig
 	Template          templateConfig
 	Merge             mergeConfig
@@ -76,11 +81,20 @@ type Config struct {
 	Vault             vaultCmdConfig
 	Pass              passCmdConfig
 	Data              map[string]interface{}
+	DataCommands      map[string]dataCommandConfig
+	Secrets           string
+	TemplateCommands  map[string]templateCommandConfig
 	colored           bool
 	maxDiffDataSize   int
 	templateFuncs     template.FuncMap
+	secretFuncs       map[string]bool
+	templateCmdFuncs  map[string]bool
+	templateTracer    *chezmoi.TemplateTracer
+	redactor          *chezmoi.Redactor
+	showSecrets       bool
 	add               addCmdConfig
 	archive           archiveCmdConfig
+	cat               catCmdConfig
 	completion        completionCmdConfig
 	data              dataCmdConfig
 	dump              dumpCmdConfig
@@ -89,16 +103,23 @@ type Config struct {
 	_import           importCmdConfig
 	init              initCmdConfig
 	keyring           keyringCmdConfig
+	lint              lintCmdConfig
 	managed           managedCmdConfig
 	purge             purgeCmdConfig
 	remove            removeCmdConfig
 	update            updateCmdConfig
 	upgrade           upgradeCmdConfig
 	Stdin             io.Reader
+	stdinReader       *bufio.Reader
 	Stdout            io.Writer
 	Stderr            io.Writer
 	bds               *xdg.BaseDirectorySpecification
+	persistentState   chezmoi.PersistentState
+	persistentStateRO bool
+	secretCacheKey    []byte
+	secretsReported   map[string]bool
 	scriptStateBucket []byte
+	skipScripts       bool
 }
 
 // A configOption sets an option on a Config.
@@ -148,11 +169,17 @@ func newConfig(options ...configOption) *Config {
 		Merge: mergeConfig{
 			Command: "vimdiff",
 		},
+		Encryption: "gpg",
+		Secrets:    secretsWarning,
 		GPG: chezmoi.GPG{
 			Command: "gpg",
 		},
+		Sops: chezmoi.Sops{
+			Command: "sops",
+		},
 		maxDiffDataSize:   1 * 1024 * 1024, // 1MB
 		templateFuncs:     sprig.TxtFuncMap(),
+		redactor:          chezmoi.NewRedactor(),
 		scriptStateBucket: []byte("script"),
 		Stdin:             os.Stdin,
 		Stdout:            os.Stdout,
@@ -174,6 +201,16 @@ func (c *Config) addTemplateFunc(key string, value interface{}) {
 	c.templateFuncs[key] = value
 }
 
+// addSecretTemplateFunc adds a template function whose results are secrets.
+// The results are recorded so that they can be redacted from output.
+func (c *Config) addSecretTemplateFunc(key string, value interface{}) {
+	c.addTemplateFunc(key, c.recordSecretsFunc(value))
+	if c.secretFuncs == nil {
+		c.secretFuncs = make(map[string]bool)
+	}
+	c.secretFuncs[key] = true
+}
+
 func (c *Config) applyArgs(args []string, persistentState chezmoi.PersistentState) error {
 	fs := vfs.NewReadOnlyFS(c.fs)
 	ts, err := c.getTargetState(nil)
@@ -187,6 +224,7 @@ func (c *Config) applyArgs(args []string, persistentState chezmoi.PersistentStat
 		PersistentState:   persistentState,
 		Remove:            c.Remove,
 		ScriptStateBucket: c.scriptStateBucket,
+		SkipScripts:       c.skipScripts,
 		Stdout:            c.Stdout,
 		Umask:             ts.Umask,
 		Verbose:           c.Verbose,
@@ -222,6 +260,9 @@ func (c *Config) autoCommit(vcs VCS) error {
 	if err != nil {
 		return err
 	}
+	if err := c.checkSourceSecrets(status); err != nil {
+		return err
+	}
 	commitMessageText, err := getAsset(commitMessageTemplateAsset)
 	if err != nil {
 		return err
@@ -312,14 +353,29 @@ func (c *Config) getData() (map[string]interface{}, error) {
 	for key, value := range c.Data {
 		data[key] = value
 	}
+	if err := c.addDataCommandData(data); err != nil {
+		return nil, err
+	}
 	return data, nil
 }
 
 func (c *Config) getDefaultData() (map[string]interface{}, error) {
+	versionStr := VersionStr
+	if versionStr == "" {
+		versionStr = "dev"
+	}
 	data := map[string]interface{}{
-		"arch":      runtime.GOARCH,
-		"os":        runtime.GOOS,
-		"sourceDir": c.SourceDir,
+		"arch":       runtime.GOARCH,
+		"configFile": c.configFile,
+		"cpus":       runtime.NumCPU(),
+		"os":         runtime.GOOS,
+		"sourceDir":  c.SourceDir,
+		"version": map[string]interface{}{
+			"builtBy": BuiltBy,
+			"commit":  Commit,
+			"date":    Date,
+			"version": versionStr,
+		},
 	}
 
 	currentUser, err := user.Current()
@@ -372,6 +428,41 @@ func (c *Config) getDefaultData() (map[string]interface{}, error) {
 	} else if err != nil {
 		return nil, err
 	}
+	data["wsl"] = isWSL(kernelInfo)
+
+	container, err := isContainer(c.fs)
+	if err != nil {
+		return nil, err
+	}
+	data["container"] = container
+
+	machineID, err := getMachineID(c.fs)
+	if err != nil {
+		return nil, err
+	}
+	if machineID != "" {
+		data["machineID"] = machineID
+	}
+
+	totalMemory, err := getTotalMemory(c.fs)
+	if err != nil {
+		return nil, err
+	}
+	if totalMemory != 0 {
+		data["totalMemory"] = totalMemory
+	}
+
+	if shell := os.Getenv("SHELL"); shell != "" {
+		data["shell"] = shell
+	}
+
+	desktop := os.Getenv("XDG_CURRENT_DESKTOP")
+	if desktop == "" {
+		desktop = os.Getenv("DESKTOP_SESSION")
+	}
+	if desktop != "" {
+		data["desktop"] = desktop
+	}
 
 	return data, nil
 }
@@ -407,6 +498,38 @@ func (c *Config) getEntries(ts *chezmoi.TargetState, args []string) ([]chezmoi.E
 	return entries, nil
 }
 
+// getEncryption returns the configured encryption.
+func (c *Config) getEncryption() (chezmoi.Encryption, error) {
+	switch c.Encryption {
+	case "age":
+		c.Age.FS = c.fs
+		if c.Age.PassphraseFunc == nil {
+			// Only prompt for the passphrase once.
+			var passphrase *string
+			c.Age.PassphraseFunc = func() (string, error) {
+				if passphrase == nil {
+					p, err := c.readPassword("Passphrase: ")
+					if err != nil {
+						return "", err
+					}
+					passphrase = &p
+				}
+				return *passphrase, nil
+			}
+		}
+		return &c.Age, nil
+	case "gpg", "":
+		// For backwards compatibility, prioritize gpgRecipient over
+		// gpg.recipient.
+		if c.GPGRecipient != "" {
+			c.GPG.Recipient = c.GPGRecipient
+		}
+		return &c.GPG, nil
+	default:
+		return nil, fmt.Errorf("%s: unknown encryption", c.Encryption)
+	}
+}
+
 func (c *Config) getPersistentState(options *bolt.Options) (chezmoi.PersistentState, error) {
 	persistentStateFile := c.getPersistentStateFile()
 	if c.DryRun {
@@ -415,7 +538,41 @@ func (c *Config) getPersistentState(options *bolt.Options) (chezmoi.PersistentSt
 		}
 		options.ReadOnly = true
 	}
-	return chezmoi.NewBoltPersistentState(c.fs, persistentStateFile, os.FileMode(c.Umask), options)
+	persistentState, err := chezmoi.NewBoltPersistentState(c.fs, persistentStateFile, os.FileMode(c.Umask), options)
+	if err != nil {
+		return nil, err
+	}
+	c.persistentState = persistentState
+	c.persistentStateRO = options != nil && options.ReadOnly
+	return persistentState, nil
+}
+
+// withPersistentState calls f with the persistent state. If no persistent
+// state is open then it is opened for the duration of the call.
+func (c *Config) withPersistentState(f func(chezmoi.PersistentState, bool) error) error {
+	if c.persistentState != nil {
+		return f(c.persistentState, c.persistentStateRO)
+	}
+	persistentState, err := c.getPersistentState(nil)
+	if err != nil {
+		return err
+	}
+	defer c.closePersistentState()
+	return f(persistentState, c.persistentStateRO)
+}
+
+// closePersistentState closes the persistent state opened by
+// getPersistentState, if any. Callers of getPersistentState must close the
+// persistent state with closePersistentState so that it is not used after it
+// is closed.
+func (c *Config) closePersistentState() error {
+	if c.persistentState == nil {
+		return nil
+	}
+	err := c.persistentState.Close()
+	c.persistentState = nil
+	c.persistentStateRO = false
+	return err
 }
 
 func (c *Config) getPersistentStateFile() string {
@@ -434,6 +591,29 @@ func (c *Config) getPersistentStateFile() string {
 func (c *Config) getTargetState(populateOptions *chezmoi.PopulateOptions) (*chezmoi.TargetState, error) {
 	fs := vfs.NewReadOnlyFS(c.fs)
 
+	ts, err := c.newTargetState()
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.Populate(fs, populateOptions); err != nil {
+		return nil, err
+	}
+	if Version != nil && ts.MinVersion != nil && Version.LessThan(*ts.MinVersion) {
+		return nil, fmt.Errorf("chezmoi version %s too old, source state requires at least %s", Version, ts.MinVersion)
+	}
+	return ts, nil
+}
+
+func (c *Config) getVCS() (VCS, error) {
+	vcs, ok := vcses[filepath.Base(c.SourceVCS.Command)]
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported source VCS command", c.SourceVCS.Command)
+	}
+	return vcs, nil
+}
+
+// newTargetState returns a new, unpopulated, target state configured from c.
+func (c *Config) newTargetState() (*chezmoi.TargetState, error) {
 	data, err := c.getData()
 	if err != nil {
 		return nil, err
@@ -447,35 +627,22 @@ func (c *Config) getTargetState(populateOptions *chezmoi.PopulateOptions) (*chez
 		}
 	}
 
-	// For backwards compatibility, prioritize gpgRecipient over gpg.recipient.
-	if c.GPGRecipient != "" {
-		c.GPG.Recipient = c.GPGRecipient
+	encryption, err := c.getEncryption()
+	if err != nil {
+		return nil, err
 	}
 
-	ts := chezmoi.NewTargetState(
+	return chezmoi.NewTargetState(
 		chezmoi.WithDestDir(destDir),
-		chezmoi.WithGPG(&c.GPG),
+		chezmoi.WithEncryption(encryption),
+		chezmoi.WithSops(&c.Sops),
 		chezmoi.WithSourceDir(c.SourceDir),
 		chezmoi.WithTemplateData(data),
 		chezmoi.WithTemplateFuncs(c.templateFuncs),
 		chezmoi.WithTemplateOptions(c.Template.Options),
+		chezmoi.WithTemplateTracer(c.templateTracer),
 		chezmoi.WithUmask(os.FileMode(c.Umask)),
-	)
-	if err := ts.Populate(fs, populateOptions); err != nil {
-		return nil, err
-	}
-	if Version != nil && ts.MinVersion != nil && Version.LessThan(*ts.MinVersion) {
-		return nil, fmt.Errorf("chezmoi version %s too old, source state requires at least %s", Version, ts.MinVersion)
-	}
-	return ts, nil
-}
-
-func (c *Config) getVCS() (VCS, error) {
-	vcs, ok := vcses[filepath.Base(c.SourceVCS.Command)]
-	if !ok {
-		return nil, fmt.Errorf("%s: unsupported source VCS command", c.SourceVCS.Command)
-	}
-	return vcs, nil
+	), nil
 }
 
 func (c *Config) output(dir, name string, argv ...string) ([]byte, error) {
@@ -492,23 +659,62 @@ func (c *Config) output(dir, name string, argv ...string) ([]byte, error) {
 
 //nolint:unparam
 func (c *Config) prompt(s, choices string) (byte, error) {
-	r := bufio.NewReader(c.Stdin)
 	for {
 		_, err := fmt.Printf("%s [%s]? ", s, strings.Join(strings.Split(choices, ""), ","))
 		if err != nil {
 			return 0, err
 		}
-		line, err := r.ReadString('\n')
+		line, err := c.readLine()
 		if err != nil {
 			return 0, err
 		}
-		line = strings.TrimSpace(line)
 		if len(line) == 1 && strings.IndexByte(choices, line[0]) != -1 {
 			return line[0], nil
 		}
 	}
 }
 
+// readPassword reads a password from the terminal, or from c.Stdin if it is
+// not a terminal.
+func (c *Config) readPassword(prompt string) (string, error) {
+	if stdin, ok := c.Stdin.(*os.File); ok && terminal.IsTerminal(int(stdin.Fd())) {
+		fmt.Fprint(c.Stderr, prompt)
+		password, err := terminal.ReadPassword(int(stdin.Fd()))
+		fmt.Fprintln(c.Stderr)
+		if err != nil {
+			return "", err
+		}
+		return string(password), nil
+	}
+	// Passwords may contain leading and trailing spaces, so only remove the
+	// line ending.
+	line, err := c.readRawLine()
+	if strings.HasSuffix(line, "\n") {
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+	}
+	return line, err
+}
+
+// readLine reads a line from c.Stdin with leading and trailing whitespace
+// removed. It returns io.EOF only if no more input is available.
+func (c *Config) readLine() (string, error) {
+	line, err := c.readRawLine()
+	return strings.TrimSpace(line), err
+}
+
+// readRawLine reads a line, including any line ending, from c.Stdin. It returns
+// io.EOF only if no more input is available.
+func (c *Config) readRawLine() (string, error) {
+	if c.stdinReader == nil {
+		c.stdinReader = bufio.NewReader(c.Stdin)
+	}
+	line, err := c.stdinReader.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return line, err
+}
+
 // run runs name argv... in dir.
 func (c *Config) run(dir, name string, argv ...string) error {
 	cmd := exec.Command(name, argv...)
@@ -530,6 +736,56 @@ func (c *Config) runEditor(argv ...string) error {
 	return c.run("", editorName, append(editorArgs, argv...)...)
 }
 
+// commandSectionNames maps the lowercase names of the config file sections
+// whose keys name commands to their documented names.
+var commandSectionNames = map[string]string{
+	"datacommands":     "dataCommands",
+	"templatecommands": "templateCommands",
+}
+
+// checkConfigFileCommandNames checks the data and template command names in
+// the config file.
+func (c *Config) checkConfigFileCommandNames() error {
+	data, err := ioutil.ReadFile(c.configFile)
+	if err != nil {
+		return err
+	}
+	return checkCommandNames(strings.TrimPrefix(filepath.Ext(c.configFile), "."), data)
+}
+
+// checkCommandNames returns an error if any of the data or template command
+// names in the config file data in format are not lowercase. Config file keys
+// are converted to lowercase when they are read, so a name that is not
+// lowercase would silently define different template data or a different
+// template function. Config file formats that cannot be decoded are not
+// checked.
+func checkCommandNames(format string, data []byte) error {
+	value, err := decodeData(format, data)
+	if err != nil {
+		return nil
+	}
+	configMap, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	for key, value := range configMap {
+		sectionName, ok := commandSectionNames[strings.ToLower(key)]
+		if !ok {
+			continue
+		}
+		commands, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for name := range commands {
+			if name != strings.ToLower(name) {
+				return fmt.Errorf("%s.%s: name must be lowercase", sectionName, name)
+			}
+		}
+	}
+	return nil
+}
+
 func (c *Config) validateData() error {
 	return validateKeys(config.Data, identifierRegexp)
 }
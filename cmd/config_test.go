@@ -10,6 +10,7 @@ import (
 
 	"github.com/Masterminds/sprig"
 	"github.com/stretchr/testify/assert"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/require"
 	vfs "github.com/twpayne/go-vfs"
 	xdg "github.com/twpayne/go-xdg/v3"
@@ -69,6 +70,65 @@ func TestAutoCommitCommitMessage(t *testing.T) {
 	}
 }
 
+func TestCheckCommandNames(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		format    string
+		data      string
+		wantError bool
+	}{
+		{
+			name:   "lowercase",
+			format: "toml",
+			data:   "[dataCommands.teamroster]\n  command = \"roster\"\n[templateCommands.corpsecret]\n  command = \"secret\"\n",
+		},
+		{
+			name:      "data_command_not_lowercase",
+			format:    "toml",
+			data:      "[dataCommands.teamRoster]\n  command = \"roster\"\n",
+			wantError: true,
+		},
+		{
+			name:      "template_command_not_lowercase",
+			format:    "yaml",
+			data:      "templateCommands:\n  corpSecret:\n    command: secret\n",
+			wantError: true,
+		},
+		{
+			name:   "data_not_lowercase",
+			format: "json",
+			data:   `{"data":{"teamRoster":"roster"}}`,
+		},
+		{
+			name:   "unknown_format",
+			format: "hcl",
+			data:   "dataCommands teamRoster {}",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkCommandNames(tc.format, []byte(tc.data))
+			if tc.wantError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestReadPassword(t *testing.T) {
+	c := newConfig(
+		withStdin(bytes.NewBufferString(" pass word \n\tsecret\r\nlast ")),
+	)
+	for _, want := range []string{" pass word ", "\tsecret", "last "} {
+		password, err := c.readPassword("Password: ")
+		require.NoError(t, err)
+		assert.Equal(t, want, password)
+	}
+	_, err := c.readPassword("Password: ")
+	assert.Equal(t, io.EOF, err)
+}
+
 func TestUpperSnakeCaseToCamelCase(t *testing.T) {
 	for s, want := range map[string]string{
 		"BUG_REPORT_URL":   "bugReportURL",
@@ -144,6 +204,17 @@ func newTestConfig(fs vfs.FS, options ...configOption) *Config {
 	)...)
 }
 
+// readTestConfigFile reads configFile into c in the same way as the root
+// command.
+func readTestConfigFile(c *Config, configFile string) error {
+	v := viper.New()
+	v.SetConfigFile(configFile)
+	if err := v.ReadInConfig(); err != nil {
+		return err
+	}
+	return v.Unmarshal(c)
+}
+
 func withAddCmdConfig(add addCmdConfig) configOption {
 	return func(c *Config) {
 		c.add = add
@@ -156,6 +227,12 @@ func withData(data map[string]interface{}) configOption {
 	}
 }
 
+func withDataCommands(dataCommands map[string]dataCommandConfig) configOption {
+	return func(c *Config) {
+		c.DataCommands = dataCommands
+	}
+}
+
 func withDestDir(destDir string) configOption {
 	return func(c *Config) {
 		c.DestDir = destDir
@@ -180,6 +257,12 @@ func withGenericSecretCmdConfig(genericSecretCmdConfig genericSecretCmdConfig) c
 	}
 }
 
+func withInitCmdConfig(initCmdConfig initCmdConfig) configOption {
+	return func(c *Config) {
+		c.init = initCmdConfig
+	}
+}
+
 func withMutator(mutator chezmoi.Mutator) configOption {
 	return func(c *Config) {
 		c.mutator = mutator
@@ -198,6 +281,12 @@ func withRemoveCmdConfig(remove removeCmdConfig) configOption {
 	}
 }
 
+func withStderr(stderr io.Writer) configOption {
+	return func(c *Config) {
+		c.Stderr = stderr
+	}
+}
+
 func withStdin(stdin io.Reader) configOption {
 	return func(c *Config) {
 		c.Stdin = stdin
@@ -210,10 +299,16 @@ func withStdout(stdout io.Writer) configOption {
 	}
 }
 
+func withTemplateCommands(templateCommands map[string]templateCommandConfig) configOption {
+	return func(c *Config) {
+		c.TemplateCommands = templateCommands
+	}
+}
+
 func withTestFS(fs vfs.FS) configOption {
 	return func(c *Config) {
 		c.fs = fs
-		c.mutator = chezmoi.NewVerboseMutator(os.Stdout, chezmoi.NewFSMutator(fs), false, 0)
+		c.mutator = chezmoi.NewVerboseMutator(os.Stdout, chezmoi.NewFSMutator(fs), false, 0, nil)
 		c.Verbose = true
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"unicode"
@@ -14,6 +15,8 @@ import (
 	"github.com/twpayne/go-vfs"
 )
 
+var containerCgroupRegexp = regexp.MustCompile(`(?m)/(docker|kubepods|lxc|containerd|libpod)[/-]`)
+
 func getKernelInfo(fs vfs.FS) (map[string]string, error) {
 	const procSysKernel = "/proc/sys/kernel"
 
@@ -47,6 +50,57 @@ func getKernelInfo(fs vfs.FS) (map[string]string, error) {
 	return kernelInfo, nil
 }
 
+// getMachineID returns the machine ID, or an empty string if it is not
+// known.
+func getMachineID(fs vfs.FS) (string, error) {
+	for _, filename := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		data, err := fs.ReadFile(filename)
+		switch {
+		case os.IsNotExist(err):
+			continue
+		case os.IsPermission(err):
+			continue
+		case err != nil:
+			return "", err
+		}
+		if machineID := string(bytes.TrimSpace(data)); machineID != "" {
+			return machineID, nil
+		}
+	}
+	return "", nil
+}
+
+// getTotalMemory returns the total memory in bytes from /proc/meminfo, or 0 if
+// it is not known.
+func getTotalMemory(fs vfs.FS) (uint64, error) {
+	f, err := fs.Open("/proc/meminfo")
+	switch {
+	case os.IsNotExist(err):
+		return 0, nil
+	case os.IsPermission(err):
+		return 0, nil
+	case err != nil:
+		return 0, err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 || fields[0] != "MemTotal:" {
+			continue
+		}
+		totalMemory, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("/proc/meminfo: %w", err)
+		}
+		if len(fields) > 2 && fields[2] == "kB" {
+			totalMemory *= 1024
+		}
+		return totalMemory, nil
+	}
+	return 0, s.Err()
+}
+
 // getOSRelease returns the operating system identification data as defined by
 // https://www.freedesktop.org/software/systemd/man/os-release.html.
 func getOSRelease(fs vfs.FS) (map[string]string, error) {
@@ -67,6 +121,36 @@ func getOSRelease(fs vfs.FS) (map[string]string, error) {
 	return nil, os.ErrNotExist
 }
 
+// isContainer returns true if chezmoi is running in a container.
+func isContainer(fs vfs.FS) (bool, error) {
+	if os.Getenv("container") != "" {
+		return true, nil
+	}
+	for _, filename := range []string{"/.dockerenv", "/run/.containerenv"} {
+		switch _, err := fs.Stat(filename); {
+		case err == nil:
+			return true, nil
+		case os.IsNotExist(err) || os.IsPermission(err):
+		default:
+			return false, err
+		}
+	}
+	data, err := fs.ReadFile("/proc/1/cgroup")
+	switch {
+	case os.IsNotExist(err) || os.IsPermission(err):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return containerCgroupRegexp.Match(data), nil
+}
+
+// isWSL returns true if kernelInfo indicates that chezmoi is running in
+// Windows Subsystem for Linux.
+func isWSL(kernelInfo map[string]string) bool {
+	return strings.Contains(strings.ToLower(kernelInfo["osrelease"]), "microsoft")
+}
+
 // maybeUnquote removes quotation marks around s.
 func maybeUnquote(s string) string {
 	// Try to unquote.
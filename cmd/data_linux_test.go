@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"bytes"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -198,3 +200,146 @@ UBUNTU_CODENAME=bionic`,
 		assert.Equal(t, tc.want, got)
 	}
 }
+
+func TestGetMachineID(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		root              interface{}
+		expectedMachineID string
+	}{
+		{
+			name: "etc_machine_id",
+			root: map[string]interface{}{
+				"/etc/machine-id":          "0123456789abcdef0123456789abcdef\n",
+				"/var/lib/dbus/machine-id": "fedcba9876543210fedcba9876543210\n",
+			},
+			expectedMachineID: "0123456789abcdef0123456789abcdef",
+		},
+		{
+			name: "dbus_machine_id",
+			root: map[string]interface{}{
+				"/etc/machine-id":          "",
+				"/var/lib/dbus/machine-id": "fedcba9876543210fedcba9876543210\n",
+			},
+			expectedMachineID: "fedcba9876543210fedcba9876543210",
+		},
+		{
+			name: "missing",
+			root: map[string]interface{}{
+				"/etc": &vfst.Dir{Perm: 0o755},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			machineID, err := getMachineID(fs)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedMachineID, machineID)
+		})
+	}
+}
+
+func TestGetTotalMemory(t *testing.T) {
+	for _, tc := range []struct {
+		name                string
+		root                interface{}
+		expectedTotalMemory uint64
+	}{
+		{
+			name: "meminfo",
+			root: map[string]interface{}{
+				"/proc/meminfo": strings.Join([]string{
+					"MemTotal:       16314028 kB",
+					"MemFree:         1198680 kB",
+				}, "\n"),
+			},
+			expectedTotalMemory: 16314028 * 1024,
+		},
+		{
+			name: "missing",
+			root: map[string]interface{}{
+				"/proc": &vfst.Dir{Perm: 0o755},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			totalMemory, err := getTotalMemory(fs)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedTotalMemory, totalMemory)
+		})
+	}
+}
+
+func TestIsContainer(t *testing.T) {
+	if os.Getenv("container") != "" {
+		t.Skip("container environment variable set")
+	}
+	for _, tc := range []struct {
+		name              string
+		root              interface{}
+		expectedContainer bool
+	}{
+		{
+			name: "dockerenv",
+			root: map[string]interface{}{
+				"/.dockerenv": "",
+			},
+			expectedContainer: true,
+		},
+		{
+			name: "podman",
+			root: map[string]interface{}{
+				"/run/.containerenv": "",
+			},
+			expectedContainer: true,
+		},
+		{
+			name: "cgroup_docker",
+			root: map[string]interface{}{
+				"/proc/1/cgroup": "12:pids:/docker/0123456789abcdef\n",
+			},
+			expectedContainer: true,
+		},
+		{
+			name: "cgroup_host",
+			root: map[string]interface{}{
+				"/proc/1/cgroup": "0::/init.scope\n",
+			},
+			expectedContainer: false,
+		},
+		{
+			name: "missing",
+			root: map[string]interface{}{
+				"/proc": &vfst.Dir{Perm: 0o755},
+			},
+			expectedContainer: false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			container, err := isContainer(fs)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedContainer, container)
+		})
+	}
+}
+
+func TestIsWSL(t *testing.T) {
+	assert.True(t, isWSL(map[string]string{
+		"osrelease": "4.19.81-microsoft-standard",
+	}))
+	assert.True(t, isWSL(map[string]string{
+		"osrelease": "4.4.0-18362-Microsoft",
+	}))
+	assert.False(t, isWSL(map[string]string{
+		"osrelease": "5.4.0-42-generic",
+	}))
+	assert.False(t, isWSL(nil))
+}
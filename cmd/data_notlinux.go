@@ -8,6 +8,22 @@ func getKernelInfo(fs vfs.FS) (map[string]string, error) {
 	return nil, nil
 }
 
+func getMachineID(fs vfs.FS) (string, error) {
+	return "", nil
+}
+
 func getOSRelease(fs vfs.FS) (map[string]string, error) {
 	return nil, nil
 }
+
+func getTotalMemory(fs vfs.FS) (uint64, error) {
+	return 0, nil
+}
+
+func isContainer(fs vfs.FS) (bool, error) {
+	return false, nil
+}
+
+func isWSL(kernelInfo map[string]string) bool {
+	return false
+}
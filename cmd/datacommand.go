@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var (
+	dataCommandCache       = make(map[string]interface{})
+	dataCommandStateBucket = []byte("dataCommand")
+)
+
+type dataCommandConfig struct {
+	Command string
+	Args    []string
+	Format  string
+	TTL     time.Duration
+}
+
+// A dataCommandState is the cached output of a data command.
+type dataCommandState struct {
+	Command   string    `json:"command"`
+	Args      []string  `json:"args"`
+	FetchedAt time.Time `json:"fetchedAt"`
+	Output    []byte    `json:"output"`
+}
+
+// addDataCommandData runs all data commands and adds their output to data.
+func (c *Config) addDataCommandData(data map[string]interface{}) error {
+	names := make([]string, 0, len(c.DataCommands))
+	for name := range c.DataCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "chezmoi" {
+			return fmt.Errorf("dataCommands.%s: reserved name", name)
+		}
+		value, err := c.getDataCommandValue(name, c.DataCommands[name])
+		if err != nil {
+			return fmt.Errorf("dataCommands.%s: %w", name, err)
+		}
+		data[name] = value
+	}
+	return nil
+}
+
+// getDataCommandValue returns the parsed output of the data command name. The
+// command is run at most once per invocation and, if dc.TTL is set, its output
+// is cached in the persistent state.
+func (c *Config) getDataCommandValue(name string, dc dataCommandConfig) (interface{}, error) {
+	if value, ok := dataCommandCache[name]; ok {
+		return value, nil
+	}
+	if dc.Command == "" {
+		return nil, fmt.Errorf("command not set")
+	}
+	format := dc.Format
+	if format == "" {
+		format = "json"
+	}
+
+	var output []byte
+	if dc.TTL > 0 {
+		var err error
+		output, err = c.getCachedDataCommandOutput(name, dc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if output == nil {
+		cmd := exec.Command(dc.Command, dc.Args...)
+		cmd.Stdin = c.Stdin
+		cmd.Stderr = c.Stderr
+		var err error
+		output, err = c.mutator.IdempotentCmdOutput(cmd)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", dc.Command, chezmoi.ShellQuoteArgs(dc.Args), err)
+		}
+		if dc.TTL > 0 {
+			if err := c.setCachedDataCommandOutput(name, dc, output); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	value, err := decodeData(format, output)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", dc.Command, chezmoi.ShellQuoteArgs(dc.Args), err)
+	}
+	dataCommandCache[name] = value
+	return value, nil
+}
+
+// getCachedDataCommandOutput returns the cached output of the data command
+// name, or nil if there is no valid cached output.
+func (c *Config) getCachedDataCommandOutput(name string, dc dataCommandConfig) ([]byte, error) {
+	var output []byte
+	if err := c.withPersistentState(func(persistentState chezmoi.PersistentState, readOnly bool) error {
+		data, err := persistentState.Get(dataCommandStateBucket, []byte(name))
+		if err != nil || data == nil {
+			return err
+		}
+		var state dataCommandState
+		if err := json.Unmarshal(data, &state); err != nil {
+			// Ignore invalid cached output.
+			return nil //nolint:nilerr
+		}
+		if state.Command != dc.Command || strings.Join(state.Args, "\x00") != strings.Join(dc.Args, "\x00") {
+			return nil
+		}
+		if time.Since(state.FetchedAt) > dc.TTL {
+			return nil
+		}
+		output = state.Output
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// setCachedDataCommandOutput caches output as the output of the data command
+// name.
+func (c *Config) setCachedDataCommandOutput(name string, dc dataCommandConfig, output []byte) error {
+	data, err := json.Marshal(&dataCommandState{
+		Command:   dc.Command,
+		Args:      dc.Args,
+		FetchedAt: time.Now(),
+		Output:    output,
+	})
+	if err != nil {
+		return err
+	}
+	return c.withPersistentState(func(persistentState chezmoi.PersistentState, readOnly bool) error {
+		if readOnly {
+			return nil
+		}
+		return persistentState.Set(dataCommandStateBucket, []byte(name), data)
+	})
+}
+
+// decodeData decodes data in format, which must be json, toml, or yaml.
+func decodeData(format string, data []byte) (interface{}, error) {
+	switch strings.ToLower(format) {
+	case "json":
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	case "toml":
+		tree, err := toml.LoadBytes(data)
+		if err != nil {
+			return nil, err
+		}
+		return tree.ToMap(), nil
+	case "yaml", "yml":
+		var value interface{}
+		if err := yaml.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+		return normalizeYAML(value), nil
+	default:
+		return nil, fmt.Errorf("%s: unknown format", format)
+	}
+}
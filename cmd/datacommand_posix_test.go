@@ -0,0 +1,199 @@
+// +build !windows
+
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestDataCommands(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		dataCommands  map[string]dataCommandConfig
+		cachedState   *dataCommandState
+		expectedData  map[string]interface{}
+		expectedError bool
+	}{
+		{
+			name: "json",
+			dataCommands: map[string]dataCommandConfig{
+				"cmd": {
+					Command: "echo",
+					Args:    []string{`{"key":"value"}`},
+				},
+			},
+			expectedData: map[string]interface{}{
+				"cmd": map[string]interface{}{
+					"key": "value",
+				},
+			},
+		},
+		{
+			name: "yaml",
+			dataCommands: map[string]dataCommandConfig{
+				"cmd": {
+					Command: "echo",
+					Args:    []string{"key: value"},
+					Format:  "yaml",
+				},
+			},
+			expectedData: map[string]interface{}{
+				"cmd": map[string]interface{}{
+					"key": "value",
+				},
+			},
+		},
+		{
+			name: "cached",
+			dataCommands: map[string]dataCommandConfig{
+				"cmd": {
+					Command: "echo",
+					Args:    []string{`{"key":"value"}`},
+					TTL:     time.Hour,
+				},
+			},
+			cachedState: &dataCommandState{
+				Command:   "echo",
+				Args:      []string{`{"key":"value"}`},
+				FetchedAt: time.Now(),
+				Output:    []byte(`{"key":"cached"}`),
+			},
+			expectedData: map[string]interface{}{
+				"cmd": map[string]interface{}{
+					"key": "cached",
+				},
+			},
+		},
+		{
+			name: "cache_expired",
+			dataCommands: map[string]dataCommandConfig{
+				"cmd": {
+					Command: "echo",
+					Args:    []string{`{"key":"value"}`},
+					TTL:     time.Hour,
+				},
+			},
+			cachedState: &dataCommandState{
+				Command:   "echo",
+				Args:      []string{`{"key":"value"}`},
+				FetchedAt: time.Now().Add(-2 * time.Hour),
+				Output:    []byte(`{"key":"cached"}`),
+			},
+			expectedData: map[string]interface{}{
+				"cmd": map[string]interface{}{
+					"key": "value",
+				},
+			},
+		},
+		{
+			name: "cache_different_args",
+			dataCommands: map[string]dataCommandConfig{
+				"cmd": {
+					Command: "echo",
+					Args:    []string{`{"key":"value"}`},
+					TTL:     time.Hour,
+				},
+			},
+			cachedState: &dataCommandState{
+				Command:   "echo",
+				Args:      []string{`{"key":"other"}`},
+				FetchedAt: time.Now(),
+				Output:    []byte(`{"key":"cached"}`),
+			},
+			expectedData: map[string]interface{}{
+				"cmd": map[string]interface{}{
+					"key": "value",
+				},
+			},
+		},
+		{
+			name: "reserved_name",
+			dataCommands: map[string]dataCommandConfig{
+				"chezmoi": {
+					Command: "echo",
+					Args:    []string{"{}"},
+				},
+			},
+			expectedError: true,
+		},
+		{
+			name: "invalid_output",
+			dataCommands: map[string]dataCommandConfig{
+				"cmd": {
+					Command: "echo",
+					Args:    []string{"not json"},
+				},
+			},
+			expectedError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dataCommandCache = make(map[string]interface{})
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user": &vfst.Dir{Perm: 0o755},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			c := newTestConfig(
+				fs,
+				withDataCommands(tc.dataCommands),
+			)
+			if tc.cachedState != nil {
+				value, err := json.Marshal(tc.cachedState)
+				require.NoError(t, err)
+				require.NoError(t, c.withPersistentState(func(persistentState chezmoi.PersistentState, readOnly bool) error {
+					return persistentState.Set(dataCommandStateBucket, []byte("cmd"), value)
+				}))
+			}
+
+			data, err := c.getData()
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			delete(data, "chezmoi")
+			assert.Equal(t, tc.expectedData, data)
+		})
+	}
+}
+
+func TestDataCommandsAfterPersistentStateClosed(t *testing.T) {
+	dataCommandCache = make(map[string]interface{})
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0o755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(
+		fs,
+		withDataCommands(map[string]dataCommandConfig{
+			"cmd": {
+				Command: "echo",
+				Args:    []string{`{"key":"value"}`},
+				TTL:     time.Hour,
+			},
+		}),
+	)
+
+	// A data command lookup after a command has closed the persistent state
+	// opens it again instead of using the closed state.
+	_, err = c.getPersistentState(nil)
+	require.NoError(t, err)
+	require.NoError(t, c.closePersistentState())
+	data, err := c.getData()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"key": "value",
+	}, data["cmd"])
+}
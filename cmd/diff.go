@@ -54,7 +54,7 @@ func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("unknown diff format: %q", c.Diff.Format)
 	}
 	if c.Debug {
-		c.mutator = chezmoi.NewDebugMutator(c.mutator)
+		c.mutator = chezmoi.NewDebugMutator(c.mutator, c.getRedactor())
 	}
 
 	persistentState, err := c.getPersistentState(&bolt.Options{
@@ -63,18 +63,18 @@ func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	defer persistentState.Close()
+	defer c.closePersistentState()
 
 	if c.Diff.NoPager || c.Diff.Pager == "" {
 		switch c.Diff.Format {
 		case "chezmoi":
-			c.mutator = chezmoi.NewVerboseMutator(c.Stdout, c.mutator, c.colored, c.maxDiffDataSize)
+			c.mutator = chezmoi.NewVerboseMutator(c.Stdout, c.mutator, c.colored, c.maxDiffDataSize, c.getRedactor())
 		case "git":
 			unifiedEncoder := diff.NewUnifiedEncoder(c.Stdout, diff.DefaultContextLines)
 			if c.colored {
 				unifiedEncoder.SetColor(diff.NewColorConfig())
 			}
-			c.mutator = chezmoi.NewGitDiffMutator(unifiedEncoder, c.mutator, c.DestDir+string(filepath.Separator))
+			c.mutator = chezmoi.NewGitDiffMutator(unifiedEncoder, c.mutator, c.DestDir+string(filepath.Separator), c.getRedactor())
 		}
 		return c.applyArgs(args, persistentState)
 	}
@@ -105,13 +105,13 @@ func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 
 	switch c.Diff.Format {
 	case "chezmoi":
-		c.mutator = chezmoi.NewVerboseMutator(pagerStdinPipe, c.mutator, c.colored, c.maxDiffDataSize)
+		c.mutator = chezmoi.NewVerboseMutator(pagerStdinPipe, c.mutator, c.colored, c.maxDiffDataSize, c.getRedactor())
 	case "git":
 		unifiedEncoder := diff.NewUnifiedEncoder(pagerStdinPipe, diff.DefaultContextLines)
 		if c.colored {
 			unifiedEncoder.SetColor(diff.NewColorConfig())
 		}
-		c.mutator = chezmoi.NewGitDiffMutator(unifiedEncoder, c.mutator, c.DestDir+string(filepath.Separator))
+		c.mutator = chezmoi.NewGitDiffMutator(unifiedEncoder, c.mutator, c.DestDir+string(filepath.Separator), c.getRedactor())
 	}
 
 	if err := c.applyArgs(args, persistentState); err != nil {
@@ -1,4 +1,5 @@
 // Code generated by github.com/twpayne/chezmoi/internal/generate-assets. DO NOT EDIT.
+//go:build !noembeddocs
 // +build !noembeddocs
 
 package cmd
@@ -523,6 +524,7 @@ func init() {
 		"* [Include a subdirectory from another repository, like Oh My Zsh](#include-a-subdirectory-from-another-repository-like-oh-my-zsh)\n" +
 		"* [Handle configuration files which are externally modified](#handle-configuration-files-which-are-externally-modified)\n" +
 		"* [Keep data private](#keep-data-private)\n" +
+		"  * [Use age to keep your secrets](#use-age-to-keep-your-secrets)\n" +
 		"  * [Use Bitwarden to keep your secrets](#use-bitwarden-to-keep-your-secrets)\n" +
 		"  * [Use gopass to keep your secrets](#use-gopass-to-keep-your-secrets)\n" +
 		"  * [Use gpg to keep your secrets](#use-gpg-to-keep-your-secrets)\n" +
@@ -531,9 +533,12 @@ func init() {
 		"  * [Use LastPass to keep your secrets](#use-lastpass-to-keep-your-secrets)\n" +
 		"  * [Use 1Password to keep your secrets](#use-1password-to-keep-your-secrets)\n" +
 		"  * [Use pass to keep your secrets](#use-pass-to-keep-your-secrets)\n" +
+		"  * [Use sops to keep your secrets](#use-sops-to-keep-your-secrets)\n" +
 		"  * [Use Vault to keep your secrets](#use-vault-to-keep-your-secrets)\n" +
 		"  * [Use a generic tool to keep your secrets](#use-a-generic-tool-to-keep-your-secrets)\n" +
 		"  * [Use templates variables to keep your secrets](#use-templates-variables-to-keep-your-secrets)\n" +
+		"  * [Cache secrets between invocations](#cache-secrets-between-invocations)\n" +
+		"  * [Encrypt whole directories](#encrypt-whole-directories)\n" +
 		"* [Use scripts to perform actions](#use-scripts-to-perform-actions)\n" +
 		"  * [Understand how scripts work](#understand-how-scripts-work)\n" +
 		"  * [Install packages with scripts](#install-packages-with-scripts)\n" +
@@ -673,6 +678,12 @@ func init() {
 		"    [user]\n" +
 		"      email = \"{{ .email }}\"\n" +
 		"\n" +
+		"To review the substitutions before the template is written, add the\n" +
+		"`--preview` flag. To ignore short values, which are more likely to match\n" +
+		"unintentionally, use `--autotemplate-min-length`:\n" +
+		"\n" +
+		"    chezmoi add --autotemplate --preview --autotemplate-min-length=4 ~/.gitconfig\n" +
+		"\n" +
 		"To disable automatic variable detection, use the `--template` or `-T` option to\n" +
 		"`chezmoi add` instead of `--autotemplate`.\n" +
 		"\n" +
@@ -805,6 +816,22 @@ func init() {
 		"\n" +
 		"Then `chezmoi init` will create an initial `chezmoi.toml` using this template.\n" +
 		"`promptString` is a special function that prompts the user (you) for a value.\n" +
+		"`promptBool`, `promptChoice`, and `promptInt` prompt for booleans, one of a list\n" +
+		"of choices, and integers respectively. All of them accept an optional default,\n" +
+		"and the values from your existing config file are available to the template, so\n" +
+		"you can re-use your previous answers when you run `chezmoi init` again:\n" +
+		"\n" +
+		"    {{- $email := promptString \"email\" (index . \"email\") -}}\n" +
+		"    {{- $work := promptBool \"work machine\" (index . \"work\") -}}\n" +
+		"    [data]\n" +
+		"        email = \"{{ $email }}\"\n" +
+		"        work = {{ $work }}\n" +
+		"\n" +
+		"To run `chezmoi init` unattended, for example in CI or when building a\n" +
+		"container, pass the answers with `--promptString`, `--promptBool`,\n" +
+		"`--promptChoice`, and `--promptInt` flags or in a file with `--promptFile`:\n" +
+		"\n" +
+		"    chezmoi init --apply --promptString email=john@home.org --promptBool \"work machine=false\" https://github.com/user/dotfiles.git\n" +
 		"\n" +
 		"To test this template, use `chezmoi execute-template` with the `--init` and\n" +
 		"`--promptString` flags, for example:\n" +
@@ -921,6 +948,50 @@ func init() {
 		"There are several ways to keep these tokens secure, and to prevent them leaving\n" +
 		"your machine.\n" +
 		"\n" +
+		"When you add a file without `--encrypt`, or when chezmoi automatically commits\n" +
+		"changes to your source directory, chezmoi scans the file for things that look\n" +
+		"like secrets, such as private keys, API tokens, and high-entropy strings, and\n" +
+		"prints a warning if it finds any. To refuse to add or commit such files\n" +
+		"instead, set `secrets` to `error` in your configuration file:\n" +
+		"\n" +
+		"    secrets = \"error\"\n" +
+		"\n" +
+		"To disable the scan, set `secrets` to `ignore`.\n" +
+		"\n" +
+		"### Use age to keep your secrets\n" +
+		"\n" +
+		"chezmoi includes native support for encrypting files with\n" +
+		"[age](https://age-encryption.org), which does not require any external programs\n" +
+		"or agents. To use age, set `encryption` to `age` in your configuration file and\n" +
+		"specify an identity file, for example one created with `age-keygen`:\n" +
+		"\n" +
+		"    encryption = \"age\"\n" +
+		"    [age]\n" +
+		"      identity = \"/home/user/.config/chezmoi/key.txt\"\n" +
+		"\n" +
+		"Files are encrypted to the recipients of the identities in the identity file.\n" +
+		"To encrypt to other recipients, for example the public keys of your other\n" +
+		"machines, list them explicitly:\n" +
+		"\n" +
+		"    [age]\n" +
+		"      identity = \"/home/user/.config/chezmoi/key.txt\"\n" +
+		"      recipients = [\"age1...\", \"age1...\"]\n" +
+		"\n" +
+		"Alternatively, to encrypt and decrypt with a passphrase, set `age.passphrase`:\n" +
+		"\n" +
+		"    [age]\n" +
+		"      passphrase = true\n" +
+		"\n" +
+		"chezmoi will prompt for the passphrase once per invocation. Add files to be\n" +
+		"encrypted with the `--encrypt` flag, for example:\n" +
+		"\n" +
+		"    chezmoi add --encrypt ~/.ssh/id_rsa\n" +
+		"\n" +
+		"Encrypted files are stored ASCII-armored in the source state and are\n" +
+		"automatically decrypted when generating the target state or printing a file's\n" +
+		"contents with `chezmoi cat`. `chezmoi edit` and `chezmoi chattr` use the\n" +
+		"configured encryption.\n" +
+		"\n" +
 		"### Use Bitwarden to keep your secrets\n" +
 		"\n" +
 		"chezmoi includes support for [Bitwarden](https://bitwarden.com/) using the\n" +
@@ -931,11 +1002,14 @@ func init() {
 		"\n" +
 		"    bw login <bitwarden-email>\n" +
 		"\n" +
-		"Unlock your Bitwarden vault:\n" +
+		"If your Bitwarden vault is locked when chezmoi needs a secret, chezmoi will run\n" +
+		"`bw unlock` once, prompting you for your master password, and use the session\n" +
+		"key for the rest of the command. To avoid being prompted on every invocation,\n" +
+		"unlock your vault yourself:\n" +
 		"\n" +
 		"    bw unlock\n" +
 		"\n" +
-		"Set the `BW_SESSION` environment variable, as instructed.\n" +
+		"and set the `BW_SESSION` environment variable, as instructed.\n" +
 		"\n" +
 		"The structured data from `bw get` is available as the `bitwarden` template\n" +
 		"function in your config files, for example:\n" +
@@ -943,6 +1017,12 @@ func init() {
 		"    username = {{ (bitwarden \"item\" \"example.com\").login.username }}\n" +
 		"    password = {{ (bitwarden \"item\" \"example.com\").login.password }}\n" +
 		"\n" +
+		"Custom fields are available, keyed by name, with the `bitwardenFields` template\n" +
+		"function, and attachments with the `bitwardenAttachment` template function:\n" +
+		"\n" +
+		"    token = {{ (bitwardenFields \"item\" \"example.com\").token.value }}\n" +
+		"    {{- bitwardenAttachment \"id_rsa\" \"<itemid>\" }}\n" +
+		"\n" +
 		"### Use gopass to keep your secrets\n" +
 		"\n" +
 		"chezmoi includes support for [gopass](https://www.gopass.pw/) using the gopass CLI.\n" +
@@ -952,6 +1032,12 @@ func init() {
 		"\n" +
 		"    {{ gopass \"<pass-name>\" }}\n" +
 		"\n" +
+		"If your secrets follow the convention of a password on the first line followed\n" +
+		"by `key: value` lines, the fields are available with the `gopassFields` template\n" +
+		"function, and the entire secret with the `gopassRaw` template function:\n" +
+		"\n" +
+		"    login = {{ (gopassFields \"<pass-name>\").login }}\n" +
+		"\n" +
 		"### Use gpg to keep your secrets\n" +
 		"\n" +
 		"chezmoi supports encrypting files with [gpg](https://www.gnupg.org/). Encrypted\n" +
@@ -960,6 +1046,9 @@ func init() {
 		"`chezmoi edit` will transparently decrypt the file before editing and re-encrypt\n" +
 		"it afterwards.\n" +
 		"\n" +
+		"gpg is the default encryption. To select it explicitly, set `encryption` to\n" +
+		"`gpg` in your configuration file.\n" +
+		"\n" +
 		"#### Asymmetric (private/public-key) encryption\n" +
 		"\n" +
 		"Specify the encryption key to use in your configuration file (`chezmoi.toml`)\n" +
@@ -976,6 +1065,17 @@ func init() {
 		"\n" +
 		"    gpg --armor --recipient ${gpg.recipient} --encrypt\n" +
 		"\n" +
+		"To encrypt files for several people, for example everyone on a team, list their\n" +
+		"keys in `gpg.recipients`:\n" +
+		"\n" +
+		"    [gpg]\n" +
+		"      recipients = [\"alice@example.com\", \"bob@example.com\"]\n" +
+		"\n" +
+		"When the list of recipients changes, re-encrypt all encrypted files for the new\n" +
+		"recipients with:\n" +
+		"\n" +
+		"    chezmoi rekey\n" +
+		"\n" +
 		"and store the encrypted file in the source state. The file will automatically be\n" +
 		"decrypted when generating the target state.\n" +
 		"\n" +
@@ -1016,6 +1116,19 @@ func init() {
 		"\n" +
 		"    {{ keepassxcAttribute \"SSH Key\" \"private-key\" }}\n" +
 		"\n" +
+		"Attachments are available through the `keepassxcAttachment` function:\n" +
+		"\n" +
+		"    {{- keepassxcAttachment \"SSH Key\" \"id_rsa\" -}}\n" +
+		"\n" +
+		"chezmoi can also read your database directly, without `keepassxc-cli`, which is\n" +
+		"faster and only prompts for your password once. Set `mode` to `builtin`, and\n" +
+		"`keyFile` if your database uses a key file:\n" +
+		"\n" +
+		"    [keepassxc]\n" +
+		"      database = \"/home/user/Passwords.kdbx\"\n" +
+		"      keyFile = \"/home/user/Passwords.keyx\"\n" +
+		"      mode = \"builtin\"\n" +
+		"\n" +
 		"### Use a keyring to keep your secrets\n" +
 		"\n" +
 		"chezmoi includes support for Keychain (on macOS), GNOME Keyring (on Linux), and\n" +
@@ -1094,6 +1207,10 @@ func init() {
 		"\n" +
 		"    eval $(op signin <subdomain>.1password.com <email>)\n" +
 		"\n" +
+		"If you are not signed in when chezmoi needs a secret, chezmoi will run `op\n" +
+		"signin` once, prompting you for your master password, and use the session token\n" +
+		"for the rest of the command.\n" +
+		"\n" +
 		"The output of `op get item <uuid>` is available as the `onepassword` template\n" +
 		"function. chezmoi parses the JSON output and returns it as structured data. For\n" +
 		"example, if the output of `op get item \"<uuid>\"` is:\n" +
@@ -1109,6 +1226,12 @@ func init() {
 		"\n" +
 		"    {{ (onepassword \"<uuid>\").details.password }}\n" +
 		"\n" +
+		"The `onepasswordDetailsFields` and `onepasswordItemFields` template functions\n" +
+		"return an item's fields keyed by their designation or label, which is simpler\n" +
+		"than searching the raw output:\n" +
+		"\n" +
+		"    {{ (onepasswordDetailsFields \"<uuid>\").password.value }}\n" +
+		"\n" +
 		"Documents can be retrieved with:\n" +
 		"\n" +
 		"    {{- onepasswordDocument \"uuid\" -}}\n" +
@@ -1128,6 +1251,53 @@ func init() {
 		"\n" +
 		"    {{ pass \"<pass-name>\" }}\n" +
 		"\n" +
+		"If your entries follow the convention of a password on the first line followed\n" +
+		"by `key: value` lines, the fields are available with the `passFields` template\n" +
+		"function, and the entire entry with the `passRaw` template function:\n" +
+		"\n" +
+		"    login = {{ (passFields \"<pass-name>\").login }}\n" +
+		"\n" +
+		"To use a password store other than the default, set `pass.storeDir` in your\n" +
+		"config file. Additional password stores can be given names in `pass.stores` and\n" +
+		"passed as an extra argument to the `pass`, `passFields`, and `passRaw` template\n" +
+		"functions:\n" +
+		"\n" +
+		"    [pass]\n" +
+		"        storeDir = \"/home/user/.password-store\"\n" +
+		"        [pass.stores]\n" +
+		"            work = \"/home/user/.password-store-work\"\n" +
+		"\n" +
+		"and then use them in your templates, for example:\n" +
+		"\n" +
+		"    {{ pass \"<pass-name>\" \"work\" }}\n" +
+		"\n" +
+		"### Use sops to keep your secrets\n" +
+		"\n" +
+		"chezmoi includes support for [sops](https://github.com/mozilla/sops) using the\n" +
+		"sops CLI. sops encrypts only the values in structured files, such as YAML and\n" +
+		"JSON, leaving the keys readable, and can use age, gpg, and cloud key management\n" +
+		"services.\n" +
+		"\n" +
+		"Files in your source directory with the `sops_` prefix are decrypted with\n" +
+		"`sops --decrypt` when generating the target state. sops uses the file's\n" +
+		"extension to determine its format, so keep the extension of the target file in\n" +
+		"the source file's name, for example `sops_private_dot_secrets.yaml`. Create and\n" +
+		"edit these files with sops itself, as `chezmoi add` and `chezmoi edit` refuse to\n" +
+		"modify them.\n" +
+		"\n" +
+		"The decrypted contents of a sops-encrypted file are also available in templates\n" +
+		"with the `sopsDecrypt` and `sopsDecryptData` template functions, for example:\n" +
+		"\n" +
+		"    {{- $secrets := sopsDecryptData \".secrets.yaml\" }}\n" +
+		"    [github]\n" +
+		"        user = {{ $secrets.github.user | quote }}\n" +
+		"        token = {{ $secrets.github.token | quote }}\n" +
+		"\n" +
+		"Extra arguments to sops can be set in your config file:\n" +
+		"\n" +
+		"    [sops]\n" +
+		"        args = [\"--config\", \"/home/user/.sops.yaml\"]\n" +
+		"\n" +
 		"### Use Vault to keep your secrets\n" +
 		"\n" +
 		"chezmoi includes support for [Vault](https://www.vaultproject.io/) using the\n" +
@@ -1146,6 +1316,21 @@ func init() {
 		"\n" +
 		"    {{ (vault \"<key>\").data.data.password }}\n" +
 		"\n" +
+		"The Vault server, namespace, and KV secrets engine mount can be set in your\n" +
+		"config file:\n" +
+		"\n" +
+		"    [vault]\n" +
+		"        address = \"https://vault.example.com:8200\"\n" +
+		"        namespace = \"team\"\n" +
+		"        mount = \"secret\"\n" +
+		"\n" +
+		"Individual fields and specific versions can be read with `vaultField`, and\n" +
+		"secrets from other secrets engines, like dynamic database credentials or PKI\n" +
+		"certificates, with `vaultRead` and `vaultWrite`:\n" +
+		"\n" +
+		"    password = {{ vaultField \"<key>\" \"password\" }}\n" +
+		"    username = {{ (vaultRead \"database/creds/readonly\").data.username }}\n" +
+		"\n" +
 		"### Use a generic tool to keep your secrets\n" +
 		"\n" +
 		"You can use any command line tool that outputs secrets either as a string or in\n" +
@@ -1164,6 +1349,27 @@ func init() {
 		"| KeePassXC       | `keepassxc-cli`         | Not possible (interactive command only)           |\n" +
 		"| pass            | `pass`                  | `{{ secret \"show\" <id> }}`                        |\n" +
 		"\n" +
+		"To use several tools, or to give them template functions with their own names,\n" +
+		"declare them in the `templateCommands` section of your configuration file. Each\n" +
+		"template command has a command, optional argument templates, and an output\n" +
+		"format (`raw`, `trim`, `json`, or `yaml`), for example:\n" +
+		"\n" +
+		"    [templateCommands.corpsecret]\n" +
+		"      command = \"corp-secrets\"\n" +
+		"      args = [\"get\", \"--format=json\", \"{{ index . 0 }}\"]\n" +
+		"      format = \"json\"\n" +
+		"      secret = true\n" +
+		"\n" +
+		"Then, in your templates:\n" +
+		"\n" +
+		"    token = {{ (corpsecret \"github\").token }}\n" +
+		"\n" +
+		"Template command names are case-insensitive and are converted to lowercase, so\n" +
+		"use lowercase names in your templates.\n" +
+		"\n" +
+		"See the [reference manual](https://github.com/twpayne/chezmoi/blob/master/docs/REFERENCE.md#template-commands)\n" +
+		"for details.\n" +
+		"\n" +
 		"### Use templates variables to keep your secrets\n" +
 		"\n" +
 		"Typically, `~/.config/chezmoi/chezmoi.toml` is not checked in to version control\n" +
@@ -1186,6 +1392,40 @@ func init() {
 		"Any config files containing tokens in plain text should be private (permissions\n" +
 		"`0600`).\n" +
 		"\n" +
+		"### Cache secrets between invocations\n" +
+		"\n" +
+		"By default, chezmoi retrieves each secret from your secret manager once per\n" +
+		"invocation. To avoid retrieving secrets, and possibly having to re-authenticate,\n" +
+		"every time you run chezmoi, set the `cacheTTL` configuration variable for your\n" +
+		"secret manager, for example:\n" +
+		"\n" +
+		"    [bitwarden]\n" +
+		"      cacheTTL = \"8h\"\n" +
+		"\n" +
+		"chezmoi will then cache the secret manager's output, encrypted, in its\n" +
+		"persistent state for up to eight hours. To clear the cache, for example after\n" +
+		"changing a secret, run:\n" +
+		"\n" +
+		"    chezmoi secret cache clear\n" +
+		"\n" +
+		"### Encrypt whole directories\n" +
+		"\n" +
+		"Encrypting individual files hides their contents, but not their names or the\n" +
+		"structure of the directories that contain them. To hide these too, add a\n" +
+		"directory with the `--archive` flag, for example:\n" +
+		"\n" +
+		"    chezmoi add --archive ~/.aws\n" +
+		"\n" +
+		"chezmoi will store the entire contents of the directory, including\n" +
+		"subdirectories and symlinks, as a single encrypted archive called\n" +
+		"`.chezmoiarchive` in the source state, using the configured encryption.\n" +
+		"chezmoi expands the archive into the target state automatically, so commands\n" +
+		"like `apply`, `diff`, and `cat` work as normal.\n" +
+		"\n" +
+		"Files in an archive cannot be edited with `chezmoi edit` or changed with\n" +
+		"`chezmoi chattr`. Instead, edit the files in your home directory and re-add the\n" +
+		"directory with `chezmoi add --archive`.\n" +
+		"\n" +
 		"## Use scripts to perform actions\n" +
 		"\n" +
 		"### Understand how scripts work\n" +
@@ -1527,14 +1767,19 @@ func init() {
 		"  * [`-n`, `--dry-run`](#-n---dry-run)\n" +
 		"  * [`-h`, `--help`](#-h---help)\n" +
 		"  * [`-r`. `--remove`](#-r---remove)\n" +
+		"  * [`--show-secrets`](#--show-secrets)\n" +
 		"  * [`-S`, `--source` *directory*](#-s---source-directory)\n" +
 		"  * [`-v`, `--verbose`](#-v---verbose)\n" +
 		"  * [`--version`](#--version)\n" +
 		"* [Configuration file](#configuration-file)\n" +
 		"  * [Configuration variables](#configuration-variables)\n" +
+		"  * [Data commands](#data-commands)\n" +
+		"  * [Template commands](#template-commands)\n" +
 		"* [Source state attributes](#source-state-attributes)\n" +
+		"  * [Managed blocks](#managed-blocks)\n" +
 		"* [Special files and directories](#special-files-and-directories)\n" +
 		"  * [`.chezmoi.<format>.tmpl`](#chezmoiformattmpl)\n" +
+		"  * [`.chezmoiarchive`](#chezmoiarchive)\n" +
 		"  * [`.chezmoiignore`](#chezmoiignore)\n" +
 		"  * [`.chezmoiremove`](#chezmoiremove)\n" +
 		"  * [`.chezmoitemplates`](#chezmoitemplates)\n" +
@@ -1561,10 +1806,12 @@ func init() {
 		"  * [`hg` [*arguments*]](#hg-arguments)\n" +
 		"  * [`init` [*repo*]](#init-repo)\n" +
 		"  * [`import` *filename*](#import-filename)\n" +
+		"  * [`lint`](#lint)\n" +
 		"  * [`manage` *targets*](#manage-targets)\n" +
 		"  * [`managed`](#managed)\n" +
 		"  * [`merge` *targets*](#merge-targets)\n" +
 		"  * [`purge`](#purge)\n" +
+		"  * [`rekey` [*targets*]](#rekey-targets)\n" +
 		"  * [`remove` *targets*](#remove-targets)\n" +
 		"  * [`rm` *targets*](#rm-targets)\n" +
 		"  * [`secret`](#secret)\n" +
@@ -1581,19 +1828,48 @@ func init() {
 		"* [Template variables](#template-variables)\n" +
 		"* [Template functions](#template-functions)\n" +
 		"  * [`bitwarden` [*args*]](#bitwarden-args)\n" +
+		"  * [`bitwardenAttachment` *filename* *itemid*](#bitwardenattachment-filename-itemid)\n" +
+		"  * [`bitwardenFields` [*args*]](#bitwardenfields-args)\n" +
+		"  * [`fromJson` *json*](#fromjson-json)\n" +
+		"  * [`fromToml` *toml*](#fromtoml-toml)\n" +
+		"  * [`fromYaml` *yaml*](#fromyaml-yaml)\n" +
+		"  * [`glob` *pattern*](#glob-pattern)\n" +
 		"  * [`gopass` *gopass-name*](#gopass-gopass-name)\n" +
+		"  * [`gopassFields` *gopass-name*](#gopassfields-gopass-name)\n" +
+		"  * [`gopassRaw` *gopass-name*](#gopassraw-gopass-name)\n" +
+		"  * [`include` *filename*](#include-filename)\n" +
+		"  * [`includeTemplate` *name* [*data*]](#includetemplate-name-data)\n" +
+		"  * [`jsonPath` *path* *data*](#jsonpath-path-data)\n" +
 		"  * [`keepassxc` *entry*](#keepassxc-entry)\n" +
+		"  * [`keepassxcAttachment` *entry* *name*](#keepassxcattachment-entry-name)\n" +
 		"  * [`keepassxcAttribute` *entry* *attribute*](#keepassxcattribute-entry-attribute)\n" +
 		"  * [`keyring` *service* *user*](#keyring-service-user)\n" +
 		"  * [`lastpass` *id*](#lastpass-id)\n" +
 		"  * [`lastpassRaw` *id*](#lastpassraw-id)\n" +
-		"  * [`onepassword` *uuid*](#onepassword-uuid)\n" +
-		"  * [`onepasswordDocument` *uuid*](#onepassworddocument-uuid)\n" +
-		"  * [`pass` *pass-name*](#pass-pass-name)\n" +
-		"  * [`promptString` *prompt*](#promptstring-prompt)\n" +
+		"  * [`lookPath` *file*](#lookpath-file)\n" +
+		"  * [`onepassword` *uuid* [*vault* [*account*]]](#onepassword-uuid-vault-account)\n" +
+		"  * [`onepasswordDetailsFields` *uuid* [*vault* [*account*]]](#onepassworddetailsfields-uuid-vault-account)\n" +
+		"  * [`onepasswordDocument` *uuid* [*vault* [*account*]]](#onepassworddocument-uuid-vault-account)\n" +
+		"  * [`onepasswordItemFields` *uuid* [*vault* [*account*]]](#onepassworditemfields-uuid-vault-account)\n" +
+		"  * [`output` *name* [*args*]](#output-name-args)\n" +
+		"  * [`pass` *pass-name* [*store*]](#pass-pass-name-store)\n" +
+		"  * [`passFields` *pass-name* [*store*]](#passfields-pass-name-store)\n" +
+		"  * [`passRaw` *pass-name* [*store*]](#passraw-pass-name-store)\n" +
+		"  * [`promptBool` *prompt* [*default*]](#promptbool-prompt-default)\n" +
+		"  * [`promptChoice` *prompt* *choices* [*default*]](#promptchoice-prompt-choices-default)\n" +
+		"  * [`promptInt` *prompt* [*default*]](#promptint-prompt-default)\n" +
+		"  * [`promptString` *prompt* [*default*]](#promptstring-prompt-default)\n" +
 		"  * [`secret` [*args*]](#secret-args)\n" +
 		"  * [`secretJSON` [*args*]](#secretjson-args)\n" +
-		"  * [`vault` *key*](#vault-key)\n" +
+		"  * [`sopsDecrypt` *filename*](#sopsdecrypt-filename)\n" +
+		"  * [`sopsDecryptData` *filename*](#sopsdecryptdata-filename)\n" +
+		"  * [`stat` *name*](#stat-name)\n" +
+		"  * [`toToml` *value*](#totoml-value)\n" +
+		"  * [`toYaml` *value*](#toyaml-value)\n" +
+		"  * [`vault` *key* [*version*]](#vault-key-version)\n" +
+		"  * [`vaultField` *key* *field* [*version*]](#vaultfield-key-field-version)\n" +
+		"  * [`vaultRead` *path* [*args*]](#vaultread-path-args)\n" +
+		"  * [`vaultWrite` *path* [*args*]](#vaultwrite-path-args)\n" +
 		"\n" +
 		"## Concepts\n" +
 		"\n" +
@@ -1662,6 +1938,14 @@ func init() {
 		"\n" +
 		"Also remove targets according to `.chezmoiremove`.\n" +
 		"\n" +
+		"### `--show-secrets`\n" +
+		"\n" +
+		"Show secrets in diffs and in the output of the `dump` and `execute-template`\n" +
+		"commands and template traces. By default, values returned by secret template\n" +
+		"functions, like `bitwarden`, `pass`, and `keyring`, are replaced with\n" +
+		"`<redacted>`. Values shorter than four characters, and strings shorter than\n" +
+		"eight characters in structured values, are not redacted.\n" +
+		"\n" +
 		"### `-S`, `--source` *directory*\n" +
 		"\n" +
 		"Use *directory* as the source directory.\n" +
@@ -1670,7 +1954,8 @@ func init() {
 		"\n" +
 		"Set verbose mode. In verbose mode, chezmoi prints the changes that it is making\n" +
 		"as approximate shell commands, and any differences in files between the target\n" +
-		"state and the destination set are printed as unified diffs.\n" +
+		"state and the destination set are printed as unified diffs. Secrets are\n" +
+		"redacted unless `--show-secrets` is given.\n" +
 		"\n" +
 		"### `--version`\n" +
 		"\n" +
@@ -1692,40 +1977,145 @@ func init() {
 		"\n" +
 		"The following configuration variables are available:\n" +
 		"\n" +
-		"| Variable                | Type     | Default value             | Description                                         |\n" +
-		"| ----------------------- | -------- | ------------------------- | --------------------------------------------------- |\n" +
-		"| `bitwarden.command`     | string   | `bw`                      | Bitwarden CLI command                               |\n" +
-		"| `cd.args`               | []string | *none*                    | Extra args to shell in `cd` command                 |\n" +
-		"| `cd.command`            | string   | *none*                    | Shell to run in `cd` command                        |\n" +
-		"| `color`                 | string   | `auto`                    | Colorize diffs                                      |\n" +
-		"| `data`                  | any      | *none*                    | Template data                                       |\n" +
-		"| `destDir`               | string   | `~`                       | Destination directory                               |\n" +
-		"| `diff.format`           | string   | `chezmoi`                 | Diff format, either `chezmoi` or `git`              |\n" +
-		"| `diff.pager`            | string   | *none*                    | Pager                                               |\n" +
-		"| `dryRun`                | bool     | `false`                   | Dry run mode                                        |\n" +
-		"| `follow`                | bool     | `false`                   | Follow symlinks                                     |\n" +
-		"| `genericSecret.command` | string   | *none*                    | Generic secret command                              |\n" +
-		"| `gopass.command`        | string   | `gopass`                  | gopass CLI command                                  |\n" +
-		"| `gpg.command`           | string   | `gpg`                     | GPG CLI command                                     |\n" +
-		"| `gpg.recipient`         | string   | *none*                    | GPG recipient                                       |\n" +
-		"| `gpg.symmetric`         | bool     | `false`                   | Use symmetric GPG encryption                        |\n" +
-		"| `keepassxc.args`        | []string | *none*                    | Extra args to KeePassXC CLI command                 |\n" +
-		"| `keepassxc.command`     | string   | `keepassxc-cli`           | KeePassXC CLI command                               |\n" +
-		"| `keepassxc.database`    | string   | *none*                    | KeePassXC database                                  |\n" +
-		"| `lastpass.command`      | string   | `lpass`                   | Lastpass CLI command                                |\n" +
-		"| `merge.args`            | []string | *none*                    | Extra args to 3-way merge command                   |\n" +
-		"| `merge.command`         | string   | `vimdiff`                 | 3-way merge command                                 |\n" +
-		"| `onepassword.command`   | string   | `op`                      | 1Password CLI command                               |\n" +
-		"| `pass.command`          | string   | `pass`                    | Pass CLI command                                    |\n" +
-		"| `remove`                | bool     | `false`                   | Remove targets                                      |\n" +
-		"| `sourceDir`             | string   | `~/.local/share/chezmoi`  | Source directory                                    |\n" +
-		"| `sourceVCS.autoCommit`  | bool     | `false`                   | Commit changes to the source state after any change |\n" +
-		"| `sourceVCS.autoPush`    | bool     | `false`                   | Push changes to the source state after any change   |\n" +
-		"| `sourceVCS.command`     | string   | `git`                     | Source version control system                       |\n" +
-		"| `template.options`      | []string | `[\"missingkey=error\"]`    | Template options                                    |\n" +
-		"| `umask`                 | int      | *from system*             | Umask                                               |\n" +
-		"| `vault.command`         | string   | `vault`                   | Vault CLI command                                   |\n" +
-		"| `verbose`               | bool     | `false`                   | Verbose mode                                        |\n" +
+		"| Variable                           | Type     | Default value            | Description                                         |\n" +
+		"| ---------------------------------- | -------- | ------------------------ | --------------------------------------------------- |\n" +
+		"| `age.identities`                   | []string | *none*                   | age identity files                                  |\n" +
+		"| `age.identity`                     | string   | *none*                   | age identity file                                   |\n" +
+		"| `age.passphrase`                   | bool     | `false`                  | Use age passphrase encryption                       |\n" +
+		"| `age.recipient`                    | string   | *none*                   | age recipient                                       |\n" +
+		"| `age.recipients`                   | []string | *none*                   | age recipients                                      |\n" +
+		"| `bitwarden.cacheTTL`               | duration | *none*                   | Time to cache Bitwarden secrets                     |\n" +
+		"| `bitwarden.command`                | string   | `bw`                     | Bitwarden CLI command                               |\n" +
+		"| `cd.args`                          | []string | *none*                   | Extra args to shell in `cd` command                 |\n" +
+		"| `cd.command`                       | string   | *none*                   | Shell to run in `cd` command                        |\n" +
+		"| `color`                            | string   | `auto`                   | Colorize diffs                                      |\n" +
+		"| `dataCommands.<name>.args`         | []string | *none*                   | Extra args to data command                          |\n" +
+		"| `dataCommands.<name>.command`      | string   | *none*                   | Data command                                        |\n" +
+		"| `dataCommands.<name>.format`       | string   | `json`                   | Data command output format                          |\n" +
+		"| `dataCommands.<name>.ttl`          | duration | *none*                   | Time to cache data command output                   |\n" +
+		"| `data`                             | any      | *none*                   | Template data                                       |\n" +
+		"| `destDir`                          | string   | `~`                      | Destination directory                               |\n" +
+		"| `diff.format`                      | string   | `chezmoi`                | Diff format, either `chezmoi` or `git`              |\n" +
+		"| `diff.pager`                       | string   | *none*                   | Pager                                               |\n" +
+		"| `dryRun`                           | bool     | `false`                  | Dry run mode                                        |\n" +
+		"| `encryption`                       | string   | `gpg`                    | Encryption, either `gpg` or `age`                   |\n" +
+		"| `follow`                           | bool     | `false`                  | Follow symlinks                                     |\n" +
+		"| `genericSecret.cacheTTL`           | duration | *none*                   | Time to cache generic secrets                       |\n" +
+		"| `genericSecret.command`            | string   | *none*                   | Generic secret command                              |\n" +
+		"| `gopass.cacheTTL`                  | duration | *none*                   | Time to cache gopass secrets                        |\n" +
+		"| `gopass.command`                   | string   | `gopass`                 | gopass CLI command                                  |\n" +
+		"| `gpg.command`                      | string   | `gpg`                    | GPG CLI command                                     |\n" +
+		"| `gpg.recipient`                    | string   | *none*                   | GPG recipient                                       |\n" +
+		"| `gpg.recipients`                   | []string | *none*                   | Additional GPG recipients                           |\n" +
+		"| `gpg.symmetric`                    | bool     | `false`                  | Use symmetric GPG encryption                        |\n" +
+		"| `keepassxc.args`                   | []string | *none*                   | Extra args to KeePassXC CLI command                 |\n" +
+		"| `keepassxc.cacheTTL`               | duration | *none*                   | Time to cache KeePassXC secrets                     |\n" +
+		"| `keepassxc.command`                | string   | `keepassxc-cli`          | KeePassXC CLI command                               |\n" +
+		"| `keepassxc.database`               | string   | *none*                   | KeePassXC database                                  |\n" +
+		"| `keepassxc.keyFile`                | string   | *none*                   | KeePassXC database key file                         |\n" +
+		"| `keepassxc.mode`                   | string   | `cli`                    | `builtin` or `cli`                                  |\n" +
+		"| `lastpass.cacheTTL`                | duration | *none*                   | Time to cache Lastpass secrets                      |\n" +
+		"| `lastpass.command`                 | string   | `lpass`                  | Lastpass CLI command                                |\n" +
+		"| `merge.args`                       | []string | *none*                   | Extra args to 3-way merge command                   |\n" +
+		"| `merge.command`                    | string   | `vimdiff`                | 3-way merge command                                 |\n" +
+		"| `onepassword.cacheTTL`             | duration | *none*                   | Time to cache 1Password secrets                     |\n" +
+		"| `onepassword.command`              | string   | `op`                     | 1Password CLI command                               |\n" +
+		"| `pass.cacheTTL`                    | duration | *none*                   | Time to cache pass secrets                          |\n" +
+		"| `pass.command`                     | string   | `pass`                   | Pass CLI command                                    |\n" +
+		"| `pass.storeDir`                    | string   | *none*                   | Pass password store directory                       |\n" +
+		"| `pass.stores`                      | map      | *none*                   | Named pass password store directories               |\n" +
+		"| `remove`                           | bool     | `false`                  | Remove targets                                      |\n" +
+		"| `secrets`                          | string   | `warning`                | `error`, `ignore`, or `warning` on possible secrets |\n" +
+		"| `sops.args`                        | []string | *none*                   | Extra args to sops CLI command                      |\n" +
+		"| `sops.command`                     | string   | `sops`                   | sops CLI command                                    |\n" +
+		"| `sourceDir`                        | string   | `~/.local/share/chezmoi` | Source directory                                    |\n" +
+		"| `sourceVCS.autoCommit`             | bool     | `false`                  | Commit changes to the source state after any change |\n" +
+		"| `sourceVCS.autoPush`               | bool     | `false`                  | Push changes to the source state after any change   |\n" +
+		"| `sourceVCS.command`                | string   | `git`                    | Source version control system                       |\n" +
+		"| `template.options`                 | []string | `[\"missingkey=error\"]`   | Template options                                    |\n" +
+		"| `templateCommands.<name>.args`     | []string | *none*                   | Template command argument templates                 |\n" +
+		"| `templateCommands.<name>.cacheTTL` | duration | *none*                   | Time to cache template command output               |\n" +
+		"| `templateCommands.<name>.command`  | string   | *none*                   | Template command                                    |\n" +
+		"| `templateCommands.<name>.format`   | string   | `trim`                   | Template command output format                      |\n" +
+		"| `templateCommands.<name>.secret`   | bool     | `false`                  | Template command output is secret                   |\n" +
+		"| `umask`                            | int      | *from system*            | Umask                                               |\n" +
+		"| `vault.address`                    | string   | *none*                   | Vault server address                                |\n" +
+		"| `vault.cacheTTL`                   | duration | *none*                   | Time to cache Vault secrets, except `vaultWrite`    |\n" +
+		"| `vault.command`                    | string   | `vault`                  | Vault CLI command                                   |\n" +
+		"| `vault.mount`                      | string   | *none*                   | Vault KV secrets engine mount path                  |\n" +
+		"| `vault.namespace`                  | string   | *none*                   | Vault namespace                                     |\n" +
+		"| `vault.writeTTL`                   | duration | `24h`                    | Time to cache the output of `vaultWrite`            |\n" +
+		"| `verbose`                          | bool     | `false`                  | Verbose mode                                        |\n" +
+		"\n" +
+		"### Data commands\n" +
+		"\n" +
+		"Data commands are commands whose output is added to the template data. Each\n" +
+		"data command is configured in the `dataCommands` section of the config file\n" +
+		"under a name, and its output is parsed according to its `format` (`json`,\n" +
+		"`toml`, or `yaml`, default `json`) and added to the template data under that\n" +
+		"name. Values from data commands take precedence over values in the `data`\n" +
+		"section. The name `chezmoi` is reserved. Config file keys are case-insensitive,\n" +
+		"so names must be lowercase: chezmoi reports an error for\n" +
+		"`[dataCommands.teamRoster]` instead of silently adding `.teamroster`.\n" +
+		"\n" +
+		"Each data command is run at most once per invocation of chezmoi. If `ttl` is\n" +
+		"set, then the command's output is cached in chezmoi's persistent state and the\n" +
+		"command is only run again when the cached output is older than `ttl` or the\n" +
+		"command or its arguments change.\n" +
+		"\n" +
+		"For example, with the following configuration:\n" +
+		"\n" +
+		"    [dataCommands.host]\n" +
+		"      command = \"hostinfo\"\n" +
+		"      args = [\"--json\"]\n" +
+		"      ttl = \"24h\"\n" +
+		"\n" +
+		"the output of `hostinfo --json` is available in templates as `.host`. Data\n" +
+		"commands' output is included in the output of `chezmoi data`.\n" +
+		"\n" +
+		"### Template commands\n" +
+		"\n" +
+		"Template commands are commands that are available as template functions. Each\n" +
+		"template command is configured in the `templateCommands` section of the config\n" +
+		"file under a name, which becomes the name of the template function. Config file\n" +
+		"keys are case-insensitive, so names must be lowercase: chezmoi reports an error\n" +
+		"for `[templateCommands.vaultToken]` instead of silently defining `vaulttoken`.\n" +
+		"The name must not be the name of an existing template function. Template\n" +
+		"commands in a config file generated by `chezmoi init` are available to\n" +
+		"`chezmoi init --apply` and `chezmoi init --one-shot`.\n" +
+		"\n" +
+		"The command is run with `args`, each of which is itself a template executed\n" +
+		"with the template function's arguments as data, so `{{ index . 0 }}` is the\n" +
+		"first argument. If `args` is not set then the template function's arguments are\n" +
+		"passed to the command unchanged. The command's output is returned according to\n" +
+		"its `format`:\n" +
+		"\n" +
+		"| Format | Result                                                  |\n" +
+		"| ------ | ------------------------------------------------------- |\n" +
+		"| `json` | The output parsed as JSON                               |\n" +
+		"| `raw`  | The output unchanged                                    |\n" +
+		"| `trim` | The output with leading and trailing whitespace removed |\n" +
+		"| `yaml` | The output parsed as YAML                               |\n" +
+		"\n" +
+		"The default format is `trim`. If `secret` is `true` then the template\n" +
+		"function's results are treated as secrets and are redacted from chezmoi's\n" +
+		"output.\n" +
+		"\n" +
+		"Each template command is run at most once per invocation of chezmoi for each\n" +
+		"set of arguments. If `cacheTTL` is set, then the command's output is cached in\n" +
+		"chezmoi's persistent state, encrypted in the same way as the output of secret\n" +
+		"managers.\n" +
+		"\n" +
+		"For example, with the following configuration:\n" +
+		"\n" +
+		"    [templateCommands.vaulttoken]\n" +
+		"      command = \"corp-secrets\"\n" +
+		"      args = [\"get\", \"--field\", \"{{ index . 1 }}\", \"{{ index . 0 }}\"]\n" +
+		"      secret = true\n" +
+		"      cacheTTL = \"1h\"\n" +
+		"\n" +
+		"the template `{{ vaulttoken \"ci\" \"token\" }}` runs `corp-secrets get --field\n" +
+		"token ci` and returns its output.\n" +
 		"\n" +
 		"## Source state attributes\n" +
 		"\n" +
@@ -1739,6 +2129,7 @@ func init() {
 		"\n" +
 		"| Prefix       | Effect                                                                         |\n" +
 		"| ------------ | ------------------------------------------------------------------------------ |\n" +
+		"| `block_`     | Manage only a marked block within the target file.                             |\n" +
 		"| `encrypted_` | Encrypt the file in the source state.                                          |\n" +
 		"| `once_`      | Only run script once.                                                          |\n" +
 		"| `private_`   | Remove all group and world permissions from the target file or directory.      |\n" +
@@ -1746,6 +2137,7 @@ func init() {
 		"| `exact_`     | Remove anything not managed by chezmoi.                                        |\n" +
 		"| `executable_`| Add executable permissions to the target file.                                 |\n" +
 		"| `run_`       | Treat the contents as a script to run.                                         |\n" +
+		"| `sops_`      | Decrypt the file with sops when generating the target state.                   |\n" +
 		"| `symlink_`   | Create a symlink instead of a regular file.                                    |\n" +
 		"| `dot_`       | Rename to use a leading dot, e.g. `dot_foo` becomes `.foo`.                    |\n" +
 		"\n" +
@@ -1753,17 +2145,36 @@ func init() {
 		"| ------- | ---------------------------------------------------- |\n" +
 		"| `.tmpl` | Treat the contents of the source file as a template. |\n" +
 		"\n" +
-		"Order of prefixes is important, the order is `run_`, `exact_`, `private_`,\n" +
-		"`empty_`, `executable_`, `symlink_`, `once_`, `dot_`.\n" +
+		"Order of prefixes is important, the order is `block_`, `run_`, `exact_`,\n" +
+		"`private_`, `empty_`, `executable_`, `symlink_`, `once_`, `dot_`.\n" +
 		"\n" +
 		"Different target types allow different prefixes and suffixes:\n" +
 		"\n" +
-		"| Target type   | Allowed prefixes                                          | Allowed suffixes |\n" +
-		"| ------------- | --------------------------------------------------------- | ---------------- |\n" +
-		"| Directory     | `exact_`, `private_`, `dot_`                              | *none*           |\n" +
-		"| Regular file  | `encrypted_`, `private_`, `empty_`, `executable_`, `dot_` | `.tmpl`          |\n" +
-		"| Script        | `run_`, `once_`                                           | `.tmpl`          |\n" +
-		"| Symbolic link | `symlink_`, `dot_`,                                       | `.tmpl`          |\n" +
+		"| Target type   | Allowed prefixes                                                   | Allowed suffixes |\n" +
+		"| ------------- | ------------------------------------------------------------------ | ---------------- |\n" +
+		"| Block         | `block_`, `dot_`                                                   | `.tmpl`          |\n" +
+		"| Directory     | `exact_`, `private_`, `dot_`                                       | *none*           |\n" +
+		"| Regular file  | `encrypted_`, `sops_`, `private_`, `empty_`, `executable_`, `dot_` | `.tmpl`          |\n" +
+		"| Script        | `run_`, `once_`                                                    | `.tmpl`          |\n" +
+		"| Symbolic link | `symlink_`, `dot_`,                                                | `.tmpl`          |\n" +
+		"\n" +
+		"### Managed blocks\n" +
+		"\n" +
+		"A source file with the `block_` prefix manages only a block within its target\n" +
+		"file, leaving the rest of the target file untouched. This is useful for files\n" +
+		"like `~/.bashrc` and `~/.profile` that other programs also modify. The block is\n" +
+		"delimited by the marker lines\n" +
+		"\n" +
+		"    # BEGIN chezmoi managed block\n" +
+		"    # END chezmoi managed block\n" +
+		"\n" +
+		"When the target state is applied, the existing block between the markers is\n" +
+		"replaced with the contents of the source file. If the target file does not\n" +
+		"contain a block then the block, including its markers, is appended to the\n" +
+		"target file. If the target file does not exist then it is created. If the\n" +
+		"contents of the source file are empty then the block and its markers are\n" +
+		"removed from the target file. `chezmoi diff` and `chezmoi verify` report\n" +
+		"differences in the block only.\n" +
 		"\n" +
 		"## Special files and directories\n" +
 		"\n" +
@@ -1782,6 +2193,17 @@ func init() {
 		"    data:\n" +
 		"        email: \"{{ $email }}\"\n" +
 		"\n" +
+		"### `.chezmoiarchive`\n" +
+		"\n" +
+		"If a file called `.chezmoiarchive` exists in a directory in the source state\n" +
+		"then it is decrypted with the configured encryption and interpreted as a tar\n" +
+		"archive of the directory's contents. Entries in the archive are added to the\n" +
+		"target state as if they were in the source state. It is an error for any other\n" +
+		"entry in the source state to have the same target as an entry in the archive.\n" +
+		"`.chezmoiarchive` files are created by `chezmoi add --archive`. Individual\n" +
+		"targets in an archive cannot be added, edited, or removed; instead, change the\n" +
+		"directory and add it again with `--archive`.\n" +
+		"\n" +
 		"### `.chezmoiignore`\n" +
 		"\n" +
 		"If a file called `.chezmoiignore` exists in the source state then it is\n" +
@@ -1826,7 +2248,14 @@ func init() {
 		"\n" +
 		"If a directory called `.chezmoitemplates` exists, then all files in this\n" +
 		"directory are parsed as templates are available as templates with a name equal\n" +
-		"to the relative path of the file.\n" +
+		"to the relative path of the file. Templates are parsed with the same functions\n" +
+		"and options as all other templates, and can be used from any template,\n" +
+		"including `.chezmoiignore`, `.chezmoiremove`, symlinks, and\n" +
+		"`.chezmoi.<format>.tmpl`.\n" +
+		"\n" +
+		"Templates can be included with `text/template`'s `template` action, or with the\n" +
+		"`includeTemplate` function, which returns the result as a string so that it can\n" +
+		"be further processed.\n" +
 		"\n" +
 		"#### `.chezmoitemplates` examples\n" +
 		"\n" +
@@ -1840,6 +2269,16 @@ func init() {
 		"\n" +
 		"The target state of `.config` will be `bar`.\n" +
 		"\n" +
+		"Given:\n" +
+		"\n" +
+		"    .chezmoitemplates/part\n" +
+		"    name = {{ .name }}\n" +
+		"\n" +
+		"    dot_file.tmpl\n" +
+		"    {{ includeTemplate \"part\" (dict \"name\" \"John\") | upper }}\n" +
+		"\n" +
+		"The target state of `.file` will be `NAME = JOHN`.\n" +
+		"\n" +
 		"### `.chezmoiversion`\n" +
 		"\n" +
 		"If a file called `.chezmoiversion` exists, then its contents are interpreted as\n" +
@@ -1860,13 +2299,29 @@ func init() {
 		"\n" +
 		"Add *targets* to the source state. If any target is already in the source state,\n" +
 		"then its source state is replaced with its current state in the destination\n" +
-		"directory. The `add` command accepts additional flags:\n" +
+		"directory. Unless the `--encrypt` flag is given, added files are scanned for\n" +
+		"possible secrets, and chezmoi warns about or refuses to add them according to\n" +
+		"the `secrets` configuration variable. The `add` command accepts additional\n" +
+		"flags:\n" +
+		"\n" +
+		"#### `--archive`\n" +
+		"\n" +
+		"Add directories as a single encrypted archive, hiding the names of the files\n" +
+		"they contain. This implies the `--encrypt` option. Directories whose contents\n" +
+		"are already managed without an archive cannot be added as an archive; remove\n" +
+		"them from the source state first. See [`.chezmoiarchive`](#chezmoiarchive).\n" +
 		"\n" +
 		"#### `--autotemplate`\n" +
 		"\n" +
-		"Automatically generate a template by replacing strings with variable names from\n" +
-		"the `data` section of the config file. Longer substitutions occur before shorter\n" +
-		"ones. This implies the `--template` option.\n" +
+		"Automatically generate a template by replacing strings with references to the\n" +
+		"template data, including values in nested maps and lists. Longer substitutions\n" +
+		"occur before shorter ones, and strings are only replaced on word boundaries.\n" +
+		"This implies the `--template` option.\n" +
+		"\n" +
+		"#### `--autotemplate-min-length` *length*\n" +
+		"\n" +
+		"Only replace strings of at least *length* bytes when generating templates with\n" +
+		"`--autotemplate`.\n" +
 		"\n" +
 		"#### `-e`, `--empty`\n" +
 		"\n" +
@@ -1884,6 +2339,11 @@ func init() {
 		"\n" +
 		"Interactively prompt before adding each file.\n" +
 		"\n" +
+		"#### `--preview`\n" +
+		"\n" +
+		"With `--autotemplate`, print the substitutions that would be made in each file\n" +
+		"and prompt before adding it.\n" +
+		"\n" +
 		"#### `-r`, `--recursive`\n" +
 		"\n" +
 		"Recursively add all files, directories, and symlinks.\n" +
@@ -1896,8 +2356,10 @@ func init() {
 		"\n" +
 		"    chezmoi add ~/.bashrc\n" +
 		"    chezmoi add ~/.gitconfig --template\n" +
+		"    chezmoi add ~/.gitconfig --autotemplate --preview\n" +
 		"    chezmoi add ~/.vim --recursive\n" +
 		"    chezmoi add ~/.oh-my-zsh --exact --recursive\n" +
+		"    chezmoi add ~/.aws --archive\n" +
 		"\n" +
 		"### `apply` [*targets*]\n" +
 		"\n" +
@@ -1926,13 +2388,23 @@ func init() {
 		"\n" +
 		"### `cat` targets\n" +
 		"\n" +
-		"Write the target state of *targets*  to stdout. *targets* must be files or\n" +
-		"symlinks. For files, the target file contents are written. For symlinks, the\n" +
-		"target target is written.\n" +
+		"Write the target state of *targets*  to stdout. *targets* must be blocks, files,\n" +
+		"or symlinks. For blocks, the block contents are written. For files, the target\n" +
+		"file contents are written. For symlinks, the target target is written. The `cat`\n" +
+		"command accepts additional flags:\n" +
+		"\n" +
+		"#### `--trace`\n" +
+		"\n" +
+		"Write a trace of template execution to stderr. Each line of the trace records a\n" +
+		"data key accessed, a template function called with its arguments and result, or\n" +
+		"a partial template included, along with the template in which it occurred. The\n" +
+		"results of secret functions, e.g. `bitwarden` and `pass`, are replaced with\n" +
+		"`<redacted>`.\n" +
 		"\n" +
 		"#### `cat` examples\n" +
 		"\n" +
 		"    chezmoi cat ~/.bashrc\n" +
+		"    chezmoi cat --trace ~/.gitconfig\n" +
 		"\n" +
 		"### `cd`\n" +
 		"\n" +
@@ -2047,7 +2519,9 @@ func init() {
 		"\n" +
 		"### `doctor`\n" +
 		"\n" +
-		"Check for potential problems.\n" +
+		"Check for potential problems. If the Bitwarden or 1Password CLIs are installed\n" +
+		"then `doctor` also reports whether the Bitwarden vault is unlocked and whether\n" +
+		"you are signed in to 1Password.\n" +
 		"\n" +
 		"#### `doctor` examples\n" +
 		"\n" +
@@ -2056,7 +2530,8 @@ func init() {
 		"### `dump` [*targets*]\n" +
 		"\n" +
 		"Dump the target state in JSON format. If no targets are specified, then the\n" +
-		"entire target state. The `dump` command accepts additional arguments:\n" +
+		"entire target state. Secrets are redacted unless `--show-secrets` is given. The\n" +
+		"`dump` command accepts additional arguments:\n" +
 		"\n" +
 		"#### `-f`, `--format` *format*\n" +
 		"\n" +
@@ -2070,9 +2545,9 @@ func init() {
 		"\n" +
 		"### `edit` [*targets*]\n" +
 		"\n" +
-		"Edit the source state of *targets*, which must be files or symlinks. If no\n" +
-		"targets are given the the source directory itself is opened with `$EDITOR`. The\n" +
-		"`edit` command accepts additional arguments:\n" +
+		"Edit the source state of *targets*, which must be blocks, files, or symlinks. If\n" +
+		"no targets are given the the source directory itself is opened with `$EDITOR`.\n" +
+		"The `edit` command accepts additional arguments:\n" +
 		"\n" +
 		"#### `-a`, `--apply`\n" +
 		"\n" +
@@ -2106,7 +2581,8 @@ func init() {
 		"Execute *templates*. This is useful for testing templates or for calling chezmoi\n" +
 		"from other scripts. *templates* are interpreted as literal templates, with no\n" +
 		"whitespace added to the output between arguments. If no templates are specified,\n" +
-		"the template is read from stdin.\n" +
+		"the template is read from stdin. Secrets in the output are redacted unless `--output`\n" +
+		"or `--show-secrets` is given.\n" +
 		"\n" +
 		"#### `--init`, `-i`\n" +
 		"\n" +
@@ -2116,18 +2592,35 @@ func init() {
 		"\n" +
 		"Write the output to *filename* instead of stdout.\n" +
 		"\n" +
+		"#### `--promptBool`, `--promptChoice`, `--promptInt` *pairs*\n" +
+		"\n" +
+		"Simulate the `promptBool`, `promptChoice`, and `promptInt` functions with\n" +
+		"functions that return values from *pairs*. *pairs* is a comma-separated list of\n" +
+		"*prompt*`=`*value* pairs. If a function is called with a *prompt* that does not\n" +
+		"match any of *pairs*, then it returns its default, if given, or otherwise\n" +
+		"`false`, the first choice, or `0` respectively.\n" +
+		"\n" +
 		"#### `--promptString`, `-p` *pairs*\n" +
 		"\n" +
 		"Simulate the `promptString` function with a function that returns values from\n" +
 		"*pairs*. *pairs* is a comma-separated list of *prompt*`=`*value* pairs. If\n" +
 		"`promptString` is called with a *prompt* that does not match any of *pairs*,\n" +
-		"then it returns *prompt* unchanged.\n" +
+		"then it returns its default, if given, or otherwise *prompt* unchanged.\n" +
+		"\n" +
+		"#### `--trace`\n" +
+		"\n" +
+		"Write a trace of template execution to stderr. Each line of the trace records a\n" +
+		"data key accessed, a template function called with its arguments and result, or\n" +
+		"a partial template included, along with the template in which it occurred. The\n" +
+		"results of secret functions, e.g. `bitwarden` and `pass`, are replaced with\n" +
+		"`<redacted>`.\n" +
 		"\n" +
 		"#### `execute-template` examples\n" +
 		"\n" +
 		"    chezmoi execute-template '{{ .chezmoi.sourceDir }}'\n" +
 		"    chezmoi execute-template '{{ .chezmoi.os }}' / '{{ .chezmoi.arch }}'\n" +
 		"    echo '{{ .chezmoi | toJson }}' | chezmoi execute-template\n" +
+		"    chezmoi execute-template --trace '{{ .email }}'\n" +
 		"    chezmoi execute-template --init --promptString email=john@home.org < ~/.local/share/chezmoi/.chezmoi.toml.tmpl\n" +
 		"\n" +
 		"### `forget` *targets*\n" +
@@ -2172,10 +2665,49 @@ func init() {
 		"file is created using that file as a template. Finally, if the `--apply` flag is\n" +
 		"passed, `chezmoi apply` is run.\n" +
 		"\n" +
+		"#### `--apply`\n" +
+		"\n" +
+		"Run `chezmoi apply` after checking out the repo and creating the config file.\n" +
+		"\n" +
+		"#### `--one-shot`\n" +
+		"\n" +
+		"Check out *repo* into a temporary directory with a shallow clone if the VCS\n" +
+		"supports it, create the config file and persistent state in the same temporary\n" +
+		"directory, run `chezmoi apply`, and then remove the temporary directory. This\n" +
+		"leaves nothing behind except the targets themselves, which is useful for\n" +
+		"throwaway containers and CI. `--one-shot` implies `--apply` and requires *repo*.\n" +
+		"\n" +
+		"#### `--skip-persistent-state`\n" +
+		"\n" +
+		"Do not read or write the persistent state when applying. `run_once_` scripts\n" +
+		"are run every time.\n" +
+		"\n" +
+		"#### `--skip-scripts`\n" +
+		"\n" +
+		"Do not run any scripts when applying.\n" +
+		"\n" +
+		"#### `--promptBool`, `--promptChoice`, `--promptInt`, `--promptString` *pairs*\n" +
+		"\n" +
+		"Answer calls to the corresponding prompt function in the config file template\n" +
+		"without prompting the user. *pairs* is a comma-separated list of\n" +
+		"*prompt*`=`*value* pairs.\n" +
+		"\n" +
+		"#### `--promptFile` *filename*\n" +
+		"\n" +
+		"Read answers to calls to any prompt function in the config file template from\n" +
+		"*filename*, a JSON, TOML, or YAML file mapping prompts to values. Answers given\n" +
+		"with the `--prompt*` flags take priority over answers in *filename*.\n" +
+		"\n" +
+		"Together with defaults, these flags allow `chezmoi init` to run unattended, for\n" +
+		"example in CI or when building containers.\n" +
+		"\n" +
 		"#### `init` examples\n" +
 		"\n" +
 		"    chezmoi init https://github.com/user/dotfiles.git\n" +
 		"    chezmoi init https://github.com/user/dotfiles.git --apply\n" +
+		"    chezmoi init https://github.com/user/dotfiles.git --promptString email=john@home.org --promptBool \"work machine=false\"\n" +
+		"    chezmoi init https://github.com/user/dotfiles.git --promptFile answers.yaml < /dev/null\n" +
+		"    chezmoi init --one-shot --skip-scripts https://github.com/user/dotfiles.git\n" +
 		"\n" +
 		"### `import` *filename*\n" +
 		"\n" +
@@ -2207,6 +2739,34 @@ func init() {
 		"    curl -s -L -o oh-my-zsh-master.tar.gz https://github.com/robbyrussell/oh-my-zsh/archive/master.tar.gz\n" +
 		"    chezmoi import --strip-components 1 --destination ~/.oh-my-zsh oh-my-zsh-master.tar.gz\n" +
 		"\n" +
+		"### `lint`\n" +
+		"\n" +
+		"Check the source state for problems without applying it or executing any\n" +
+		"templates. `lint` reports:\n" +
+		"\n" +
+		"* Template syntax errors in files, scripts, symlinks, managed blocks,\n" +
+		"  `.chezmoiignore`, `.chezmoiremove`, `.chezmoitemplates`, and the config file\n" +
+		"  template.\n" +
+		"* References to undefined functions.\n" +
+		"* References to undefined data keys, where the value of dot is known.\n" +
+		"* Templates in `.chezmoitemplates` that are never used.\n" +
+		"* Invalid or misordered attributes in source names.\n" +
+		"* Multiple source files or directories that manage the same target.\n" +
+		"\n" +
+		"Templates in encrypted files and the contents of encrypted archives are not\n" +
+		"checked, and nothing is decrypted. `lint` exits with a non-zero exit status if\n" +
+		"any problems are found. `check` is an alias for `lint`.\n" +
+		"\n" +
+		"#### `-f`, `--format` *format*\n" +
+		"\n" +
+		"Print problems in the given format. The accepted formats are `text` (one\n" +
+		"problem per line), `json` (JSON), and `yaml` (YAML).\n" +
+		"\n" +
+		"#### `lint` examples\n" +
+		"\n" +
+		"    chezmoi lint\n" +
+		"    chezmoi lint --format=json\n" +
+		"\n" +
 		"### `manage` *targets*\n" +
 		"\n" +
 		"`manage` is an alias for `add` for symmetry with `unmanage`.\n" +
@@ -2257,9 +2817,28 @@ func init() {
 		"    chezmoi purge\n" +
 		"    chezmoi purge --force\n" +
 		"\n" +
+		"### `rekey` [*targets*]\n" +
+		"\n" +
+		"Decrypt each encrypted file in the source state and re-encrypt it with the\n" +
+		"current encryption settings, for example after adding or removing a recipient\n" +
+		"from `gpg.recipients` or `age.recipients`. If *targets* are given then only\n" +
+		"encrypted files in *targets* are re-encrypted. Changes are written through the\n" +
+		"usual mechanisms, so `--dry-run` and `--verbose` are respected. Files keep their\n" +
+		"permissions. chezmoi records the recipients that each file was re-encrypted for\n" +
+		"in its persistent state, and files that have not changed since they were last\n" +
+		"re-encrypted for the current recipients are not rewritten.\n" +
+		"\n" +
+		"#### `rekey` examples\n" +
+		"\n" +
+		"    chezmoi rekey\n" +
+		"    chezmoi rekey --dry-run --verbose\n" +
+		"    chezmoi rekey ~/.ssh\n" +
+		"\n" +
 		"### `remove` *targets*\n" +
 		"\n" +
 		"Remove *targets* from both the source state and the destination directory.\n" +
+		"If a target is a managed block then only the block, including its markers, is\n" +
+		"removed from the target and the rest of the target is left untouched.\n" +
 		"\n" +
 		"#### `-f`, `--force`\n" +
 		"\n" +
@@ -2281,9 +2860,17 @@ func init() {
 		"\n" +
 		"    chezmoi secret help\n" +
 		"\n" +
+		"If a secret manager's `cacheTTL` configuration variable is set, then chezmoi\n" +
+		"caches the output of the secret manager's CLI in its persistent state for that\n" +
+		"duration, so secrets are not retrieved again on every invocation. The cache is\n" +
+		"encrypted with a randomly-generated key stored in `chezmoisecretcache.key` in\n" +
+		"the same directory as the persistent state. `chezmoi secret cache clear` clears\n" +
+		"the cache, and exits with an error if it cannot, for example with `--dry-run`.\n" +
+		"\n" +
 		"#### `secret` examples\n" +
 		"\n" +
 		"    chezmoi secret bitwarden list items\n" +
+		"    chezmoi secret cache clear\n" +
 		"    chezmoi secret keyring set --service service --user user\n" +
 		"    chezmoi secret keyring get --service service --user user\n" +
 		"    chezmoi secret lastpass ls\n" +
@@ -2291,6 +2878,7 @@ func init() {
 		"    chezmoi secret onepassword list items\n" +
 		"    chezmoi secret onepassword get item id\n" +
 		"    chezmoi secret pass show id\n" +
+		"    chezmoi secret sops -- --decrypt secrets.yaml\n" +
 		"    chezmoi secret vault -- kv get -format=json id\n" +
 		"\n" +
 		"### `source` [*args*]\n" +
@@ -2413,6 +3001,34 @@ func init() {
 		"For a full list of options, see\n" +
 		"[`Template.Option`](https://pkg.go.dev/text/template?tab=doc#Template.Option).\n" +
 		"\n" +
+		"Individual templates can override the delimiters and options with\n" +
+		"`chezmoi:template:` directives. A directive is a line at the start of the\n" +
+		"template containing `chezmoi:template:` followed by space-separated\n" +
+		"*key*`=`*value* pairs. Values may be double-quoted to include spaces. Directive\n" +
+		"lines are removed from the template before it is executed. Lines containing\n" +
+		"`chezmoi:template:` after the first line that is not a directive are left\n" +
+		"unchanged. The supported keys are:\n" +
+		"\n" +
+		"| Key               | Effect                                                                    |\n" +
+		"| ----------------- | ------------------------------------------------------------------------- |\n" +
+		"| `left-delimiter`  | Set the left action delimiter, default `{{`                               |\n" +
+		"| `right-delimiter` | Set the right action delimiter, default `}}`                              |\n" +
+		"| `missingkey`      | Set the `missingkey` option to `default`, `invalid`, `zero`, or `error`   |\n" +
+		"\n" +
+		"A `missingkey` directive in a template in `.chezmoitemplates` applies when the\n" +
+		"template is included with `includeTemplate`, but not when it is included with\n" +
+		"the `template` action.\n" +
+		"\n" +
+		"For example, to use `[[` and `]]` as delimiters in a file that already\n" +
+		"contains `{{` and `}}`:\n" +
+		"\n" +
+		"    # chezmoi:template:left-delimiter=[[ right-delimiter=]]\n" +
+		"    email = [[ .email ]]\n" +
+		"    prompt = {{ user }}\n" +
+		"\n" +
+		"`chezmoi add --autotemplate` honours any directives in the added file and\n" +
+		"leaves them in place.\n" +
+		"\n" +
 		"## Template variables\n" +
 		"\n" +
 		"chezmoi provides the following automatically populated variables:\n" +
@@ -2420,17 +3036,27 @@ func init() {
 		"| Variable                | Value                                                                                                                           |\n" +
 		"| ----------------------- | ------------------------------------------------------------------------------------------------------------------------------- |\n" +
 		"| `.chezmoi.arch`         | Architecture, e.g. `amd64`, `arm`, etc. as returned by [runtime.GOARCH](https://pkg.go.dev/runtime?tab=doc#pkg-constants).      |\n" +
+		"| `.chezmoi.configFile`   | The path to the config file.                                                                                                    |\n" +
+		"| `.chezmoi.container`    | `true` if chezmoi is running in a container, e.g. Docker or Podman. Linux only.                                                 |\n" +
+		"| `.chezmoi.cpus`         | The number of logical CPUs.                                                                                                     |\n" +
+		"| `.chezmoi.desktop`      | The desktop environment, from `$XDG_CURRENT_DESKTOP` or `$DESKTOP_SESSION`, if set.                                             |\n" +
 		"| `.chezmoi.fullHostname` | The full hostname of the machine chezmoi is running on.                                                                         |\n" +
 		"| `.chezmoi.group`        | The group of the user running chezmoi.                                                                                          |\n" +
 		"| `.chezmoi.homedir`      | The home directory of the user running chezmoi.                                                                                 |\n" +
 		"| `.chezmoi.hostname`     | The hostname of the machine chezmoi is running on, up to the first `.`.                                                         |\n" +
 		"| `.chezmoi.kernel`       | Contains information from `/proc/sys/kernel`. Linux only, useful for detecting specific kernels (i.e. Microsoft's WSL kernel).  |\n" +
+		"| `.chezmoi.machineID`    | The machine ID from `/etc/machine-id` or `/var/lib/dbus/machine-id`. Linux only.                                                |\n" +
 		"| `.chezmoi.os`           | Operating system, e.g. `darwin`, `linux`, etc. as returned by [runtime.GOOS](https://pkg.go.dev/runtime?tab=doc#pkg-constants). |\n" +
 		"| `.chezmoi.osRelease`    | The information from `/etc/os-release`, Linux only, run `chezmoi data` to see its output.                                       |\n" +
+		"| `.chezmoi.shell`        | The user's shell, from `$SHELL`, if set.                                                                                        |\n" +
 		"| `.chezmoi.sourceDir`    | The source directory.                                                                                                           |\n" +
+		"| `.chezmoi.totalMemory`  | The total memory in bytes, from `/proc/meminfo`. Linux only.                                                                    |\n" +
 		"| `.chezmoi.username`     | The username of the user running chezmoi.                                                                                       |\n" +
+		"| `.chezmoi.version`      | The version of chezmoi, with `builtBy`, `commit`, `date`, and `version` fields.                                                 |\n" +
+		"| `.chezmoi.wsl`          | `true` if chezmoi is running in Windows Subsystem for Linux.                                                                    |\n" +
 		"\n" +
-		"Additional variables can be defined in the config file in the `data` section.\n" +
+		"Additional variables can be defined in the config file in the `data` section,\n" +
+		"or generated by [data commands](#data-commands).\n" +
 		"Variable names must consist of a letter and be followed by zero or more letters\n" +
 		"and/or digits.\n" +
 		"\n" +
@@ -2448,12 +3074,72 @@ func init() {
 		"unchanged and the output from `bw` is parsed as JSON. The output from `bw` is\n" +
 		"cached so calling `bitwarden` multiple times with the same arguments will only\n" +
 		"invoke `bw` once.\n" +
+		"If the Bitwarden vault is locked then chezmoi runs `bw unlock` once, prompting\n" +
+		"for the master password, and uses the session key for all subsequent `bw`\n" +
+		"invocations. If you are not logged in then `bitwarden` fails and you must run\n" +
+		"`bw login` first.\n" +
 		"\n" +
 		"#### `bitwarden` examples\n" +
 		"\n" +
 		"    username = {{ (bitwarden \"item\" \"example.com\").login.username }}\n" +
 		"    password = {{ (bitwarden \"item\" \"example.com\").login.password }}\n" +
 		"\n" +
+		"### `bitwardenAttachment` *filename* *itemid*\n" +
+		"\n" +
+		"`bitwardenAttachment` returns the contents of the attachment *filename* of the\n" +
+		"item with id *itemid* using `bw get attachment <filename> --itemid <itemid>\n" +
+		"--raw`. The output from `bw` is cached so calling `bitwardenAttachment` multiple\n" +
+		"times with the same arguments will only invoke `bw` once.\n" +
+		"\n" +
+		"#### `bitwardenAttachment` examples\n" +
+		"\n" +
+		"    {{- bitwardenAttachment \"id_rsa\" \"bf22e4b4-ae4a-4d1c-8c98-ac620004b628\" -}}\n" +
+		"\n" +
+		"### `bitwardenFields` [*args*]\n" +
+		"\n" +
+		"`bitwardenFields` returns the custom fields of the item returned by `bw get\n" +
+		"<args>` as a map keyed by field name. Each value is the field's structured data,\n" +
+		"including its `value`.\n" +
+		"\n" +
+		"#### `bitwardenFields` examples\n" +
+		"\n" +
+		"    {{ (bitwardenFields \"item\" \"example.com\").token.value }}\n" +
+		"\n" +
+		"### `fromJson` *json*\n" +
+		"\n" +
+		"`fromJson` parses *json* as JSON and returns the resulting structured data.\n" +
+		"\n" +
+		"#### `fromJson` examples\n" +
+		"\n" +
+		"    {{ (fromJson (output \"curl\" \"-s\" \"https://api.github.com/users/octocat\")).login }}\n" +
+		"\n" +
+		"### `fromToml` *toml*\n" +
+		"\n" +
+		"`fromToml` parses *toml* as TOML and returns the resulting structured data.\n" +
+		"\n" +
+		"#### `fromToml` examples\n" +
+		"\n" +
+		"    {{ (fromToml (include \".config.toml\")).user.email }}\n" +
+		"\n" +
+		"### `fromYaml` *yaml*\n" +
+		"\n" +
+		"`fromYaml` parses *yaml* as YAML and returns the resulting structured data.\n" +
+		"\n" +
+		"#### `fromYaml` examples\n" +
+		"\n" +
+		"    {{ (fromYaml (secret \"get\" \"config\")).password }}\n" +
+		"\n" +
+		"### `glob` *pattern*\n" +
+		"\n" +
+		"`glob` returns the list of files matching *pattern* according to\n" +
+		"[`doublestar.Glob`](https://pkg.go.dev/github.com/bmatcuk/doublestar?tab=doc#Glob).\n" +
+		"\n" +
+		"#### `glob` examples\n" +
+		"\n" +
+		"    {{ range (glob \"/etc/profile.d/*.sh\") }}\n" +
+		"    source {{ . }}\n" +
+		"    {{ end }}\n" +
+		"\n" +
 		"### `gopass` *gopass-name*\n" +
 		"\n" +
 		"`gopass` returns passwords stored in [gopass](https://www.gopass.pw/) using the\n" +
@@ -2466,6 +3152,63 @@ func init() {
 		"\n" +
 		"    {{ gopass \"<pass-name>\" }}\n" +
 		"\n" +
+		"### `gopassFields` *gopass-name*\n" +
+		"\n" +
+		"`gopassFields` returns the fields of the secret *gopass-name* stored in gopass.\n" +
+		"By convention, the first line of a secret is the password and following lines\n" +
+		"of the form `key: value` are fields. `gopassFields` returns a map of keys to\n" +
+		"values, with surrounding whitespace stripped. Other lines are ignored.\n" +
+		"\n" +
+		"#### `gopassFields` examples\n" +
+		"\n" +
+		"    {{ (gopassFields \"<pass-name>\").login }}\n" +
+		"\n" +
+		"### `gopassRaw` *gopass-name*\n" +
+		"\n" +
+		"`gopassRaw` returns the entire contents of the secret *gopass-name* stored in\n" +
+		"gopass, including the password and all following lines.\n" +
+		"\n" +
+		"#### `gopassRaw` examples\n" +
+		"\n" +
+		"    {{ gopassRaw \"<pass-name>\" }}\n" +
+		"\n" +
+		"### `include` *filename*\n" +
+		"\n" +
+		"`include` returns the literal contents of the file named *filename*. Relative\n" +
+		"paths are interpreted relative to the source directory.\n" +
+		"\n" +
+		"#### `include` examples\n" +
+		"\n" +
+		"    {{ include \"foo.txt\" }}\n" +
+		"\n" +
+		"### `includeTemplate` *name* [*data*]\n" +
+		"\n" +
+		"`includeTemplate` executes the template *name* from the `.chezmoitemplates`\n" +
+		"directory with *data* and returns the result. If *data* is not given then the\n" +
+		"template is executed with the current template data.\n" +
+		"\n" +
+		"#### `includeTemplate` examples\n" +
+		"\n" +
+		"    {{ includeTemplate \"aliases\" . | trim }}\n" +
+		"    {{ includeTemplate \"gitconfig-user\" (dict \"email\" .work.email) }}\n" +
+		"\n" +
+		"### `jsonPath` *path* *data*\n" +
+		"\n" +
+		"`jsonPath` returns the value in *data* selected by the\n" +
+		"[JSONPath](https://goessner.net/articles/JsonPath/)-style query *path*. *path*\n" +
+		"consists of an optional leading `$` followed by any number of `.`*key*,\n" +
+		"`[`*index*`]`, `[\"`*key*`\"]`, and `*` or `[*]` components. Negative indexes\n" +
+		"count from the end of a list. If *path* contains a wildcard then a list of all\n" +
+		"matching values is returned, otherwise the single matching value is returned,\n" +
+		"or a false value if there is no match.\n" +
+		"\n" +
+		"#### `jsonPath` examples\n" +
+		"\n" +
+		"    {{ (bitwarden \"item\" \"example.com\") | jsonPath \".login.uris[0].uri\" }}\n" +
+		"    {{ range (fromJson (output \"kubectl\" \"get\" \"contexts\" \"-o\" \"json\") | jsonPath \".items[*].name\") }}\n" +
+		"    {{ . }}\n" +
+		"    {{ end }}\n" +
+		"\n" +
 		"### `keepassxc` *entry*\n" +
 		"\n" +
 		"`keepassxc` returns structured data retrieved from a\n" +
@@ -2474,9 +3217,19 @@ func init() {
 		"the configuration file. *database* and *entry* are passed to `keepassxc-cli\n" +
 		"show`. You will be prompted for the database password the first time\n" +
 		"`keepassxc-cli` is run, and the password is cached, in plain text, in memory\n" +
-		"until chezmoi terminates. The output from `keepassxc-cli` is parsed into\n" +
+		"until chezmoi terminates. If standard input is not a terminal then the password\n" +
+		"is read as a single line from standard input. The output from `keepassxc-cli` is parsed into\n" +
 		"key-value pairs and cached so calling `keepassxc` multiple times with the same\n" +
-		"*entry* will only invoke `keepassxc-cli` once.\n" +
+		"*entry* will only invoke `keepassxc-cli` once. If `keepassxc.keyFile` is set then\n" +
+		"it is passed to `keepassxc-cli` with `--key-file`.\n" +
+		"\n" +
+		"If `keepassxc.mode` is `builtin`, or if it is `cli` and `keepassxc.command`\n" +
+		"cannot be found, then chezmoi reads the database directly instead of running\n" +
+		"`keepassxc-cli`. The database is read and unlocked, with the\n" +
+		"password and `keepassxc.keyFile`, once, and `keepassxc` returns all of the\n" +
+		"entry's attributes, including custom attributes. *entry* is a path relative to\n" +
+		"the root group, for example `group/title`, and is resolved in the same way as\n" +
+		"by `keepassxc-cli`.\n" +
 		"\n" +
 		"#### `keepassxc` examples\n" +
 		"\n" +
@@ -2494,6 +3247,17 @@ func init() {
 		"\n" +
 		"    {{ keepassxcAttribute \"SSH Key\" \"private-key\" }}\n" +
 		"\n" +
+		"### `keepassxcAttachment` *entry* *name*\n" +
+		"\n" +
+		"`keepassxcAttachment` returns the contents of the attachment *name* of *entry*.\n" +
+		"It behaves identically to the `keepassxc` function in terms of configuration,\n" +
+		"password prompting, password storage, and result caching. In `cli` mode it\n" +
+		"requires `keepassxc-cli attachment-export`, which was added in KeePassXC 2.7.\n" +
+		"\n" +
+		"#### `keepassxcAttachment` examples\n" +
+		"\n" +
+		"    {{- keepassxcAttachment \"SSH Key\" \"id_rsa\" -}}\n" +
+		"\n" +
 		"### `keyring` *service* *user*\n" +
 		"\n" +
 		"`keyring` retrieves the password associated with *service* and *user* from the\n" +
@@ -2537,33 +3301,89 @@ func init() {
 		"\n" +
 		"    {{ (index (lastpassRaw \"SSH Private Key\") 0).note }}\n" +
 		"\n" +
-		"### `onepassword` *uuid*\n" +
+		"### `lookPath` *file*\n" +
+		"\n" +
+		"`lookPath` searches for an executable named *file* in the directories named by\n" +
+		"the `PATH` environment variable. If file contains a slash, it is tried directly\n" +
+		"and the `PATH` is not consulted. The result may be an absolute path or a path\n" +
+		"relative to the current directory. If *file* is not found, `lookPath` returns\n" +
+		"an empty string.\n" +
+		"\n" +
+		"#### `lookPath` examples\n" +
+		"\n" +
+		"    {{ if lookPath \"diff-so-fancy\" }}\n" +
+		"    # diff-so-fancy is in $PATH\n" +
+		"    {{ end }}\n" +
+		"\n" +
+		"### `onepassword` *uuid* [*vault* [*account*]]\n" +
 		"\n" +
 		"`onepassword` returns structured data from [1Password](https://1password.com/)\n" +
 		"using the [1Password\n" +
 		"CLI](https://support.1password.com/command-line-getting-started/) (`op`). *uuid*\n" +
-		"is passed to `op get item <uuid>` and the output from `op` is parsed as JSON.\n" +
-		"The output from `op` is cached so calling `onepassword` multiple times with the\n" +
-		"same *uuid* will only invoke `op` once.\n" +
+		"is passed to `op get item <uuid>`, or `op item get <uuid> --format json` with\n" +
+		"version 2 or later of `op`, and the output from `op` is parsed as JSON. If\n" +
+		"*vault* or *account* are given and not empty then they are passed with `--vault`\n" +
+		"and `--account`. The output from `op` is cached so calling `onepassword`\n" +
+		"multiple times with the same arguments will only invoke `op` once.\n" +
+		"If you are not signed in then chezmoi runs `op signin` once, prompting for the\n" +
+		"master password, and uses the session token for all subsequent `op`\n" +
+		"invocations.\n" +
 		"\n" +
 		"#### `onepassword` examples\n" +
 		"\n" +
 		"    {{ (onepassword \"<uuid>\").details.password }}\n" +
+		"    {{ (onepassword \"<uuid>\" \"Personal\").details.password }}\n" +
+		"\n" +
+		"### `onepasswordDetailsFields` *uuid* [*vault* [*account*]]\n" +
 		"\n" +
-		"### `onepasswordDocument` *uuid*\n" +
+		"`onepasswordDetailsFields` returns the built-in fields of the item returned by\n" +
+		"`onepassword`, like its username and password, as a map keyed by the field's\n" +
+		"designation, for example `username` or `password`. Each value is the field's\n" +
+		"structured data, including its `value`. Both the version 1 and version 2 item\n" +
+		"formats of `op` are supported.\n" +
+		"\n" +
+		"#### `onepasswordDetailsFields` examples\n" +
+		"\n" +
+		"    {{ (onepasswordDetailsFields \"<uuid>\").password.value }}\n" +
+		"\n" +
+		"### `onepasswordDocument` *uuid* [*vault* [*account*]]\n" +
 		"\n" +
 		"`onepassword` returns a document from [1Password](https://1password.com/)\n" +
 		"using the [1Password\n" +
 		"CLI](https://support.1password.com/command-line-getting-started/) (`op`). *uuid*\n" +
-		"is passed to `op get document <uuid>` and the output from `op` is returned.\n" +
-		"The output from `op` is cached so calling `onepasswordDocument` multiple times with the\n" +
-		"same *uuid* will only invoke `op` once.\n" +
+		"is passed to `op get document <uuid>`, or `op document get <uuid>` with version 2\n" +
+		"or later of `op`, and the output from `op` is returned. *vault* and *account*\n" +
+		"are handled as for `onepassword`. The output from `op` is cached so calling\n" +
+		"`onepasswordDocument` multiple times with the same arguments will only invoke\n" +
+		"`op` once.\n" +
 		"\n" +
 		"#### `onepasswordDocument` examples\n" +
 		"\n" +
 		"    {{- onepasswordDocument \"<uuid>\" -}}\n" +
 		"\n" +
-		"### `pass` *pass-name*\n" +
+		"### `onepasswordItemFields` *uuid* [*vault* [*account*]]\n" +
+		"\n" +
+		"`onepasswordItemFields` returns the fields in the sections of the item returned\n" +
+		"by `onepassword` as a map keyed by the field's label. Each value is the field's\n" +
+		"structured data. With version 1 of `op` the value is in `v`, with version 2 and\n" +
+		"later it is in `value`.\n" +
+		"\n" +
+		"#### `onepasswordItemFields` examples\n" +
+		"\n" +
+		"    {{ (onepasswordItemFields \"<uuid>\").\"API key\".value }}\n" +
+		"\n" +
+		"### `output` *name* [*args*]\n" +
+		"\n" +
+		"`output` returns the output of executing the command *name* with *args*. If\n" +
+		"executing the command returns an error then template execution exits with an\n" +
+		"error. The output is cached so multiple calls to `output` with the same *name*\n" +
+		"and *args* will only invoke the command once.\n" +
+		"\n" +
+		"#### `output` examples\n" +
+		"\n" +
+		"    current-context: {{ output \"kubectl\" \"config\" \"current-context\" | trim }}\n" +
+		"\n" +
+		"### `pass` *pass-name* [*store*]\n" +
 		"\n" +
 		"`pass` returns passwords stored in [pass](https://www.passwordstore.org/) using\n" +
 		"the pass CLI (`pass`). *pass-name* is passed to `pass show <pass-name>` and\n" +
@@ -2571,19 +3391,108 @@ func init() {
 		"stripped. The output from `pass` is cached so calling `pass` multiple times with\n" +
 		"the same *pass-name* will only invoke `pass` once.\n" +
 		"\n" +
+		"The password store is the one in the `pass.storeDir` configuration variable, if\n" +
+		"set, or pass's default. If *store* is given then the password store is the\n" +
+		"directory with that name in the `pass.stores` configuration variable. Store\n" +
+		"names are case-insensitive.\n" +
+		"\n" +
 		"#### `pass` examples\n" +
 		"\n" +
 		"    {{ pass \"<pass-name>\" }}\n" +
+		"    {{ pass \"<pass-name>\" \"work\" }}\n" +
 		"\n" +
-		"### `promptString` *prompt*\n" +
+		"### `passFields` *pass-name* [*store*]\n" +
 		"\n" +
-		"`promptString` takes a single argument is a string prompted to the user, and the\n" +
-		"return value is the user's response to that prompt with all leading and trailing\n" +
-		"space stripped. It is only available when generating the initial config file.\n" +
+		"`passFields` returns the fields of the entry *pass-name* stored in pass. By\n" +
+		"convention, the first line of an entry is the password and following lines of\n" +
+		"the form `key: value` are fields. `passFields` returns a map of keys to values,\n" +
+		"with surrounding whitespace stripped. Other lines are ignored. *store* is\n" +
+		"interpreted as for `pass`.\n" +
+		"\n" +
+		"#### `passFields` examples\n" +
+		"\n" +
+		"    {{ (passFields \"<pass-name>\").login }}\n" +
+		"\n" +
+		"### `passRaw` *pass-name* [*store*]\n" +
+		"\n" +
+		"`passRaw` returns the entire contents of the entry *pass-name* stored in pass,\n" +
+		"including the password and all following lines. *store* is interpreted as for\n" +
+		"`pass`.\n" +
+		"\n" +
+		"#### `passRaw` examples\n" +
+		"\n" +
+		"    {{ passRaw \"<pass-name>\" }}\n" +
+		"\n" +
+		"### `promptBool` *prompt* [*default*]\n" +
+		"\n" +
+		"`promptBool` prompts the user with *prompt* and returns the user's response\n" +
+		"as a boolean. `1`, `on`, `t`, `true`, `y`, and `yes` are true and `0`, `f`,\n" +
+		"`false`, `n`, `no`, and `off` are false, ignoring case. The user is prompted\n" +
+		"again if the response is not a valid boolean. *default* and answers given in\n" +
+		"advance behave as for `promptString`. It is only available when generating the\n" +
+		"initial config file.\n" +
+		"\n" +
+		"#### `promptBool` examples\n" +
+		"\n" +
+		"    {{ $work := promptBool \"work machine\" false -}}\n" +
+		"    [data]\n" +
+		"        work = {{ $work }}\n" +
+		"\n" +
+		"### `promptChoice` *prompt* *choices* [*default*]\n" +
+		"\n" +
+		"`promptChoice` prompts the user with *prompt* to choose one of *choices*, a\n" +
+		"list of strings, and returns the user's choice. The user is prompted again if\n" +
+		"the response is not one of *choices*. *default* and answers given in advance\n" +
+		"behave as for `promptString`. It is only available when generating the initial\n" +
+		"config file.\n" +
+		"\n" +
+		"#### `promptChoice` examples\n" +
+		"\n" +
+		"    {{ $shell := promptChoice \"shell\" (list \"bash\" \"fish\" \"zsh\") \"bash\" -}}\n" +
+		"    [data]\n" +
+		"        shell = \"{{ $shell }}\"\n" +
+		"\n" +
+		"### `promptInt` *prompt* [*default*]\n" +
+		"\n" +
+		"`promptInt` prompts the user with *prompt* and returns the user's response as an\n" +
+		"integer. The user is prompted again if the response is not a valid integer.\n" +
+		"*default* and answers given in advance behave as for `promptString`. It is only\n" +
+		"available when generating the initial config file.\n" +
+		"\n" +
+		"#### `promptInt` examples\n" +
+		"\n" +
+		"    {{ $monitors := promptInt \"number of monitors\" 1 -}}\n" +
+		"    [data]\n" +
+		"        monitors = {{ $monitors }}\n" +
+		"\n" +
+		"### `promptString` *prompt* [*default*]\n" +
+		"\n" +
+		"`promptString` prompts the user with *prompt* and returns the user's response\n" +
+		"with all leading and trailing space stripped. If *default* is given and the user\n" +
+		"enters an empty response, or if there is no more input, then *default* is\n" +
+		"returned. It is only available when generating the initial config file.\n" +
+		"\n" +
+		"The values in the existing config file's `data` section are available when\n" +
+		"generating the config file, so they can be used as defaults when running\n" +
+		"`chezmoi init` again. On the first `chezmoi init` there are no existing values,\n" +
+		"so, with the default `missingkey=error` template option, `.email` is an error.\n" +
+		"Use `index`, which returns an empty value for missing keys, or guard the lookup\n" +
+		"with `hasKey`, to look up values that might not exist yet.\n" +
+		"\n" +
+		"Answers can be given in advance with `chezmoi init`'s `--promptString` and\n" +
+		"`--promptFile` flags, in which case the user is not prompted.\n" +
 		"\n" +
 		"#### `promptString` examples\n" +
 		"\n" +
-		"    {{ $email := promptString \"email\" -}}\n" +
+		"    {{ $email := promptString \"email\" (index . \"email\") -}}\n" +
+		"    [data]\n" +
+		"        email = \"{{ $email }}\"\n" +
+		"\n" +
+		"    {{ $email := \"\" -}}\n" +
+		"    {{ if hasKey . \"email\" -}}\n" +
+		"    {{   $email = .email -}}\n" +
+		"    {{ end -}}\n" +
+		"    {{ $email = promptString \"email\" $email -}}\n" +
 		"    [data]\n" +
 		"        email = \"{{ $email }}\"\n" +
 		"\n" +
@@ -2601,15 +3510,118 @@ func init() {
 		"parsed as JSON. The output is cached so multiple calls to `secret` with the same\n" +
 		"*args* will only invoke the generic secret command once.\n" +
 		"\n" +
-		"### `vault` *key*\n" +
+		"### `sopsDecrypt` *filename*\n" +
+		"\n" +
+		"`sopsDecrypt` returns the decrypted contents of the\n" +
+		"[sops](https://github.com/mozilla/sops)-encrypted file *filename*, using the\n" +
+		"`sops.command` configuration variable. Relative filenames are relative to the\n" +
+		"source directory. The output is cached so multiple calls to `sopsDecrypt` with\n" +
+		"the same *filename* will only invoke sops once.\n" +
+		"\n" +
+		"#### `sopsDecrypt` examples\n" +
+		"\n" +
+		"    {{ sopsDecrypt \".secrets.env\" }}\n" +
+		"\n" +
+		"### `sopsDecryptData` *filename*\n" +
+		"\n" +
+		"`sopsDecryptData` returns the decrypted contents of the sops-encrypted file\n" +
+		"*filename* as structured data. The file can be in any format supported by sops,\n" +
+		"for example JSON or YAML. The output is cached so multiple calls to\n" +
+		"`sopsDecryptData` with the same *filename* will only invoke sops once.\n" +
+		"\n" +
+		"#### `sopsDecryptData` examples\n" +
+		"\n" +
+		"    {{ (sopsDecryptData \".secrets.yaml\").github.token }}\n" +
+		"\n" +
+		"### `stat` *name*\n" +
+		"\n" +
+		"`stat` runs `stat(2)` on *name*. If *name* exists it returns structured data. If\n" +
+		"*name* does not exist then it returns a false value. If `stat(2)` returns any\n" +
+		"other error then template execution exits with an error. The structured value\n" +
+		"returned if *name* exists contains the fields `name`, `size`, `mode`, `perm`,\n" +
+		"`modTime`, and `isDir`.\n" +
+		"\n" +
+		"#### `stat` examples\n" +
+		"\n" +
+		"    {{ if stat (printf \"%s/.pyenv\" .chezmoi.homedir) }}\n" +
+		"    # ~/.pyenv exists\n" +
+		"    {{ end }}\n" +
+		"\n" +
+		"### `toToml` *value*\n" +
+		"\n" +
+		"`toToml` returns the TOML representation of *value*.\n" +
+		"\n" +
+		"#### `toToml` examples\n" +
+		"\n" +
+		"    {{ dict \"user\" (dict \"email\" .email) | toToml }}\n" +
+		"\n" +
+		"### `toYaml` *value*\n" +
+		"\n" +
+		"`toYaml` returns the YAML representation of *value*.\n" +
+		"\n" +
+		"#### `toYaml` examples\n" +
+		"\n" +
+		"    {{ dict \"user\" (dict \"email\" .email) | toYaml }}\n" +
+		"\n" +
+		"### `vault` *key* [*version*]\n" +
 		"\n" +
 		"`vault` returns structured data from [Vault](https://www.vaultproject.io/) using\n" +
 		"the [Vault CLI](https://www.vaultproject.io/docs/commands/) (`vault`). *key* is\n" +
 		"passed to `vault kv get -format=json <key>` and the output from `vault` is\n" +
-		"parsed as JSON. The output from `vault` is cached so calling `vault` multiple\n" +
-		"times with the same *key* will only invoke `vault` once.\n" +
+		"parsed as JSON. If *version* is given then that version of a secret in version 2\n" +
+		"of the KV secrets engine is returned. The output from `vault` is cached so\n" +
+		"calling `vault` multiple times with the same arguments will only invoke `vault`\n" +
+		"once.\n" +
+		"\n" +
+		"If `vault.address` or `vault.namespace` are set then they are passed to all\n" +
+		"`vault` invocations with `-address` and `-namespace`. If `vault.mount` is set\n" +
+		"then it is passed to `vault kv get` with `-mount`.\n" +
 		"\n" +
 		"#### `vault` examples\n" +
 		"\n" +
-		"    {{ (vault \"<key>\").data.data.password }}\n")
+		"    {{ (vault \"<key>\").data.data.password }}\n" +
+		"    {{ (vault \"<key>\" 3).data.data.password }}\n" +
+		"\n" +
+		"### `vaultField` *key* *field* [*version*]\n" +
+		"\n" +
+		"`vaultField` returns the value of *field* in the secret returned by `vault`.\n" +
+		"Both version 1 and version 2 of the KV secrets engine are supported. It is an\n" +
+		"error if the secret does not contain *field*.\n" +
+		"\n" +
+		"#### `vaultField` examples\n" +
+		"\n" +
+		"    {{ vaultField \"<key>\" \"password\" }}\n" +
+		"\n" +
+		"### `vaultRead` *path* [*args*]\n" +
+		"\n" +
+		"`vaultRead` returns structured data from any Vault secrets engine, for example\n" +
+		"dynamic database credentials, using `vault read -format=json <path> <args>`.\n" +
+		"*args* are `key=value` pairs. The output from `vault` is cached so calling\n" +
+		"`vaultRead` multiple times with the same arguments will only invoke `vault`\n" +
+		"once, which ensures that dynamic secrets are consistent.\n" +
+		"\n" +
+		"#### `vaultRead` examples\n" +
+		"\n" +
+		"    {{- $creds := vaultRead \"database/creds/readonly\" }}\n" +
+		"    username = {{ $creds.data.username }}\n" +
+		"    password = {{ $creds.data.password }}\n" +
+		"\n" +
+		"### `vaultWrite` *path* [*args*]\n" +
+		"\n" +
+		"`vaultWrite` returns the structured data output by `vault write -format=json\n" +
+		"<path> <args>`, which is needed by secrets engines like PKI that generate\n" +
+		"secrets on write. *args* are `key=value` pairs.\n" +
+		"\n" +
+		"Writing changes the state of the Vault server, for example each write to the\n" +
+		"PKI secrets engine issues a new certificate. The output is therefore cached in\n" +
+		"the persistent state for `vault.writeTTL`, regardless of `vault.cacheTTL`, and\n" +
+		"reused until it expires so that the target state does not change on every\n" +
+		"`chezmoi apply`. Set `vault.writeTTL` to less than the lifetime of the\n" +
+		"generated secret. When chezmoi is not making changes, for example with\n" +
+		"`--dry-run` and in `chezmoi cat`, `chezmoi diff`, and `chezmoi verify`,\n" +
+		"`vaultWrite` uses the cached output and returns an error if there is none.\n" +
+		"\n" +
+		"#### `vaultWrite` examples\n" +
+		"\n" +
+		"    {{ (vaultWrite \"pki/issue/example-dot-com\" \"common_name=www.example.com\").data.certificate }}\n")
 }
@@ -72,6 +72,14 @@ type doctorFileCheck struct {
 
 type doctorRuntimeCheck struct{}
 
+type doctorSessionCheck struct {
+	name       string
+	binaryName string
+	statusFunc func() (string, bool, error)
+	status     string
+	err        error
+}
+
 type doctorSuspiciousFilesCheck struct {
 	path      string
 	filenames map[string]bool
@@ -156,11 +164,27 @@ func (c *Config) runDoctorCmd(cmd *cobra.Command, args []string) error {
 		},
 		vcsCommandCheck,
 		gpgBinaryCheck,
+		&doctorBinaryCheck{
+			name:          "sops",
+			binaryName:    c.Sops.Command,
+			versionArgs:   []string{"--version"},
+			versionRegexp: regexp.MustCompile(`^sops (\d+\.\d+\.\d+)`),
+		},
 		&doctorBinaryCheck{
 			name:          "1Password CLI",
 			binaryName:    c.Onepassword.Command,
 			versionArgs:   []string{"--version"},
-			versionRegexp: regexp.MustCompile(`^(\d+\.\d+\.\d+)`),
+			versionRegexp: onepasswordVersionRegexp,
+		},
+		&doctorSessionCheck{
+			name:       "1Password session",
+			binaryName: c.Onepassword.Command,
+			statusFunc: func() (string, bool, error) {
+				if c.onepasswordSignedIn() {
+					return "signed in", true, nil
+				}
+				return "signed out", false, nil
+			},
 		},
 		&doctorBinaryCheck{
 			name:          "Bitwarden CLI",
@@ -168,6 +192,14 @@ func (c *Config) runDoctorCmd(cmd *cobra.Command, args []string) error {
 			versionArgs:   []string{"--version"},
 			versionRegexp: regexp.MustCompile(`^(\d+\.\d+\.\d+)`),
 		},
+		&doctorSessionCheck{
+			name:       "Bitwarden session",
+			binaryName: c.Bitwarden.Command,
+			statusFunc: func() (string, bool, error) {
+				status, err := c.bitwardenStatus()
+				return status, status == bitwardenStatusUnlocked, err
+			},
+		},
 		&doctorBinaryCheck{
 			name:          "gopass CLI",
 			binaryName:    c.Gopass.Command,
@@ -395,6 +427,32 @@ func (doctorRuntimeCheck) Skip() bool {
 	return false
 }
 
+func (c *doctorSessionCheck) Check() (bool, error) {
+	var ok bool
+	c.status, ok, c.err = c.statusFunc()
+	return ok && c.err == nil, nil
+}
+
+func (c *doctorSessionCheck) Enabled() bool {
+	return c.binaryName != ""
+}
+
+func (c *doctorSessionCheck) MustSucceed() bool {
+	return false
+}
+
+func (c *doctorSessionCheck) Result() string {
+	if c.err != nil {
+		return fmt.Sprintf("%s (%s, %v)", c.binaryName, c.name, c.err)
+	}
+	return fmt.Sprintf("%s (%s, %s)", c.binaryName, c.name, c.status)
+}
+
+func (c *doctorSessionCheck) Skip() bool {
+	_, err := exec.LookPath(c.binaryName)
+	return err != nil
+}
+
 func (c *doctorSuspiciousFilesCheck) Check() (bool, error) {
 	if err := filepath.Walk(c.path, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -45,6 +45,7 @@ func TestDumpCmd(t *testing.T) {
 					"empty":      false,
 					"encrypted":  false,
 					"perm":       float64(0o644),
+					"sops":       false,
 					"template":   false,
 					"contents":   "contents",
 				},
@@ -78,23 +78,31 @@ func (c *Config) runEditCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	// Build a list of source file names to pass to the editor. Check that each
-	// is either a file or a symlink. If the entry is an encrypted file then
-	// remember it.
+	// is either a block, a file, or a symlink. If the entry is an encrypted
+	// file then remember it.
 	argv := make([]string, len(entries))
 	var encryptedFiles []encryptedFile
 	for i, entry := range entries {
+		if chezmoi.InArchive(entry) {
+			return fmt.Errorf("%s: in encrypted archive, edit the target and re-add its directory with --archive", args[i])
+		}
 		argv[i] = filepath.Join(c.SourceDir, entry.SourceName())
-		if file, ok := entry.(*chezmoi.File); ok {
-			if file.Encrypted {
+		switch entry := entry.(type) {
+		case *chezmoi.File:
+			if entry.Sops {
+				return fmt.Errorf("%s: encrypted with sops, edit %s with sops", args[i], argv[i])
+			}
+			if entry.Encrypted {
 				ef := encryptedFile{
 					index:          i,
-					file:           file,
+					file:           entry,
 					ciphertextPath: argv[i],
 				}
 				encryptedFiles = append(encryptedFiles, ef)
 			}
-		} else if _, ok := entry.(*chezmoi.Symlink); !ok {
-			return fmt.Errorf("%s: not a file or symlink", args[i])
+		case *chezmoi.Block, *chezmoi.Symlink:
+		default:
+			return fmt.Errorf("%s: not a block, file, or symlink", args[i])
 		}
 	}
 
@@ -134,7 +142,7 @@ func (c *Config) runEditCmd(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
-		ciphertext, err := ts.GPG.Encrypt(ef.plaintextPath, plaintext)
+		ciphertext, err := ts.Encryption.Encrypt(ef.plaintextPath, plaintext)
 		if err != nil {
 			return err
 		}
@@ -168,7 +176,7 @@ func (c *Config) runEditCmd(cmd *cobra.Command, args []string) error {
 		anyMutator := chezmoi.NewAnyMutator(chezmoi.NullMutator{})
 		var mutator chezmoi.Mutator = anyMutator
 		if c.edit.diff {
-			mutator = chezmoi.NewVerboseMutator(c.Stdout, mutator, c.colored, c.maxDiffDataSize)
+			mutator = chezmoi.NewVerboseMutator(c.Stdout, mutator, c.colored, c.maxDiffDataSize, c.getRedactor())
 		}
 		if err := entry.Apply(readOnlyFS, mutator, c.Follow, &applyOptions); err != nil {
 			return err
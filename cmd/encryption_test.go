@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestAgeEncryption(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".config/chezmoi/key.txt": identity.String() + "\n",
+			".local/share/chezmoi":    &vfst.Dir{Perm: 0o700},
+			".netrc":                  "machine example.com login user password secret\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	stdout := &strings.Builder{}
+	c := newTestConfig(
+		fs,
+		withAddCmdConfig(addCmdConfig{
+			options: chezmoi.AddOptions{
+				Encrypt: true,
+			},
+		}),
+		withStdout(stdout),
+	)
+	c.Encryption = "age"
+	c.Age.Identity = "/home/user/.config/chezmoi/key.txt"
+
+	require.NoError(t, c.runAddCmd(&cobra.Command{}, []string{"/home/user/.netrc"}))
+	ciphertext, err := fs.ReadFile("/home/user/.local/share/chezmoi/encrypted_dot_netrc")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(ciphertext), armor.Header))
+
+	require.NoError(t, c.runCatCmd(&cobra.Command{}, []string{"/home/user/.netrc"}))
+	assert.Equal(t, "machine example.com login user password secret\n", stdout.String())
+}
+
+func TestAgeEncryptionArchive(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".config/chezmoi/key.txt": identity.String() + "\n",
+			".local/share/chezmoi":    &vfst.Dir{Perm: 0o700},
+			".aws/credentials":        "# credentials\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	stdout := &strings.Builder{}
+	c := newTestConfig(
+		fs,
+		withAddCmdConfig(addCmdConfig{
+			options: chezmoi.AddOptions{
+				Archive: true,
+			},
+		}),
+		withStdout(stdout),
+	)
+	c.Encryption = "age"
+	c.Age.Identity = "/home/user/.config/chezmoi/key.txt"
+
+	require.NoError(t, c.runAddCmd(&cobra.Command{}, []string{"/home/user/.aws"}))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/dot_aws/.chezmoiarchive",
+			vfst.TestModeIsRegular,
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi/dot_aws/credentials",
+			vfst.TestDoesNotExist,
+		),
+	)
+
+	require.NoError(t, c.runCatCmd(&cobra.Command{}, []string{"/home/user/.aws/credentials"}))
+	assert.Equal(t, "# credentials\n", stdout.String())
+	assert.Error(t, c.runEditCmd(&cobra.Command{}, []string{"/home/user/.aws/credentials"}))
+}
+
+func TestUnknownEncryption(t *testing.T) {
+	c := newConfig()
+	c.Encryption = "unknown"
+	_, err := c.getEncryption()
+	assert.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"io/ioutil"
 	"strconv"
 	"strings"
@@ -11,7 +12,11 @@ import (
 type executeTemplateCmdConfig struct {
 	init         bool
 	output       string
+	promptBool   map[string]string
+	promptChoice map[string]string
+	promptInt    map[string]string
 	promptString map[string]string
+	trace        bool
 }
 
 var executeTemplateCmd = &cobra.Command{
@@ -29,19 +34,49 @@ func init() {
 	persistentFlags := executeTemplateCmd.PersistentFlags()
 	persistentFlags.BoolVarP(&config.executeTemplate.init, "init", "i", false, "simulate chezmoi init")
 	persistentFlags.StringVarP(&config.executeTemplate.output, "output", "o", "", "output filename")
+	persistentFlags.StringToStringVar(&config.executeTemplate.promptBool, "promptBool", nil, "simulate promptBool")
+	persistentFlags.StringToStringVar(&config.executeTemplate.promptChoice, "promptChoice", nil, "simulate promptChoice")
+	persistentFlags.StringToStringVar(&config.executeTemplate.promptInt, "promptInt", nil, "simulate promptInt")
 	persistentFlags.StringToStringVarP(&config.executeTemplate.promptString, "promptString", "p", nil, "simulate promptString")
+	persistentFlags.BoolVar(&config.executeTemplate.trace, "trace", false, "write a trace of data and functions used to stderr")
 }
 
-func (c *Config) runExecuteTemplateCmd(cmd *cobra.Command, args []string) error {
+func (c *Config) runExecuteTemplateCmd(cmd *cobra.Command, args []string) (err error) {
 	if c.executeTemplate.init {
-		c.templateFuncs["promptString"] = func(prompt string) string {
-			if value, ok := c.executeTemplate.promptString[prompt]; ok {
-				return value
+		c.templateFuncs["promptBool"] = func(prompt string, args ...interface{}) bool {
+			value, err := parseBool(simulatePrompt(c.executeTemplate.promptBool, prompt, "false", args))
+			if err != nil {
+				panic(fmt.Errorf("promptBool: %s: %w", prompt, err))
+			}
+			return value
+		}
+		c.templateFuncs["promptChoice"] = func(prompt string, choices interface{}, args ...interface{}) string {
+			choiceStrs, err := toStrings(choices)
+			if err != nil || len(choiceStrs) == 0 {
+				panic(fmt.Errorf("promptChoice: %s: invalid choices", prompt))
 			}
-			return prompt
+			return simulatePrompt(c.executeTemplate.promptChoice, prompt, choiceStrs[0], args)
+		}
+		c.templateFuncs["promptInt"] = func(prompt string, args ...interface{}) int {
+			value, err := strconv.Atoi(simulatePrompt(c.executeTemplate.promptInt, prompt, "0", args))
+			if err != nil {
+				panic(fmt.Errorf("promptInt: %s: %w", prompt, err))
+			}
+			return value
+		}
+		c.templateFuncs["promptString"] = func(prompt string, args ...interface{}) string {
+			return simulatePrompt(c.executeTemplate.promptString, prompt, prompt, args)
 		}
 	}
 
+	if c.executeTemplate.trace {
+		c.startTemplateTrace()
+		defer func() {
+			if traceErr := c.writeTemplateTrace(c.Stderr); err == nil {
+				err = traceErr
+			}
+		}()
+	}
 	ts, err := c.getTargetState(nil)
 	if err != nil {
 		return err
@@ -73,8 +108,20 @@ func (c *Config) runExecuteTemplateCmd(cmd *cobra.Command, args []string) error
 	}
 
 	if c.executeTemplate.output == "" {
-		_, err = c.Stdout.Write([]byte(output.String()))
+		_, err = c.Stdout.Write([]byte(c.getRedactor().RedactString(output.String())))
 		return err
 	}
 	return c.fs.WriteFile(c.executeTemplate.output, []byte(output.String()), 0o666)
 }
+
+// simulatePrompt returns the answer to prompt in answers, or the default value
+// in args, or fallback.
+func simulatePrompt(answers map[string]string, prompt, fallback string, args []interface{}) string {
+	if value, ok := answers[prompt]; ok {
+		return value
+	}
+	if len(args) > 0 && args[0] != nil && args[0] != "" {
+		return fmt.Sprint(args[0])
+	}
+	return fallback
+}
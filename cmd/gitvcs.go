@@ -38,6 +38,10 @@ func (gitVCS) PushArgs() []string {
 	return []string{"push"}
 }
 
+func (gitVCS) ShallowCloneArgs(repo, dir string) []string {
+	return []string{"clone", "--depth", "1", "--shallow-submodules", repo, dir}
+}
+
 func (gitVCS) StatusArgs() []string {
 	return []string{"status", "--porcelain=v2"}
 }
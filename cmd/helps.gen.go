@@ -13,13 +13,29 @@ var helps = map[string]help{
 			"Description:\n" +
 			"  Add *targets* to the source state. If any target is already in the source\n" +
 			"  state, then its source state is replaced with its current state in the\n" +
-			"  destination directory. The `add` command accepts additional flags:\n" +
+			"  destination directory. Unless the `--encrypt` flag is given, added files are\n" +
+			"  scanned for possible secrets, and chezmoi warns about or refuses to add them\n" +
+			"  according to the `secrets` configuration variable. The `add` command accepts\n" +
+			"  additional flags:\n" +
+			"\n" +
+			"  `--archive`\n" +
+			"\n" +
+			"  Add directories as a single encrypted archive, hiding the names of the files\n" +
+			"  they contain. This implies the `--encrypt` option. Directories whose contents\n" +
+			"  are already managed without an archive cannot be added as an archive; remove\n" +
+			"  them from the source state first. See .chezmoiarchive.\n" +
 			"\n" +
 			"  `--autotemplate`\n" +
 			"\n" +
-			"  Automatically generate a template by replacing strings with variable names\n" +
-			"  from the `data` section of the config file. Longer substitutions occur before\n" +
-			"  shorter ones. This implies the `--template` option.\n" +
+			"  Automatically generate a template by replacing strings with references to the\n" +
+			"  template data, including values in nested maps and lists. Longer substitutions\n" +
+			"  occur before shorter ones, and strings are only replaced on word boundaries.\n" +
+			"  This implies the `--template` option.\n" +
+			"\n" +
+			"  `--autotemplate-min-length` *length*\n" +
+			"\n" +
+			"  Only replace strings of at least *length* bytes when generating templates with\n" +
+			"  `--autotemplate`.\n" +
 			"\n" +
 			"  `-e`, `--empty`\n" +
 			"\n" +
@@ -38,6 +54,11 @@ var helps = map[string]help{
 			"\n" +
 			"  Interactively prompt before adding each file.\n" +
 			"\n" +
+			"  `--preview`\n" +
+			"\n" +
+			"  With `--autotemplate`, print the substitutions that would be made in each file\n" +
+			"  and prompt before adding it.\n" +
+			"\n" +
 			"  `-r`, `--recursive`\n" +
 			"\n" +
 			"  Recursively add all files, directories, and symlinks.\n" +
@@ -48,8 +69,10 @@ var helps = map[string]help{
 		example: "" +
 			"  chezmoi add ~/.bashrc\n" +
 			"  chezmoi add ~/.gitconfig --template\n" +
+			"  chezmoi add ~/.gitconfig --autotemplate --preview\n" +
 			"  chezmoi add ~/.vim --recursive\n" +
-			"  chezmoi add ~/.oh-my-zsh --exact --recursive",
+			"  chezmoi add ~/.oh-my-zsh --exact --recursive\n" +
+			"  chezmoi add ~/.aws --archive",
 	},
 	"apply": {
 		long: "" +
@@ -77,11 +100,21 @@ var helps = map[string]help{
 	"cat": {
 		long: "" +
 			"Description:\n" +
-			"  Write the target state of *targets*  to stdout. *targets* must be files or\n" +
-			"  symlinks. For files, the target file contents are written. For symlinks, the\n" +
-			"  target target is written.",
+			"  Write the target state of *targets*  to stdout. *targets* must be blocks,\n" +
+			"  files, or symlinks. For blocks, the block contents are written. For files, the\n" +
+			"  target file contents are written. For symlinks, the target target is written.\n" +
+			"  The `cat` command accepts additional flags:\n" +
+			"\n" +
+			"  `--trace`\n" +
+			"\n" +
+			"  Write a trace of template execution to stderr. Each line of the trace records\n" +
+			"  a data key accessed, a template function called with its arguments and result,\n" +
+			"  or a partial template included, along with the template in which it occurred.\n" +
+			"  The results of secret functions, e.g. `bitwarden` and `pass`, are replaced\n" +
+			"  with `<redacted>`.",
 		example: "" +
-			"  chezmoi cat ~/.bashrc",
+			"  chezmoi cat ~/.bashrc\n" +
+			"  chezmoi cat --trace ~/.gitconfig",
 	},
 	"cd": {
 		long: "" +
@@ -191,7 +224,9 @@ var helps = map[string]help{
 	"doctor": {
 		long: "" +
 			"Description:\n" +
-			"  Check for potential problems.",
+			"  Check for potential problems. If the Bitwarden or 1Password CLIs are installed\n" +
+			"  then `doctor` also reports whether the Bitwarden vault is unlocked and whether\n" +
+			"  you are signed in to 1Password.",
 		example: "" +
 			"  chezmoi doctor",
 	},
@@ -199,7 +234,8 @@ var helps = map[string]help{
 		long: "" +
 			"Description:\n" +
 			"  Dump the target state in JSON format. If no targets are specified, then the\n" +
-			"  entire target state. The `dump` command accepts additional arguments:\n" +
+			"  entire target state. Secrets are redacted unless `--show-secrets` is given. The\n" +
+			"  `dump` command accepts additional arguments:\n" +
 			"\n" +
 			"  `-f`, `--format` *format*\n" +
 			"\n" +
@@ -212,9 +248,9 @@ var helps = map[string]help{
 	"edit": {
 		long: "" +
 			"Description:\n" +
-			"  Edit the source state of *targets*, which must be files or symlinks. If no\n" +
-			"  targets are given the the source directory itself is opened with `$EDITOR`.\n" +
-			"  The `edit` command accepts additional arguments:\n" +
+			"  Edit the source state of *targets*, which must be blocks, files, or symlinks.\n" +
+			"  If no targets are given the the source directory itself is opened with\n" +
+			"  `$EDITOR`. The `edit` command accepts additional arguments:\n" +
 			"\n" +
 			"  `-a`, `--apply`\n" +
 			"\n" +
@@ -248,7 +284,8 @@ var helps = map[string]help{
 			"  Execute *templates*. This is useful for testing templates or for calling\n" +
 			"  chezmoi from other scripts. *templates* are interpreted as literal templates,\n" +
 			"  with no whitespace added to the output between arguments. If no templates are\n" +
-			"  specified, the template is read from stdin.\n" +
+			"  specified, the template is read from stdin. Secrets in the output are redacted\n" +
+			"  unless `--output` or `--show-secrets` is given.\n" +
 			"\n" +
 			"  `--init`, `-i`\n" +
 			"\n" +
@@ -258,18 +295,35 @@ var helps = map[string]help{
 			"\n" +
 			"  Write the output to *filename* instead of stdout.\n" +
 			"\n" +
+			"  `--promptBool`, `--promptChoice`, `--promptInt` *pairs*\n" +
+			"\n" +
+			"  Simulate the `promptBool`, `promptChoice`, and `promptInt` functions with\n" +
+			"  functions that return values from *pairs*. *pairs* is a comma-separated list of\n" +
+			"  *prompt*`=`*value* pairs. If a function is called with a *prompt* that does\n" +
+			"  not match any of *pairs*, then it returns its default, if given, or otherwise\n" +
+			"  `false`, the first choice, or `0` respectively.\n" +
+			"\n" +
 			"  `--promptString`, `-p` *pairs*\n" +
 			"\n" +
 			"  Simulate the `promptString` function with a function that returns values from\n" +
 			"  *pairs*. *pairs* is a comma-separated list of *prompt*`=`*value* pairs. If\n" +
 			"  `promptString` is called with a *prompt* that does not match any of *pairs*,\n" +
-			"  then it returns *prompt* unchanged.\n" +
+			"  then it returns its default, if given, or otherwise *prompt* unchanged.\n" +
+			"\n" +
+			"  `--trace`\n" +
+			"\n" +
+			"  Write a trace of template execution to stderr. Each line of the trace records\n" +
+			"  a data key accessed, a template function called with its arguments and result,\n" +
+			"  or a partial template included, along with the template in which it occurred.\n" +
+			"  The results of secret functions, e.g. `bitwarden` and `pass`, are replaced\n" +
+			"  with `<redacted>`.\n" +
 			"\n" +
 			"  `execute-template` examples\n" +
 			"\n" +
 			"    chezmoi execute-template '{{ .chezmoi.sourceDir }}'\n" +
 			"    chezmoi execute-template '{{ .chezmoi.os }}' / '{{ .chezmoi.arch }}'\n" +
 			"    echo '{{ .chezmoi | toJson }}' | chezmoi execute-template\n" +
+			"    chezmoi execute-template --trace '{{ .email }}'\n" +
 			"    chezmoi execute-template --init --promptString email=john@home.org <\n" +
 			"  ~/.local/share/chezmoi/.chezmoi.toml.tmpl",
 	},
@@ -342,10 +396,78 @@ var helps = map[string]help{
 			"  If a file called `.chezmoi.format.tmpl` exists, where `format` is one of the\n" +
 			"  supported file formats (e.g. `json`, `toml`, or `yaml`) then a new\n" +
 			"  configuration file is created using that file as a template. Finally, if the `--\n" +
-			"  apply` flag is passed, `chezmoi apply` is run.",
+			"  apply` flag is passed, `chezmoi apply` is run.\n" +
+			"\n" +
+			"  `--apply`\n" +
+			"\n" +
+			"  Run `chezmoi apply` after checking out the repo and creating the config file.\n" +
+			"\n" +
+			"  `--one-shot`\n" +
+			"\n" +
+			"  Check out *repo* into a temporary directory with a shallow clone if the VCS\n" +
+			"  supports it, create the config file and persistent state in the same temporary\n" +
+			"  directory, run `chezmoi apply`, and then remove the temporary directory. This\n" +
+			"  leaves nothing behind except the targets themselves, which is useful for\n" +
+			"  throwaway containers and CI. `--one-shot` implies `--apply` and requires *repo*.\n" +
+			"\n" +
+			"  `--skip-persistent-state`\n" +
+			"\n" +
+			"  Do not read or write the persistent state when applying. `run_once_` scripts\n" +
+			"  are run every time.\n" +
+			"\n" +
+			"  `--skip-scripts`\n" +
+			"\n" +
+			"  Do not run any scripts when applying.\n" +
+			"\n" +
+			"  `--promptBool`, `--promptChoice`, `--promptInt`, `--promptString` *pairs*\n" +
+			"\n" +
+			"  Answer calls to the corresponding prompt function in the config file template\n" +
+			"  without prompting the user. *pairs* is a comma-separated list of\n" +
+			"  *prompt*`=`*value* pairs.\n" +
+			"\n" +
+			"  `--promptFile` *filename*\n" +
+			"\n" +
+			"  Read answers to calls to any prompt function in the config file template from\n" +
+			"  *filename*, a JSON, TOML, or YAML file mapping prompts to values. Answers\n" +
+			"  given with the `--prompt*` flags take priority over answers in *filename*.\n" +
+			"\n" +
+			"  Together with defaults, these flags allow `chezmoi init` to run unattended,\n" +
+			"  for example in CI or when building containers.",
 		example: "" +
 			"  chezmoi init https://github.com/user/dotfiles.git\n" +
-			"  chezmoi init https://github.com/user/dotfiles.git --apply",
+			"  chezmoi init https://github.com/user/dotfiles.git --apply\n" +
+			"  chezmoi init https://github.com/user/dotfiles.git --promptString\n" +
+			"email=john@home.org --promptBool \"work machine=false\"\n" +
+			"  chezmoi init https://github.com/user/dotfiles.git --promptFile answers.yaml <\n" +
+			"/dev/null\n" +
+			"  chezmoi init --one-shot --skip-scripts https://github.com/user/dotfiles.git",
+	},
+	"lint": {
+		long: "" +
+			"Description:\n" +
+			"  Check the source state for problems without applying it or executing any\n" +
+			"  templates. `lint` reports:\n" +
+			"\n" +
+			"  • Template syntax errors in files, scripts, symlinks, managed blocks,\n" +
+			"  `.chezmoiignore`, `.chezmoiremove`, `.chezmoitemplates`, and the config file\n" +
+			"  template.\n" +
+			"  • References to undefined functions.\n" +
+			"  • References to undefined data keys, where the value of dot is known.\n" +
+			"  • Templates in `.chezmoitemplates` that are never used.\n" +
+			"  • Invalid or misordered attributes in source names.\n" +
+			"  • Multiple source files or directories that manage the same target.\n" +
+			"\n" +
+			"  Templates in encrypted files and the contents of encrypted archives are not\n" +
+			"  checked, and nothing is decrypted. `lint` exits with a non-zero exit status if\n" +
+			"  any problems are found. `check` is an alias for `lint`.\n" +
+			"\n" +
+			"  `-f`, `--format` *format*\n" +
+			"\n" +
+			"  Print problems in the given format. The accepted formats are `text` (one\n" +
+			"  problem per line), `json` (JSON), and `yaml` (YAML).",
+		example: "" +
+			"  chezmoi lint\n" +
+			"  chezmoi lint --format=json",
 	},
 	"manage": {
 		long: "" +
@@ -396,10 +518,28 @@ var helps = map[string]help{
 			"  chezmoi purge\n" +
 			"  chezmoi purge --force",
 	},
+	"rekey": {
+		long: "" +
+			"Description:\n" +
+			"  Decrypt each encrypted file in the source state and re-encrypt it with the\n" +
+			"  current encryption settings, for example after adding or removing a recipient\n" +
+			"  from `gpg.recipients` or `age.recipients`. If *targets* are given then only\n" +
+			"  encrypted files in *targets* are re-encrypted. Changes are written through the\n" +
+			"  usual mechanisms, so `--dry-run` and `--verbose` are respected. Files keep their\n" +
+			"  permissions. chezmoi records the recipients that each file was re-encrypted for\n" +
+			"  in its persistent state, and files that have not changed since they were last\n" +
+			"  re-encrypted for the current recipients are not rewritten.",
+		example: "" +
+			"  chezmoi rekey\n" +
+			"  chezmoi rekey --dry-run --verbose\n" +
+			"  chezmoi rekey ~/.ssh",
+	},
 	"remove": {
 		long: "" +
 			"Description:\n" +
-			"  Remove *targets* from both the source state and the destination directory.\n" +
+			"  Remove *targets* from both the source state and the destination directory. If\n" +
+			"  a target is a managed block then only the block, including its markers, is\n" +
+			"  removed from the target and the rest of the target is left untouched.\n" +
 			"\n" +
 			"  `-f`, `--force`\n" +
 			"\n" +
@@ -421,9 +561,18 @@ var helps = map[string]help{
 			"\n" +
 			"  To get a full list of available commands run:\n" +
 			"\n" +
-			"    chezmoi secret help",
+			"    chezmoi secret help\n" +
+			"\n" +
+			"  If a secret manager's `cacheTTL` configuration variable is set, then chezmoi\n" +
+			"  caches the output of the secret manager's CLI in its persistent state for that\n" +
+			"  duration, so secrets are not retrieved again on every invocation. The cache is\n" +
+			"  encrypted with a randomly-generated key stored in `chezmoisecretcache.key` in\n" +
+			"  the same directory as the persistent state. `chezmoi secret cache clear`\n" +
+			"  clears the cache, and exits with an error if it cannot, for example with `--dry-\n" +
+			"  run`.",
 		example: "" +
 			"  chezmoi secret bitwarden list items\n" +
+			"  chezmoi secret cache clear\n" +
 			"  chezmoi secret keyring set --service service --user user\n" +
 			"  chezmoi secret keyring get --service service --user user\n" +
 			"  chezmoi secret lastpass ls\n" +
@@ -431,6 +580,7 @@ var helps = map[string]help{
 			"  chezmoi secret onepassword list items\n" +
 			"  chezmoi secret onepassword get item id\n" +
 			"  chezmoi secret pass show id\n" +
+			"  chezmoi secret sops -- --decrypt secrets.yaml\n" +
 			"  chezmoi secret vault -- kv get -format=json id",
 	},
 	"source": {
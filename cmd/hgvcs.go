@@ -34,6 +34,10 @@ func (hgVCS) PushArgs() []string {
 	return nil
 }
 
+func (hgVCS) ShallowCloneArgs(repo, dir string) []string {
+	return nil
+}
+
 func (hgVCS) StatusArgs() []string {
 	return nil
 }
@@ -1,17 +1,23 @@
 package cmd
 
 import (
-	"bufio"
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"text/template"
 
+	"github.com/pelletier/go-toml"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	vfs "github.com/twpayne/go-vfs"
+	yaml "gopkg.in/yaml.v2"
 
 	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
@@ -27,7 +33,16 @@ var initCmd = &cobra.Command{
 }
 
 type initCmdConfig struct {
-	apply bool
+	apply               bool
+	oneShot             bool
+	promptAnswers       map[string]interface{}
+	promptBool          map[string]string
+	promptChoice        map[string]string
+	promptFile          string
+	promptInt           map[string]string
+	promptString        map[string]string
+	skipPersistentState bool
+	skipScripts         bool
 }
 
 func init() {
@@ -35,9 +50,37 @@ func init() {
 
 	persistentFlags := initCmd.PersistentFlags()
 	persistentFlags.BoolVar(&config.init.apply, "apply", false, "update destination directory")
+	persistentFlags.BoolVar(&config.init.oneShot, "one-shot", false, "clone and apply, then remove all of chezmoi's files")
+	persistentFlags.StringToStringVar(&config.init.promptBool, "promptBool", nil, "answers to promptBool")
+	persistentFlags.StringToStringVar(&config.init.promptChoice, "promptChoice", nil, "answers to promptChoice")
+	persistentFlags.StringVar(&config.init.promptFile, "promptFile", "", "file containing answers to prompts")
+	persistentFlags.StringToStringVar(&config.init.promptInt, "promptInt", nil, "answers to promptInt")
+	persistentFlags.StringToStringVar(&config.init.promptString, "promptString", nil, "answers to promptString")
+	persistentFlags.BoolVar(&config.init.skipPersistentState, "skip-persistent-state", false, "do not read or write the persistent state")
+	persistentFlags.BoolVar(&config.init.skipScripts, "skip-scripts", false, "do not run scripts")
+
+	panicOnError(initCmd.MarkPersistentFlagFilename("promptFile", "json", "toml", "yaml", "yml"))
 }
 
-func (c *Config) runInitCmd(cmd *cobra.Command, args []string) error {
+func (c *Config) runInitCmd(cmd *cobra.Command, args []string) (err error) {
+	if c.init.oneShot {
+		if len(args) == 0 {
+			return errors.New("--one-shot requires a repo")
+		}
+		var tempDir string
+		tempDir, err = c.useOneShotDirs()
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if removeErr := c.fs.RemoveAll(tempDir); err == nil {
+				err = removeErr
+			}
+		}()
+		c.init.apply = true
+	}
+	c.skipScripts = c.init.skipScripts
+
 	vcs, err := c.getVCS()
 	if err != nil {
 		return err
@@ -71,7 +114,13 @@ func (c *Config) runInitCmd(cmd *cobra.Command, args []string) error {
 			return err
 		}
 	case 1: // clone
-		cloneArgs := vcs.CloneArgs(args[0], rawSourceDir)
+		var cloneArgs []string
+		if c.init.oneShot {
+			cloneArgs = vcs.ShallowCloneArgs(args[0], rawSourceDir)
+		}
+		if cloneArgs == nil {
+			cloneArgs = vcs.CloneArgs(args[0], rawSourceDir)
+		}
 		if cloneArgs == nil {
 			return fmt.Errorf("%s: cloning not supported", c.SourceVCS.Command)
 		}
@@ -98,10 +147,16 @@ func (c *Config) runInitCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	if c.init.apply {
-		persistentState, err := c.getPersistentState(nil)
-		if err != nil {
-			return err
+		var persistentState chezmoi.PersistentState = chezmoi.NullPersistentState{}
+		if !c.init.skipPersistentState {
+			persistentState, err = c.getPersistentState(nil)
+			if err != nil {
+				return err
+			}
+		} else {
+			c.persistentState = persistentState
 		}
+		defer c.closePersistentState()
 		if err := c.applyArgs(nil, persistentState); err != nil {
 			return err
 		}
@@ -110,6 +165,33 @@ func (c *Config) runInitCmd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// useOneShotDirs creates a temporary directory and configures c to use it for
+// the source directory, config file, and persistent state. It returns the
+// temporary directory, which the caller should remove when done.
+func (c *Config) useOneShotDirs() (string, error) {
+	tempDirParent := os.TempDir()
+	if err := vfs.MkdirAll(c.fs, tempDirParent, 0o777); err != nil {
+		return "", err
+	}
+	rawTempDirParent, err := c.fs.RawPath(tempDirParent)
+	if err != nil {
+		return "", err
+	}
+	rawTempDir, err := ioutil.TempDir(rawTempDirParent, "chezmoi-one-shot-")
+	if err != nil {
+		return "", err
+	}
+	tempDir := filepath.Join(tempDirParent, filepath.Base(rawTempDir))
+
+	bds := *c.bds
+	bds.ConfigHome = filepath.Join(tempDir, "config")
+	bds.ConfigDirs = []string{bds.ConfigHome}
+	c.bds = &bds
+	c.configFile = filepath.Join(bds.ConfigHome, "chezmoi", "chezmoi.toml")
+	c.SourceDir = filepath.Join(tempDir, "source")
+	return tempDir, nil
+}
+
 func (c *Config) createConfigFile() error {
 	filename, ext, data, err := c.findConfigTemplate()
 	if err != nil {
@@ -121,27 +203,37 @@ func (c *Config) createConfigFile() error {
 		return nil
 	}
 
-	funcMap := make(template.FuncMap)
-	for key, value := range c.templateFuncs {
-		funcMap[key] = value
-	}
-	funcMap["promptString"] = c.promptString
-	t, err := template.New(filename).Funcs(funcMap).Parse(data)
+	defaultData, err := c.getDefaultData()
 	if err != nil {
 		return err
 	}
 
-	defaultData, err := c.getDefaultData()
-	if err != nil {
+	if err := c.readPromptFile(); err != nil {
 		return err
 	}
 
-	contents := &bytes.Buffer{}
-	if err = t.Execute(contents, map[string]interface{}{
-		"chezmoi": defaultData,
-	}); err != nil {
+	// Make any existing data available so that it can be used as defaults
+	// when re-initializing.
+	templateData := make(map[string]interface{})
+	for key, value := range c.Data {
+		templateData[key] = value
+	}
+	templateData["chezmoi"] = defaultData
+
+	ts := chezmoi.NewTargetState(
+		chezmoi.WithSourceDir(c.SourceDir),
+		chezmoi.WithTemplateData(templateData),
+		chezmoi.WithTemplateFuncs(c.getConfigTemplateFuncs()),
+		chezmoi.WithTemplateOptions(c.Template.Options),
+	)
+	if err := ts.LoadTemplates(c.fs); err != nil {
 		return err
 	}
+	contentsData, err := ts.ExecuteTemplateData(filename, []byte(data))
+	if err != nil {
+		return err
+	}
+	contents := bytes.NewBuffer(contentsData)
 
 	configDir := filepath.Join(c.bds.ConfigHome, "chezmoi")
 	if err := vfs.MkdirAll(c.mutator, configDir, 0o777&^os.FileMode(c.Umask)); err != nil {
@@ -157,7 +249,27 @@ func (c *Config) createConfigFile() error {
 	if err := viper.ReadConfig(contents); err != nil {
 		return err
 	}
-	return viper.Unmarshal(c)
+	if err := viper.Unmarshal(c); err != nil {
+		return err
+	}
+	if err := checkCommandNames(ext, contentsData); err != nil {
+		return fmt.Errorf("%s: %w", configPath, err)
+	}
+	return c.addTemplateCommandFuncs()
+}
+
+// getConfigTemplateFuncs returns the functions available in the config file
+// template.
+func (c *Config) getConfigTemplateFuncs() template.FuncMap {
+	funcMap := make(template.FuncMap)
+	for key, value := range c.templateFuncs {
+		funcMap[key] = value
+	}
+	funcMap["promptBool"] = c.promptBool
+	funcMap["promptChoice"] = c.promptChoice
+	funcMap["promptInt"] = c.promptInt
+	funcMap["promptString"] = c.promptString
+	return funcMap
 }
 
 func (c *Config) findConfigTemplate() (string, string, string, error) {
@@ -174,9 +286,148 @@ func (c *Config) findConfigTemplate() (string, string, string, error) {
 	return "", "", "", nil
 }
 
-func (c *Config) promptString(field string) string {
-	fmt.Fprintf(c.Stdout, "%s? ", field)
-	value, err := bufio.NewReader(c.Stdin).ReadString('\n')
-	panicOnError(err)
-	return strings.TrimSpace(value)
+func (c *Config) promptBool(prompt string, args ...interface{}) bool {
+	return c.promptValue("promptBool", c.init.promptBool, prompt, "", args, func(s string) (interface{}, error) {
+		return parseBool(s)
+	}).(bool)
+}
+
+func (c *Config) promptChoice(prompt string, choices interface{}, args ...interface{}) string {
+	choiceStrs, err := toStrings(choices)
+	if err != nil {
+		panic(fmt.Errorf("promptChoice: %s: %w", prompt, err))
+	}
+	return c.promptValue("promptChoice", c.init.promptChoice, prompt, " ("+strings.Join(choiceStrs, "/")+")", args, func(s string) (interface{}, error) {
+		for _, choice := range choiceStrs {
+			if s == choice {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("%s: invalid choice", s)
+	}).(string)
+}
+
+func (c *Config) promptInt(prompt string, args ...interface{}) int {
+	return c.promptValue("promptInt", c.init.promptInt, prompt, "", args, func(s string) (interface{}, error) {
+		return strconv.Atoi(s)
+	}).(int)
+}
+
+func (c *Config) promptString(prompt string, args ...interface{}) string {
+	return c.promptValue("promptString", c.init.promptString, prompt, "", args, func(s string) (interface{}, error) {
+		return s, nil
+	}).(string)
+}
+
+// promptValue returns the answer to prompt. If prompt is answered in answers
+// or in the prompt file then that answer is used, otherwise the user is
+// prompted on stdin. args is an optional default value, which is used if the
+// user enters an empty line or if stdin is exhausted. parse converts and
+// validates answers. If the user's answer is invalid then they are prompted
+// again.
+func (c *Config) promptValue(funcName string, answers map[string]string, prompt, suffix string, args []interface{}, parse func(string) (interface{}, error)) interface{} {
+	if len(args) > 1 {
+		panic(fmt.Errorf("%s: %s: expected at most one default, got %d", funcName, prompt, len(args)))
+	}
+	answer, ok := answers[prompt]
+	if !ok {
+		var answerValue interface{}
+		if answerValue, ok = c.init.promptAnswers[prompt]; ok {
+			answer = fmt.Sprint(answerValue)
+		}
+	}
+	if ok {
+		value, err := parse(answer)
+		if err != nil {
+			panic(fmt.Errorf("%s: %s: %w", funcName, prompt, err))
+		}
+		return value
+	}
+
+	defaultValue, hasDefault := "", false
+	if len(args) == 1 && args[0] != nil && args[0] != "" {
+		defaultValue, hasDefault = fmt.Sprint(args[0]), true
+		if _, err := parse(defaultValue); err != nil {
+			panic(fmt.Errorf("%s: %s: default: %w", funcName, prompt, err))
+		}
+		suffix += " [" + defaultValue + "]"
+	}
+	for {
+		fmt.Fprintf(c.Stdout, "%s%s? ", prompt, suffix)
+		line, err := c.readLine()
+		switch {
+		case errors.Is(err, io.EOF) && hasDefault:
+			line = defaultValue
+		case err != nil:
+			panic(fmt.Errorf("%s: %s: %w", funcName, prompt, err))
+		case line == "" && hasDefault:
+			line = defaultValue
+		}
+		value, err := parse(line)
+		if err == nil {
+			return value
+		}
+		fmt.Fprintf(c.Stdout, "%v\n", err)
+	}
+}
+
+// readPromptFile reads answers to prompts from c.init.promptFile, if set.
+func (c *Config) readPromptFile() error {
+	if c.init.promptFile == "" {
+		return nil
+	}
+	data, err := c.fs.ReadFile(c.init.promptFile)
+	if err != nil {
+		return err
+	}
+	var answers map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(c.init.promptFile)); ext {
+	case ".json":
+		err = json.Unmarshal(data, &answers)
+	case ".toml":
+		var tree *toml.Tree
+		if tree, err = toml.LoadBytes(data); err == nil {
+			answers = tree.ToMap()
+		}
+	case ".yaml", ".yml":
+		var yamlAnswers map[string]interface{}
+		err = yaml.Unmarshal(data, &yamlAnswers)
+		answers = yamlAnswers
+	default:
+		return fmt.Errorf("%s: unknown format", c.init.promptFile)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.init.promptFile, err)
+	}
+	c.init.promptAnswers = answers
+	return nil
+}
+
+// parseBool is like strconv.ParseBool but also accepts y, yes, n, no, on, and
+// off, ignoring case.
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "y", "yes", "on":
+		return true, nil
+	case "n", "no", "off":
+		return false, nil
+	default:
+		return strconv.ParseBool(strings.ToLower(s))
+	}
+}
+
+// toStrings converts value, which must be a slice, to a []string.
+func toStrings(value interface{}) ([]string, error) {
+	switch value := value.(type) {
+	case []string:
+		return value, nil
+	case []interface{}:
+		strs := make([]string, 0, len(value))
+		for _, v := range value {
+			strs = append(strs, fmt.Sprint(v))
+		}
+		return strs, nil
+	default:
+		return nil, fmt.Errorf("%v: not a list", value)
+	}
 }
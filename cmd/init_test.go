@@ -89,6 +89,273 @@ func TestCreateConfigFile(t *testing.T) {
 	}, c.Data)
 }
 
+func TestCreateConfigFileWithTemplates(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			".chezmoitemplates/email": `{{ promptString "email" | trim }}`,
+			".chezmoi.toml.tmpl": strings.Join([]string{
+				`[data]`,
+				`  email = {{ includeTemplate "email" | quote }}`,
+			}, "\n"),
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(
+		fs,
+		withStdin(bytes.NewBufferString("john.smith@company.com\n")),
+	)
+
+	require.NoError(t, c.createConfigFile())
+
+	assert.Equal(t, map[string]interface{}{
+		"email": "john.smith@company.com",
+	}, c.Data)
+}
+
+func TestCreateConfigFilePrompts(t *testing.T) {
+	configTemplate := strings.Join([]string{
+		`[data]`,
+		`  email = {{ promptString "email" (index . "email") | quote }}`,
+		`  work = {{ promptBool "work" false }}`,
+		`  monitors = {{ promptInt "monitors" 1 }}`,
+		`  shell = {{ promptChoice "shell" (list "bash" "fish" "zsh") "bash" | quote }}`,
+	}, "\n")
+
+	for _, tc := range []struct {
+		name         string
+		root         interface{}
+		options      []configOption
+		expectedData map[string]interface{}
+	}{
+		{
+			name: "stdin",
+			options: []configOption{
+				withStdin(bytes.NewBufferString("john.smith@company.com\nmaybe\nyes\n2\nksh\nzsh\n")),
+			},
+			expectedData: map[string]interface{}{
+				"email":    "john.smith@company.com",
+				"work":     true,
+				"monitors": int64(2),
+				"shell":    "zsh",
+			},
+		},
+		{
+			name: "defaults",
+			options: []configOption{
+				withStdin(bytes.NewBufferString("john.smith@company.com\n\n")),
+			},
+			expectedData: map[string]interface{}{
+				"email":    "john.smith@company.com",
+				"work":     false,
+				"monitors": int64(1),
+				"shell":    "bash",
+			},
+		},
+		{
+			name: "existing_data",
+			options: []configOption{
+				withData(map[string]interface{}{
+					"email": "john.smith@company.com",
+				}),
+				withStdin(&bytes.Buffer{}),
+			},
+			expectedData: map[string]interface{}{
+				"email":    "john.smith@company.com",
+				"work":     false,
+				"monitors": int64(1),
+				"shell":    "bash",
+			},
+		},
+		{
+			name: "flags",
+			options: []configOption{
+				withInitCmdConfig(initCmdConfig{
+					promptBool: map[string]string{
+						"work": "on",
+					},
+					promptChoice: map[string]string{
+						"shell": "fish",
+					},
+					promptInt: map[string]string{
+						"monitors": "3",
+					},
+					promptString: map[string]string{
+						"email": "john.smith@company.com",
+					},
+				}),
+				withStdin(&bytes.Buffer{}),
+			},
+			expectedData: map[string]interface{}{
+				"email":    "john.smith@company.com",
+				"work":     true,
+				"monitors": int64(3),
+				"shell":    "fish",
+			},
+		},
+		{
+			name: "prompt_file",
+			root: map[string]interface{}{
+				"/home/user/answers.yaml": strings.Join([]string{
+					`email: john.smith@company.com`,
+					`work: true`,
+					`monitors: 2`,
+				}, "\n"),
+			},
+			options: []configOption{
+				withInitCmdConfig(initCmdConfig{
+					promptChoice: map[string]string{
+						"shell": "zsh",
+					},
+					promptFile: "/home/user/answers.yaml",
+				}),
+				withStdin(&bytes.Buffer{}),
+			},
+			expectedData: map[string]interface{}{
+				"email":    "john.smith@company.com",
+				"work":     true,
+				"monitors": int64(2),
+				"shell":    "zsh",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoi.toml.tmpl": configTemplate,
+			})
+			require.NoError(t, err)
+			defer cleanup()
+			if tc.root != nil {
+				require.NoError(t, vfst.NewBuilder().Build(fs, tc.root))
+			}
+
+			c := newTestConfig(fs, append(tc.options, withStdout(&bytes.Buffer{}))...)
+			require.NoError(t, c.createConfigFile())
+			assert.Equal(t, tc.expectedData, c.Data)
+		})
+	}
+}
+
+func TestCreateConfigFilePromptDefaults(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		configFile    string
+		options       []configOption
+		expectedData  map[string]interface{}
+		expectedError bool
+	}{
+		{
+			name: "has_key_first_init",
+			configFile: strings.Join([]string{
+				`{{ $email := "" -}}`,
+				`{{ if hasKey . "email" -}}`,
+				`{{   $email = .email -}}`,
+				`{{ end -}}`,
+				`[data]`,
+				`  email = {{ promptString "email" $email | quote }}`,
+			}, "\n"),
+			options: []configOption{
+				withStdin(bytes.NewBufferString("john.smith@company.com\n")),
+			},
+			expectedData: map[string]interface{}{
+				"email": "john.smith@company.com",
+			},
+		},
+		{
+			name: "has_key_reinit",
+			configFile: strings.Join([]string{
+				`{{ $email := "" -}}`,
+				`{{ if hasKey . "email" -}}`,
+				`{{   $email = .email -}}`,
+				`{{ end -}}`,
+				`[data]`,
+				`  email = {{ promptString "email" $email | quote }}`,
+			}, "\n"),
+			options: []configOption{
+				withData(map[string]interface{}{
+					"email": "john.smith@company.com",
+				}),
+				withStdin(bytes.NewBufferString("\n")),
+			},
+			expectedData: map[string]interface{}{
+				"email": "john.smith@company.com",
+			},
+		},
+		{
+			name: "index_first_init",
+			configFile: strings.Join([]string{
+				`[data]`,
+				`  email = {{ promptString "email" (index . "email") | quote }}`,
+			}, "\n"),
+			options: []configOption{
+				withStdin(bytes.NewBufferString("john.smith@company.com\n")),
+			},
+			expectedData: map[string]interface{}{
+				"email": "john.smith@company.com",
+			},
+		},
+		{
+			name: "missing_key_first_init",
+			configFile: strings.Join([]string{
+				`[data]`,
+				`  email = {{ promptString "email" .email | quote }}`,
+			}, "\n"),
+			options: []configOption{
+				withStdin(bytes.NewBufferString("john.smith@company.com\n")),
+			},
+			expectedError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoi.toml.tmpl": tc.configFile,
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			c := newTestConfig(fs, append(tc.options, withStdout(&bytes.Buffer{}))...)
+			if tc.expectedError {
+				assert.Error(t, c.createConfigFile())
+				return
+			}
+			require.NoError(t, c.createConfigFile())
+			assert.Equal(t, tc.expectedData, c.Data)
+		})
+	}
+}
+
+func TestPromptErrors(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0o755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(
+		fs,
+		withInitCmdConfig(initCmdConfig{
+			promptBool: map[string]string{
+				"invalid": "maybe",
+			},
+		}),
+		withStdin(&bytes.Buffer{}),
+		withStdout(&bytes.Buffer{}),
+	)
+	assert.Panics(t, func() {
+		c.promptBool("invalid")
+	})
+	assert.Panics(t, func() {
+		c.promptString("no default")
+	})
+	assert.Panics(t, func() {
+		c.promptChoice("invalid default", []string{"a", "b"}, "c")
+	})
+	assert.Panics(t, func() {
+		c.promptString("empty default", "")
+	})
+}
+
 func TestInit(t *testing.T) {
 	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
 		"/home/user": &vfst.Dir{Perm: 0o755},
@@ -142,3 +409,40 @@ func TestInitRepo(t *testing.T) {
 		),
 	)
 }
+
+func TestInitOneShot(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0o755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(
+		fs,
+		withInitCmdConfig(initCmdConfig{
+			oneShot:             true,
+			skipPersistentState: true,
+			skipScripts:         true,
+		}),
+	)
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, c.runInitCmd(nil, []string{filepath.Join(wd, "testdata/gitrepo")}))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString(lines("# contents of .bashrc\n")),
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.config/chezmoi",
+			vfst.TestDoesNotExist,
+		),
+	)
+	infos, err := fs.ReadDir(os.TempDir())
+	require.NoError(t, err)
+	for _, info := range infos {
+		assert.False(t, strings.HasPrefix(info.Name(), "chezmoi-one-shot-"))
+	}
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A jsonPathSegment is a single step in a JSONPath-style query. Exactly one
+// of key, index, or wildcard is used.
+type jsonPathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// jsonPathFunc evaluates the JSONPath-style query path against data, for
+// example "$.items[0].name" or ".items[*].name". If path contains a wildcard
+// then a list of all matching values is returned, otherwise the single
+// matching value is returned, or nil if there is no match.
+func jsonPathFunc(path string, data interface{}) interface{} {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		panic(fmt.Errorf("jsonPath: %s: %w", path, err))
+	}
+	values := []interface{}{data}
+	wildcard := false
+	for _, segment := range segments {
+		if segment.wildcard {
+			wildcard = true
+		}
+		var nextValues []interface{}
+		for _, value := range values {
+			nextValues = append(nextValues, segment.apply(value)...)
+		}
+		values = nextValues
+	}
+	switch {
+	case wildcard:
+		if values == nil {
+			return []interface{}{}
+		}
+		return values
+	case len(values) == 0:
+		return nil
+	default:
+		return values[0]
+	}
+}
+
+// apply returns the values selected by s from value.
+func (s jsonPathSegment) apply(value interface{}) []interface{} {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		switch {
+		case s.wildcard:
+			keys := v.MapKeys()
+			sort.Slice(keys, func(i, j int) bool {
+				return keys[i].String() < keys[j].String()
+			})
+			values := make([]interface{}, 0, len(keys))
+			for _, key := range keys {
+				values = append(values, v.MapIndex(key).Interface())
+			}
+			return values
+		case s.isIndex:
+			return nil
+		default:
+			elem := v.MapIndex(reflect.ValueOf(s.key).Convert(v.Type().Key()))
+			if !elem.IsValid() {
+				return nil
+			}
+			return []interface{}{elem.Interface()}
+		}
+	case reflect.Array, reflect.Slice:
+		switch {
+		case s.wildcard:
+			values := make([]interface{}, 0, v.Len())
+			for i := 0; i < v.Len(); i++ {
+				values = append(values, v.Index(i).Interface())
+			}
+			return values
+		default:
+			index := s.index
+			if !s.isIndex {
+				var err error
+				if index, err = strconv.Atoi(s.key); err != nil {
+					return nil
+				}
+			}
+			if index < 0 {
+				index += v.Len()
+			}
+			if index < 0 || index >= v.Len() {
+				return nil
+			}
+			return []interface{}{v.Index(index).Interface()}
+		}
+	default:
+		return nil
+	}
+}
+
+// parseJSONPath parses path into segments.
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	var segments []jsonPathSegment
+	s := strings.TrimPrefix(path, "$")
+	if s != "" && s[0] != '.' && s[0] != '[' {
+		s = "." + s
+	}
+	for s != "" {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[")
+			if end == -1 {
+				end = len(s)
+			}
+			name := s[:end]
+			s = s[end:]
+			switch name {
+			case "":
+				if s == "" && len(segments) == 0 {
+					// A path of "." or "$." selects the root.
+					return segments, nil
+				}
+				return nil, errors.New("empty key")
+			case "*":
+				segments = append(segments, jsonPathSegment{wildcard: true})
+			default:
+				segments = append(segments, jsonPathSegment{key: name})
+			}
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end == -1 {
+				return nil, errors.New("missing ]")
+			}
+			subscript := strings.TrimSpace(s[1:end])
+			s = s[end+1:]
+			switch {
+			case subscript == "*":
+				segments = append(segments, jsonPathSegment{wildcard: true})
+			case len(subscript) >= 2 && (subscript[0] == '"' || subscript[0] == '\''):
+				quote := subscript[0]
+				if subscript[len(subscript)-1] != quote {
+					return nil, fmt.Errorf("%s: unterminated string", subscript)
+				}
+				key := subscript[1 : len(subscript)-1]
+				if quote == '"' {
+					var err error
+					key, err = strconv.Unquote(subscript)
+					if err != nil {
+						return nil, fmt.Errorf("%s: %w", subscript, err)
+					}
+				}
+				segments = append(segments, jsonPathSegment{key: key})
+			default:
+				index, err := strconv.Atoi(subscript)
+				if err != nil {
+					return nil, fmt.Errorf("%s: invalid subscript", subscript)
+				}
+				segments = append(segments, jsonPathSegment{index: index, isIndex: true})
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q", s[0])
+		}
+	}
+	return segments, nil
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONPathFunc(t *testing.T) {
+	data := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{
+				"name": "foo",
+				"tags": map[string]string{
+					"env": "prod",
+				},
+			},
+			map[string]interface{}{
+				"name": "bar",
+			},
+		},
+		"key.with.dots": "value",
+	}
+	for _, tc := range []struct {
+		path string
+		want interface{}
+	}{
+		{path: ".", want: data},
+		{path: "$", want: data},
+		{path: "$.items[0].name", want: "foo"},
+		{path: ".items[1].name", want: "bar"},
+		{path: "items.1.name", want: "bar"},
+		{path: ".items[-1].name", want: "bar"},
+		{path: ".items[0].tags.env", want: "prod"},
+		{path: ".items[*].name", want: []interface{}{"foo", "bar"}},
+		{path: `$["key.with.dots"]`, want: "value"},
+		{path: `$['key.with.dots']`, want: "value"},
+		{path: ".missing", want: nil},
+		{path: ".items[2].name", want: nil},
+		{path: ".missing[*]", want: []interface{}{}},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.want, jsonPathFunc(tc.path, data))
+		})
+	}
+}
+
+func TestJSONPathFuncErrors(t *testing.T) {
+	for _, path := range []string{
+		".items[0",
+		".items[foo]",
+		".items..name",
+		`$["unterminated]`,
+	} {
+		t.Run(path, func(t *testing.T) {
+			assert.Panics(t, func() {
+				jsonPathFunc(path, nil)
+			})
+		})
+	}
+}
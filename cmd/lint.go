@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+type lintCmdConfig struct {
+	format string
+}
+
+var lintCmd = &cobra.Command{
+	Use:     "lint",
+	Aliases: []string{"check"},
+	Args:    cobra.NoArgs,
+	Short:   "Check the source state for problems without applying it",
+	Long:    mustGetLongHelp("lint"),
+	Example: getExample("lint"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runLintCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+
+	persistentFlags := lintCmd.PersistentFlags()
+	persistentFlags.StringVarP(&config.lint.format, "format", "f", "text", "format (text, JSON, or YAML)")
+}
+
+func (c *Config) runLintCmd(cmd *cobra.Command, args []string) error {
+	format := strings.ToLower(c.lint.format)
+	if format != "text" && format != "json" && format != "yaml" {
+		return fmt.Errorf("%s: unknown format", c.lint.format)
+	}
+
+	ts, err := c.newTargetState()
+	if err != nil {
+		return err
+	}
+	problems, err := ts.Lint(vfs.NewReadOnlyFS(c.fs), &chezmoi.LintOptions{
+		ConfigTemplateFuncs: c.getConfigTemplateFuncs(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if format == "text" {
+		for _, problem := range problems {
+			if problem.SourceName == "" {
+				fmt.Fprintf(c.Stdout, "%s: %s\n", problem.Type, problem.Message)
+			} else {
+				fmt.Fprintf(c.Stdout, "%s: %s: %s\n", problem.SourceName, problem.Type, problem.Message)
+			}
+		}
+	} else {
+		if problems == nil {
+			problems = []*chezmoi.LintProblem{}
+		}
+		if err := formatMap[format](c.Stdout, problems); err != nil {
+			return err
+		}
+	}
+
+	if len(problems) != 0 {
+		return errExitFailure
+	}
+	return nil
+}
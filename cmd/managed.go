@@ -59,6 +59,9 @@ func (c *Config) runManagedCmd(cmd *cobra.Command, args []string) error {
 
 	targetNames := make([]string, 0, len(allEntries))
 	for _, entry := range allEntries {
+		if _, ok := entry.(*chezmoi.Block); ok && !includeFiles {
+			continue
+		}
 		if _, ok := entry.(*chezmoi.Dir); ok && !includeDirs {
 			continue
 		}
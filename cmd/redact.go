@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"reflect"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+// getRedactor returns the Redactor used to redact secrets from output, or nil
+// if secrets should be shown.
+func (c *Config) getRedactor() *chezmoi.Redactor {
+	if c.showSecrets {
+		return nil
+	}
+	return c.redactor
+}
+
+// recordSecretsFunc returns a function with the same signature as f that
+// records its first result as a secret. String arguments, like the names of
+// items, are not recorded.
+func (c *Config) recordSecretsFunc(f interface{}) interface{} {
+	fValue := reflect.ValueOf(f)
+	if fValue.Kind() != reflect.Func {
+		return f
+	}
+	fType := fValue.Type()
+	return reflect.MakeFunc(fType, func(args []reflect.Value) []reflect.Value {
+		var results []reflect.Value
+		if fType.IsVariadic() {
+			results = fValue.CallSlice(args)
+		} else {
+			results = fValue.Call(args)
+		}
+		if len(results) > 0 {
+			var argStrs []string
+			for i, arg := range args {
+				switch {
+				case fType.IsVariadic() && i == len(args)-1:
+					for j := 0; j < arg.Len(); j++ {
+						if s, ok := arg.Index(j).Interface().(string); ok {
+							argStrs = append(argStrs, s)
+						}
+					}
+				case arg.Kind() == reflect.String:
+					argStrs = append(argStrs, arg.String())
+				}
+			}
+			c.redactor.Add(results[0].Interface(), argStrs...)
+		}
+		return results
+	}).Interface()
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestRedactSecrets(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		showSecrets bool
+		run         func(*Config) error
+	}{
+		{
+			name: "diff_chezmoi",
+			run: func(c *Config) error {
+				c.Diff.Format = "chezmoi"
+				return c.runDiffCmd(&cobra.Command{}, nil)
+			},
+		},
+		{
+			name: "diff_git",
+			run: func(c *Config) error {
+				c.Diff.Format = "git"
+				return c.runDiffCmd(&cobra.Command{}, nil)
+			},
+		},
+		{
+			name: "dump",
+			run: func(c *Config) error {
+				c.dump.format = "json"
+				return c.runDumpCmd(&cobra.Command{}, nil)
+			},
+		},
+		{
+			name: "execute_template",
+			run: func(c *Config) error {
+				return c.runExecuteTemplateCmd(&cobra.Command{}, []string{"{{ testSecret \"key\" }}"})
+			},
+		},
+		{
+			name:        "execute_template_show_secrets",
+			showSecrets: true,
+			run: func(c *Config) error {
+				return c.runExecuteTemplateCmd(&cobra.Command{}, []string{"{{ testSecret \"key\" }}"})
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".netrc": "machine example.org login user password old-secret\n",
+					".local/share/chezmoi": map[string]interface{}{
+						"dot_netrc.tmpl": "machine example.com login user password {{ testSecret \"key\" }}\n",
+					},
+				},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			stdout := &strings.Builder{}
+			c := newTestConfig(
+				fs,
+				withStdout(stdout),
+			)
+			c.showSecrets = tc.showSecrets
+			c.addSecretTemplateFunc("testSecret", func(string) string {
+				return "hunter2-secret"
+			})
+			c.redactor.Add("old-secret")
+
+			require.NoError(t, tc.run(c))
+			if tc.showSecrets {
+				assert.Contains(t, stdout.String(), "hunter2-secret")
+				return
+			}
+			assert.Contains(t, stdout.String(), "redacted")
+			assert.NotContains(t, stdout.String(), "hunter2-secret")
+			assert.NotContains(t, stdout.String(), "old-secret")
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var rekeyCmd = &cobra.Command{
+	Use:      "rekey [targets...]",
+	Short:    "Re-encrypt encrypted files in the source state for the current recipients",
+	Long:     mustGetLongHelp("rekey"),
+	Example:  getExample("rekey"),
+	PreRunE:  config.ensureNoError,
+	RunE:     config.runRekeyCmd,
+	PostRunE: config.autoCommitAndAutoPush,
+}
+
+var rekeyStateBucket = []byte("rekey")
+
+// A rekeyState records the recipients that a source file was last re-encrypted
+// for.
+type rekeyState struct {
+	Recipients       []string `json:"recipients"`
+	CiphertextSHA256 []byte   `json:"ciphertextSHA256"`
+}
+
+func init() {
+	rootCmd.AddCommand(rekeyCmd)
+
+	markRemainingZshCompPositionalArgumentsAsFiles(rekeyCmd, 1)
+}
+
+func (c *Config) runRekeyCmd(cmd *cobra.Command, args []string) error {
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+
+	var entries []chezmoi.Entry
+	if len(args) == 0 {
+		entries = ts.AllEntries()
+	} else {
+		argEntries, err := c.getEntries(ts, args)
+		if err != nil {
+			return err
+		}
+		for _, entry := range argEntries {
+			entries = entry.AppendAllEntries(entries)
+		}
+	}
+
+	recipientNames, err := ts.Encryption.RecipientNames()
+	if err != nil {
+		return err
+	}
+
+	// Entries in an encrypted archive share a single source file, so only
+	// re-encrypt each source file once.
+	rekeyed := make(map[string]bool)
+	for _, entry := range entries {
+		if file, ok := entry.(*chezmoi.File); !ok || !file.Encrypted {
+			if !chezmoi.InArchive(entry) {
+				continue
+			}
+		}
+		sourcePath := filepath.Join(ts.SourceDir, entry.SourceName())
+		if rekeyed[sourcePath] {
+			continue
+		}
+		rekeyed[sourcePath] = true
+		info, err := c.fs.Stat(sourcePath)
+		if err != nil {
+			return err
+		}
+		oldCiphertext, err := c.fs.ReadFile(sourcePath)
+		if err != nil {
+			return err
+		}
+		key := []byte(entry.SourceName())
+		upToDate, err := c.rekeyUpToDate(key, recipientNames, oldCiphertext)
+		if err != nil {
+			return err
+		}
+		if upToDate {
+			continue
+		}
+		plaintext, err := ts.Encryption.Decrypt(sourcePath, oldCiphertext)
+		if err != nil {
+			return err
+		}
+		newCiphertext, err := ts.Encryption.Encrypt(sourcePath, plaintext)
+		if err != nil {
+			return err
+		}
+		if err := c.mutator.WriteFile(sourcePath, newCiphertext, info.Mode().Perm(), oldCiphertext); err != nil {
+			return err
+		}
+		if err := c.setRekeyState(key, recipientNames, newCiphertext); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rekeyUpToDate returns true if ciphertext was written by a previous rekey for
+// recipientNames, in which case it does not need to be re-encrypted.
+func (c *Config) rekeyUpToDate(key []byte, recipientNames []string, ciphertext []byte) (bool, error) {
+	var upToDate bool
+	if err := c.withPersistentState(func(persistentState chezmoi.PersistentState, readOnly bool) error {
+		data, err := persistentState.Get(rekeyStateBucket, key)
+		if err != nil || data == nil {
+			return err
+		}
+		var state rekeyState
+		if err := json.Unmarshal(data, &state); err != nil {
+			// Ignore invalid state.
+			return nil //nolint:nilerr
+		}
+		ciphertextSHA256 := sha256.Sum256(ciphertext)
+		upToDate = bytes.Equal(state.CiphertextSHA256, ciphertextSHA256[:]) &&
+			strings.Join(state.Recipients, "\x00") == strings.Join(recipientNames, "\x00")
+		return nil
+	}); err != nil {
+		return false, err
+	}
+	return upToDate, nil
+}
+
+// setRekeyState records that ciphertext was encrypted for recipientNames.
+func (c *Config) setRekeyState(key []byte, recipientNames []string, ciphertext []byte) error {
+	ciphertextSHA256 := sha256.Sum256(ciphertext)
+	data, err := json.Marshal(&rekeyState{
+		Recipients:       recipientNames,
+		CiphertextSHA256: ciphertextSHA256[:],
+	})
+	if err != nil {
+		return err
+	}
+	return c.withPersistentState(func(persistentState chezmoi.PersistentState, readOnly bool) error {
+		if readOnly {
+			return nil
+		}
+		return persistentState.Set(rekeyStateBucket, key, data)
+	})
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"testing"
+
+	"filippo.io/age"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestRekeyCmd(t *testing.T) {
+	identity1, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	identity2, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name                string
+		mutator             chezmoi.Mutator
+		expectedDecryptable bool
+	}{
+		{
+			name:                "rekey",
+			expectedDecryptable: true,
+		},
+		{
+			name:                "dry_run",
+			mutator:             chezmoi.NullMutator{},
+			expectedDecryptable: false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".config/chezmoi/key1.txt": identity1.String() + "\n",
+					".config/chezmoi/key2.txt": identity2.String() + "\n",
+					".local/share/chezmoi":     &vfst.Dir{Perm: 0o700},
+				},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			oldEncryption := &chezmoi.AgeEncryption{
+				FS:       fs,
+				Identity: "/home/user/.config/chezmoi/key1.txt",
+			}
+			ciphertext, err := oldEncryption.Encrypt("dot_netrc", []byte("secret\n"))
+			require.NoError(t, err)
+			require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/encrypted_private_dot_netrc", ciphertext, 0o600))
+			require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/dot_bashrc", []byte("# bashrc\n"), 0o644))
+
+			options := []configOption{}
+			if tc.mutator != nil {
+				options = append(options, withMutator(tc.mutator))
+			}
+			c := newTestConfig(fs, options...)
+			c.Encryption = "age"
+			c.Age.Identity = "/home/user/.config/chezmoi/key1.txt"
+			c.Age.Recipients = []string{
+				identity1.Recipient().String(),
+				identity2.Recipient().String(),
+			}
+			require.NoError(t, c.runRekeyCmd(&cobra.Command{}, nil))
+
+			newCiphertext, err := fs.ReadFile("/home/user/.local/share/chezmoi/encrypted_private_dot_netrc")
+			require.NoError(t, err)
+			newEncryption := &chezmoi.AgeEncryption{
+				FS:       fs,
+				Identity: "/home/user/.config/chezmoi/key2.txt",
+			}
+			plaintext, err := newEncryption.Decrypt("dot_netrc", newCiphertext)
+			if tc.expectedDecryptable {
+				require.NoError(t, err)
+				assert.Equal(t, []byte("secret\n"), plaintext)
+			} else {
+				assert.Error(t, err)
+			}
+
+			vfst.RunTests(t, fs, "",
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_bashrc",
+					vfst.TestContentsString("# bashrc\n"),
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/encrypted_private_dot_netrc",
+					vfst.TestModePerm(0o600),
+				),
+			)
+
+			// Rekeying again for the same recipients does not rewrite the file.
+			require.NoError(t, c.runRekeyCmd(&cobra.Command{}, nil))
+			vfst.RunTests(t, fs, "",
+				vfst.TestPath("/home/user/.local/share/chezmoi/encrypted_private_dot_netrc",
+					vfst.TestContents(newCiphertext),
+				),
+			)
+		})
+	}
+}
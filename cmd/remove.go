@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 
 	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 type removeCmdConfig struct {
@@ -40,13 +42,23 @@ func (c *Config) runRemoveCmd(cmd *cobra.Command, args []string) error {
 	}
 	entries, err := c.getEntries(ts, args)
 	if err != nil {
-		return nil
+		return err
+	}
+	for i, entry := range entries {
+		if chezmoi.InArchive(entry) {
+			return fmt.Errorf("%s: in encrypted archive, remove the target and re-add its directory with --archive", args[i])
+		}
 	}
 	for _, entry := range entries {
 		destDirPath := filepath.Join(c.DestDir, entry.TargetName())
 		sourceDirPath := filepath.Join(c.SourceDir, entry.SourceName())
+		block, isBlock := entry.(*chezmoi.Block)
 		if !c.remove.force {
-			choice, err := c.prompt(fmt.Sprintf("Remove %s and %s", destDirPath, sourceDirPath), "ynqa")
+			prompt := fmt.Sprintf("Remove %s and %s", destDirPath, sourceDirPath)
+			if isBlock {
+				prompt = fmt.Sprintf("Remove managed block from %s and %s", destDirPath, sourceDirPath)
+			}
+			choice, err := c.prompt(prompt, "ynqa")
 			if err != nil {
 				return err
 			}
@@ -60,7 +72,11 @@ func (c *Config) runRemoveCmd(cmd *cobra.Command, args []string) error {
 				c.remove.force = true
 			}
 		}
-		if err := c.mutator.RemoveAll(destDirPath); err != nil && !os.IsNotExist(err) {
+		if isBlock {
+			if err := block.Remove(c.fs, c.mutator, c.DestDir); err != nil {
+				return err
+			}
+		} else if err := c.mutator.RemoveAll(destDirPath); err != nil && !os.IsNotExist(err) {
 			return err
 		}
 		if err := c.mutator.RemoveAll(sourceDirPath); err != nil && !os.IsNotExist(err) {
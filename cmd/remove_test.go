@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestRemoveCommand(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		args      []string
+		root      interface{}
+		tests     interface{}
+		wantError bool
+	}{
+		{
+			name: "file",
+			args: []string{"/home/user/.bashrc"},
+			root: map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".bashrc":                         "# contents of .bashrc\n",
+					".local/share/chezmoi/dot_bashrc": "# contents of .bashrc\n",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_bashrc",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
+		{
+			name: "block",
+			args: []string{"/home/user/.bashrc"},
+			root: map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".bashrc": &vfst.File{
+						Perm:     0o600,
+						Contents: []byte("# before\n# BEGIN chezmoi managed block\nexport EDITOR=vi\n# END chezmoi managed block\n# after\n"),
+					},
+					".local/share/chezmoi/block_dot_bashrc": "export EDITOR=vi\n",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestModeIsRegular,
+					vfst.TestModePerm(0o600),
+					vfst.TestContentsString("# before\n# after\n"),
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/block_dot_bashrc",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
+		{
+			name: "not_in_source_state",
+			args: []string{"/home/user/.bashrc"},
+			root: map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".bashrc":              "# contents of .bashrc\n",
+					".local/share/chezmoi": &vfst.Dir{Perm: 0o755},
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("# contents of .bashrc\n"),
+				),
+			},
+			wantError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			c := newTestConfig(fs)
+			c.remove.force = true
+			if tc.wantError {
+				assert.Error(t, c.runRemoveCmd(nil, tc.args))
+			} else {
+				assert.NoError(t, c.runRemoveCmd(nil, tc.args))
+			}
+			vfst.RunTests(t, fs, "", tc.tests)
+		})
+	}
+}
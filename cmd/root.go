@@ -83,6 +83,8 @@ func init() {
 	persistentFlags.BoolVar(&config.Debug, "debug", false, "write debug logs")
 	panicOnError(viper.BindPFlag("debug", persistentFlags.Lookup("debug")))
 
+	persistentFlags.BoolVar(&config.showSecrets, "show-secrets", false, "show secrets in diffs and other output")
+
 	cobra.OnInitialize(func() {
 		_, err := os.Stat(config.configFile)
 		switch {
@@ -95,6 +97,12 @@ func init() {
 			if config.err == nil {
 				config.err = config.validateData()
 			}
+			if config.err == nil {
+				config.err = config.checkConfigFileCommandNames()
+			}
+			if config.err == nil {
+				config.err = config.addTemplateCommandFuncs()
+			}
 			if config.err != nil {
 				rootCmd.Printf("warning: %s: %v\n", config.configFile, config.err)
 			}
@@ -183,10 +191,10 @@ func (c *Config) persistentPreRunRootE(cmd *cobra.Command, args []string) error
 		c.mutator = chezmoi.NullMutator{}
 	}
 	if c.Debug {
-		c.mutator = chezmoi.NewDebugMutator(c.mutator)
+		c.mutator = chezmoi.NewDebugMutator(c.mutator, c.getRedactor())
 	}
 	if c.Verbose {
-		c.mutator = chezmoi.NewVerboseMutator(c.Stdout, c.mutator, c.colored, c.maxDiffDataSize)
+		c.mutator = chezmoi.NewVerboseMutator(c.Stdout, c.mutator, c.colored, c.maxDiffDataSize, c.getRedactor())
 	}
 
 	info, err := c.fs.Stat(c.SourceDir)
@@ -6,6 +6,8 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -20,14 +22,30 @@ var bitwardenCmd = &cobra.Command{
 }
 
 type bitwardenCmdConfig struct {
-	Command string
+	Command    string
+	CacheTTL   time.Duration
+	session    string
+	unlockOnce sync.Once
+	unlockErr  error
 }
 
-var bitwardenCache = make(map[string]interface{})
+// Bitwarden CLI statuses, as reported by bw status.
+const (
+	bitwardenStatusLocked          = "locked"
+	bitwardenStatusUnauthenticated = "unauthenticated"
+	bitwardenStatusUnlocked        = "unlocked"
+)
+
+var (
+	bitwardenAttachmentCache = make(map[string]string)
+	bitwardenCache           = make(map[string]interface{})
+)
 
 func init() {
 	config.Bitwarden.Command = "bw"
-	config.addTemplateFunc("bitwarden", config.bitwardenFunc)
+	config.addSecretTemplateFunc("bitwarden", config.bitwardenFunc)
+	config.addSecretTemplateFunc("bitwardenAttachment", config.bitwardenAttachmentFunc)
+	config.addSecretTemplateFunc("bitwardenFields", config.bitwardenFieldsFunc)
 
 	secretCmd.AddCommand(bitwardenCmd)
 }
@@ -41,19 +59,126 @@ func (c *Config) bitwardenFunc(args ...string) interface{} {
 	if data, ok := bitwardenCache[key]; ok {
 		return data
 	}
-	name := c.Bitwarden.Command
 	args = append([]string{"get"}, args...)
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
-	if err != nil {
-		panic(fmt.Errorf("bitwarden: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
-	}
+	output := c.bitwardenOutput(args)
 	var data interface{}
 	if err := json.Unmarshal(output, &data); err != nil {
-		panic(fmt.Errorf("bitwarden: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
+		panic(fmt.Errorf("bitwarden: %s %s: %w\n%s", c.Bitwarden.Command, chezmoi.ShellQuoteArgs(args), err, output))
 	}
 	bitwardenCache[key] = data
 	return data
 }
+
+// bitwardenAttachmentFunc returns the contents of the attachment name of the
+// item with id itemID.
+func (c *Config) bitwardenAttachmentFunc(name, itemID string) string {
+	key := name + "\x00" + itemID
+	if data, ok := bitwardenAttachmentCache[key]; ok {
+		return data
+	}
+	data := string(c.bitwardenOutput([]string{"get", "attachment", name, "--itemid", itemID, "--raw"}))
+	bitwardenAttachmentCache[key] = data
+	return data
+}
+
+// bitwardenFieldsFunc returns the custom fields of the item selected by args
+// keyed by their name.
+func (c *Config) bitwardenFieldsFunc(args ...string) map[string]interface{} {
+	item, _ := c.bitwardenFunc(args...).(map[string]interface{})
+	fields, _ := item["fields"].([]interface{})
+	result := make(map[string]interface{})
+	for _, field := range fields {
+		field, ok := field.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, ok := field["name"].(string); ok && name != "" {
+			result[name] = field
+		}
+	}
+	return result
+}
+
+// bitwardenOutput returns the output of the Bitwarden CLI with args, unlocking
+// the vault first if needed.
+func (c *Config) bitwardenOutput(args []string) []byte {
+	name := c.Bitwarden.Command
+	output, err := c.cachedSecretOutput(c.Bitwarden.CacheTTL, append([]string{name}, args...), func() ([]byte, error) {
+		c.Bitwarden.unlockOnce.Do(func() {
+			c.Bitwarden.unlockErr = c.bitwardenUnlock()
+		})
+		if c.Bitwarden.unlockErr != nil {
+			return nil, c.Bitwarden.unlockErr
+		}
+		cmd := exec.Command(name, args...)
+		cmd.Env = c.bitwardenEnv()
+		cmd.Stdin = os.Stdin
+		cmd.Stderr = os.Stderr
+		return c.mutator.IdempotentCmdOutput(cmd)
+	})
+	if err != nil {
+		panic(fmt.Errorf("bitwarden: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
+	}
+	return output
+}
+
+// bitwardenEnv returns the environment for running the Bitwarden CLI, which
+// includes the session key if the vault was unlocked by chezmoi.
+func (c *Config) bitwardenEnv() []string {
+	if c.Bitwarden.session == "" {
+		return nil
+	}
+	return append(os.Environ(), "BW_SESSION="+c.Bitwarden.session)
+}
+
+// bitwardenStatus returns the status of the Bitwarden CLI.
+func (c *Config) bitwardenStatus() (string, error) {
+	name := c.Bitwarden.Command
+	cmd := exec.Command(name, "status")
+	cmd.Env = c.bitwardenEnv()
+	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	if err != nil {
+		return "", fmt.Errorf("bitwarden: %s status: %w", name, err)
+	}
+	var status struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(output, &status); err != nil {
+		return "", fmt.Errorf("bitwarden: %s status: %w\n%s", name, err, output)
+	}
+	return status.Status, nil
+}
+
+// bitwardenUnlock unlocks the Bitwarden vault if it is locked, prompting the
+// user for their master password. The session key is used for all subsequent
+// Bitwarden CLI commands.
+func (c *Config) bitwardenUnlock() error {
+	status, err := c.bitwardenStatus()
+	if err != nil {
+		return err
+	}
+	name := c.Bitwarden.Command
+	switch status {
+	case bitwardenStatusUnlocked:
+		return nil
+	case bitwardenStatusLocked:
+		cmd := exec.Command(name, "unlock", "--raw")
+		cmd.Stdin = os.Stdin
+		cmd.Stderr = os.Stderr
+		output, err := c.mutator.IdempotentCmdOutput(cmd)
+		if err != nil {
+			return fmt.Errorf("bitwarden: %s unlock: %w", name, err)
+		}
+		session := strings.TrimSpace(string(output))
+		if session == "" {
+			return fmt.Errorf("bitwarden: %s unlock: no session key", name)
+		}
+		c.redactor.Add(session)
+		c.Bitwarden.session = session
+		return nil
+	case bitwardenStatusUnauthenticated:
+		return fmt.Errorf("bitwarden: not logged in, run %s login", name)
+	default:
+		return fmt.Errorf("bitwarden: %s status: unknown status %q", name, status)
+	}
+}
@@ -0,0 +1,108 @@
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestBitwardenUnlock(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+	bw := filepath.Join(tempDir, "bw")
+	evidence := filepath.Join(tempDir, "evidence")
+	require.NoError(t, ioutil.WriteFile(bw, []byte(strings.Join([]string{
+		"#!/bin/sh",
+		"echo \"$*\" >>" + evidence,
+		"case \"$1\" in",
+		"status)",
+		"  if [ \"$BW_SESSION\" = session-key ]; then",
+		"    echo '{\"status\":\"unlocked\"}'",
+		"  else",
+		"    echo '{\"status\":\"locked\"}'",
+		"  fi",
+		"  ;;",
+		"unlock)",
+		"  echo session-key",
+		"  ;;",
+		"get)",
+		"  [ \"$BW_SESSION\" = session-key ] || exit 1",
+		"  case \"$2\" in",
+		"  attachment) echo \"$3 of $5\" ;;",
+		"  item) echo \"{\\\"name\\\":\\\"$3\\\",\\\"fields\\\":[{\\\"name\\\":\\\"field\\\",\\\"value\\\":\\\"value\\\",\\\"type\\\":0}]}\" ;;",
+		"  esac",
+		"  ;;",
+		"esac",
+	}, "\n")), 0o755))
+
+	c := newConfig(
+		withMutator(chezmoi.NullMutator{}),
+	)
+	c.Bitwarden.Command = bw
+
+	status, err := c.bitwardenStatus()
+	require.NoError(t, err)
+	assert.Equal(t, bitwardenStatusLocked, status)
+
+	assert.Equal(t, "item1", c.bitwardenFunc("item", "item1").(map[string]interface{})["name"])
+	assert.Equal(t, map[string]interface{}{
+		"field": map[string]interface{}{
+			"name":  "field",
+			"value": "value",
+			"type":  0.0,
+		},
+	}, c.bitwardenFieldsFunc("item", "item2"))
+	assert.Equal(t, "attachment of item1\n", c.bitwardenAttachmentFunc("attachment", "item1"))
+
+	status, err = c.bitwardenStatus()
+	require.NoError(t, err)
+	assert.Equal(t, bitwardenStatusUnlocked, status)
+
+	// The vault is only unlocked once.
+	actual, err := ioutil.ReadFile(evidence)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"status",
+		"status",
+		"unlock --raw",
+		"get item item1",
+		"get item item2",
+		"get attachment attachment --itemid item1 --raw",
+		"status",
+	}, "\n")+"\n", string(actual))
+
+	// The session key is redacted.
+	assert.Equal(t, chezmoi.Redacted, c.redactor.RedactString("session-key"))
+}
+
+func TestBitwardenUnauthenticated(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+	bw := filepath.Join(tempDir, "bw")
+	require.NoError(t, ioutil.WriteFile(bw, []byte("#!/bin/sh\necho '{\"status\":\"unauthenticated\"}'\n"), 0o755))
+
+	c := newConfig(
+		withMutator(chezmoi.NullMutator{}),
+	)
+	c.Bitwarden.Command = bw
+
+	assert.EqualError(t, c.bitwardenUnlock(), "bitwarden: not logged in, run "+bw+" login")
+	assert.Panics(t, func() {
+		c.bitwardenFunc("item", "item")
+	})
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var secretCacheCmd = &cobra.Command{
+	Use:   "cache",
+	Args:  cobra.NoArgs,
+	Short: "Manage the secret cache",
+}
+
+var secretCacheClearCmd = &cobra.Command{
+	Use:     "clear",
+	Args:    cobra.NoArgs,
+	Short:   "Clear the secret cache",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runSecretCacheClearCmd,
+}
+
+const (
+	secretCacheKeyFileName = "chezmoisecretcache.key"
+	secretCacheKeySize     = 32
+)
+
+var secretCacheStateBucket = []byte("secretCache")
+
+// A secretCacheState is the cached output of a secret manager command.
+type secretCacheState struct {
+	FetchedAt time.Time `json:"fetchedAt"`
+	Output    []byte    `json:"output"`
+}
+
+func init() {
+	secretCacheCmd.AddCommand(secretCacheClearCmd)
+	secretCmd.AddCommand(secretCacheCmd)
+}
+
+func (c *Config) runSecretCacheClearCmd(cmd *cobra.Command, args []string) error {
+	return c.withPersistentState(func(persistentState chezmoi.PersistentState, readOnly bool) error {
+		if readOnly {
+			return errors.New("secret cache not cleared: persistent state is read-only")
+		}
+		return persistentState.DeleteBucket(secretCacheStateBucket)
+	})
+}
+
+// cachedSecretOutput returns the output of the secret manager command args,
+// calling f to run the command if needed. If ttl is positive then the output is
+// cached, encrypted with a local key, in the persistent state for ttl.
+func (c *Config) cachedSecretOutput(ttl time.Duration, args []string, f func() ([]byte, error)) ([]byte, error) {
+	if ttl <= 0 {
+		return f()
+	}
+	key := secretCacheStateKey(args)
+	output, err := c.getCachedSecretOutput(key, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if output != nil {
+		return output, nil
+	}
+	output, err = f()
+	if err != nil {
+		return output, err
+	}
+	if err := c.setCachedSecretOutput(key, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// getCachedSecretOutput returns the cached output for key, or nil if there is
+// no valid cached output.
+func (c *Config) getCachedSecretOutput(key []byte, ttl time.Duration) ([]byte, error) {
+	var output []byte
+	if err := c.withPersistentState(func(persistentState chezmoi.PersistentState, readOnly bool) error {
+		ciphertext, err := persistentState.Get(secretCacheStateBucket, key)
+		if err != nil || ciphertext == nil {
+			return err
+		}
+		aead, err := c.getSecretCacheAEAD(readOnly)
+		if err != nil || aead == nil {
+			return err
+		}
+		nonceSize := aead.NonceSize()
+		if len(ciphertext) < nonceSize {
+			return nil
+		}
+		plaintext, err := aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], key)
+		if err != nil {
+			// Ignore cached output encrypted with a different key.
+			return nil //nolint:nilerr
+		}
+		var state secretCacheState
+		if err := json.Unmarshal(plaintext, &state); err != nil {
+			// Ignore invalid cached output.
+			return nil //nolint:nilerr
+		}
+		if time.Since(state.FetchedAt) > ttl {
+			return nil
+		}
+		output = state.Output
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// setCachedSecretOutput caches output as the output for key.
+func (c *Config) setCachedSecretOutput(key, output []byte) error {
+	plaintext, err := json.Marshal(&secretCacheState{
+		FetchedAt: time.Now(),
+		Output:    output,
+	})
+	if err != nil {
+		return err
+	}
+	return c.withPersistentState(func(persistentState chezmoi.PersistentState, readOnly bool) error {
+		if readOnly {
+			return nil
+		}
+		aead, err := c.getSecretCacheAEAD(readOnly)
+		if err != nil {
+			return err
+		}
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return err
+		}
+		ciphertext := aead.Seal(nonce, nonce, plaintext, key)
+		return persistentState.Set(secretCacheStateBucket, key, ciphertext)
+	})
+}
+
+// getSecretCacheAEAD returns the cipher used to encrypt the secret cache. The
+// key is read from a file next to the persistent state, which is created if it
+// does not exist and readOnly is false. If there is no key and readOnly is true
+// then getSecretCacheAEAD returns nil.
+func (c *Config) getSecretCacheAEAD(readOnly bool) (cipher.AEAD, error) {
+	if c.secretCacheKey == nil {
+		keyFile := c.getSecretCacheKeyFile()
+		key, err := c.fs.ReadFile(keyFile)
+		switch {
+		case os.IsNotExist(err) && readOnly:
+			return nil, nil
+		case os.IsNotExist(err):
+			key = make([]byte, secretCacheKeySize)
+			if _, err := io.ReadFull(rand.Reader, key); err != nil {
+				return nil, err
+			}
+			if err := vfs.MkdirAll(c.mutator, filepath.Dir(keyFile), 0o777&^os.FileMode(c.Umask)); err != nil {
+				return nil, err
+			}
+			if err := c.mutator.WriteFile(keyFile, key, 0o600, nil); err != nil {
+				return nil, err
+			}
+		case err != nil:
+			return nil, err
+		case len(key) != secretCacheKeySize:
+			return nil, errors.New(keyFile + ": invalid key")
+		}
+		c.secretCacheKey = key
+	}
+	block, err := aes.NewCipher(c.secretCacheKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// getSecretCacheKeyFile returns the path of the secret cache key file.
+func (c *Config) getSecretCacheKeyFile() string {
+	return filepath.Join(filepath.Dir(c.getPersistentStateFile()), secretCacheKeyFileName)
+}
+
+// secretCacheStateKey returns the persistent state key for the secret manager
+// command args. The key is a hash so that the names of secrets are not stored.
+func secretCacheStateKey(args []string) []byte {
+	sum := sha256.Sum256([]byte(strings.Join(args, "\x00")))
+	return sum[:]
+}
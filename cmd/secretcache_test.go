@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestSecretCache(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0o755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	calls := 0
+	secret := []byte("secret")
+	f := func() ([]byte, error) {
+		calls++
+		return secret, nil
+	}
+	args := []string{"secret-manager", "get", "item"}
+
+	c := newTestConfig(fs)
+	output, err := c.cachedSecretOutput(time.Hour, args, f)
+	require.NoError(t, err)
+	assert.Equal(t, secret, output)
+	assert.Equal(t, 1, calls)
+
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.config/chezmoi/"+secretCacheKeyFileName,
+			vfst.TestModeIsRegular,
+			vfst.TestModePerm(0o600),
+		),
+	)
+	require.NoError(t, c.withPersistentState(func(persistentState chezmoi.PersistentState, readOnly bool) error {
+		ciphertext, err := persistentState.Get(secretCacheStateBucket, secretCacheStateKey(args))
+		require.NoError(t, err)
+		assert.NotNil(t, ciphertext)
+		assert.False(t, bytes.Contains(ciphertext, secret))
+		return nil
+	}))
+
+	// A new invocation uses the cached output.
+	c = newTestConfig(fs)
+	output, err = c.cachedSecretOutput(time.Hour, args, f)
+	require.NoError(t, err)
+	assert.Equal(t, secret, output)
+	assert.Equal(t, 1, calls)
+
+	// Expired output is not used.
+	_, err = c.cachedSecretOutput(time.Nanosecond, args, f)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	// A zero TTL disables the cache.
+	_, err = c.cachedSecretOutput(0, args, f)
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	require.NoError(t, c.runSecretCacheClearCmd(&cobra.Command{}, nil))
+	_, err = c.cachedSecretOutput(time.Hour, args, f)
+	require.NoError(t, err)
+	assert.Equal(t, 4, calls)
+
+	// Output encrypted with a different key is ignored.
+	require.NoError(t, fs.WriteFile("/home/user/.config/chezmoi/"+secretCacheKeyFileName, bytes.Repeat([]byte{0}, secretCacheKeySize), 0o600))
+	c = newTestConfig(fs)
+	_, err = c.cachedSecretOutput(time.Hour, args, f)
+	require.NoError(t, err)
+	assert.Equal(t, 5, calls)
+}
+
+func TestSecretCacheNoChanges(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0o755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	// The key file is written with the mutator, so it is not written when
+	// chezmoi is not making changes.
+	c := newTestConfig(
+		fs,
+		withMutator(chezmoi.NullMutator{}),
+	)
+	output, err := c.cachedSecretOutput(time.Hour, []string{"secret-manager"}, func() ([]byte, error) {
+		return []byte("secret"), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), output)
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.config/chezmoi/"+secretCacheKeyFileName,
+			vfst.TestDoesNotExist,
+		),
+	)
+
+	// The cache cannot be cleared if the persistent state is read-only.
+	c.DryRun = true
+	assert.Error(t, c.runSecretCacheClearCmd(&cobra.Command{}, nil))
+}
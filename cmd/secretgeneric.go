@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -21,7 +22,8 @@ var genericSecretCmd = &cobra.Command{
 }
 
 type genericSecretCmdConfig struct {
-	Command string
+	Command  string
+	CacheTTL time.Duration
 }
 
 var (
@@ -30,8 +32,8 @@ var (
 )
 
 func init() {
-	config.addTemplateFunc("secret", config.secretFunc)
-	config.addTemplateFunc("secretJSON", config.secretJSONFunc)
+	config.addSecretTemplateFunc("secret", config.secretFunc)
+	config.addSecretTemplateFunc("secretJSON", config.secretJSONFunc)
 
 	secretCmd.AddCommand(genericSecretCmd)
 }
@@ -49,7 +51,9 @@ func (c *Config) secretFunc(args ...string) string {
 	cmd := exec.Command(name, args...)
 	cmd.Stdin = os.Stdin
 	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.cachedSecretOutput(c.GenericSecret.CacheTTL, append([]string{name}, args...), func() ([]byte, error) {
+		return c.mutator.IdempotentCmdOutput(cmd)
+	})
 	if err != nil {
 		panic(fmt.Errorf("secret: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
 	}
@@ -67,7 +71,9 @@ func (c *Config) secretJSONFunc(args ...string) interface{} {
 	cmd := exec.Command(name, args...)
 	cmd.Stdin = os.Stdin
 	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.cachedSecretOutput(c.GenericSecret.CacheTTL, append([]string{name}, args...), func() ([]byte, error) {
+		return c.mutator.IdempotentCmdOutput(cmd)
+	})
 	if err != nil {
 		panic(fmt.Errorf("secretJSON: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
 	}
@@ -1,9 +1,9 @@
 package cmd
 
 import (
-	"bytes"
 	"fmt"
 	"os/exec"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -18,39 +18,56 @@ var gopassCmd = &cobra.Command{
 }
 
 type gopassCmdConfig struct {
-	Command string
+	Command  string
+	CacheTTL time.Duration
 }
 
-var gopassCache = make(map[string]string)
+var gopassCache = make(map[string][]byte)
 
 func init() {
 	secretCmd.AddCommand(gopassCmd)
 
 	config.Gopass.Command = "gopass"
-	config.addTemplateFunc("gopass", config.gopassFunc)
+	config.addSecretTemplateFunc("gopass", config.gopassFunc)
+	config.addSecretTemplateFunc("gopassFields", config.gopassFieldsFunc)
+	config.addSecretTemplateFunc("gopassRaw", config.gopassRawFunc)
 }
 
 func (c *Config) runSecretGopassCmd(cmd *cobra.Command, args []string) error {
 	return c.run("", c.Gopass.Command, args...)
 }
 
+// gopassFunc returns the password, the first line of the secret id.
 func (c *Config) gopassFunc(id string) string {
-	if s, ok := gopassCache[id]; ok {
-		return s
+	return string(firstLine(c.gopassOutput("gopass", id)))
+}
+
+// gopassFieldsFunc returns the key: value fields of the secret id, following
+// the password.
+func (c *Config) gopassFieldsFunc(id string) map[string]string {
+	return parsePassFields(c.gopassOutput("gopassFields", id))
+}
+
+// gopassRawFunc returns the entire secret id.
+func (c *Config) gopassRawFunc(id string) string {
+	return string(c.gopassOutput("gopassRaw", id))
+}
+
+// gopassOutput returns the output of gopass show id. funcName is the name of
+// the calling template function, used in errors.
+func (c *Config) gopassOutput(funcName, id string) []byte {
+	if output, ok := gopassCache[id]; ok {
+		return output
 	}
 	name := c.Gopass.Command
 	args := []string{"show", id}
 	cmd := exec.Command(name, args...)
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.cachedSecretOutput(c.Gopass.CacheTTL, append([]string{name}, args...), func() ([]byte, error) {
+		return c.mutator.IdempotentCmdOutput(cmd)
+	})
 	if err != nil {
-		panic(fmt.Errorf("gopass: %s %s: %w", name, chezmoi.ShellQuoteArgs(args), err))
-	}
-	var password string
-	if index := bytes.IndexByte(output, '\n'); index != -1 {
-		password = string(output[:index])
-	} else {
-		password = string(output)
+		panic(fmt.Errorf("%s: %s %s: %w", funcName, name, chezmoi.ShellQuoteArgs(args), err))
 	}
-	gopassCache[id] = password
-	return gopassCache[id]
+	gopassCache[id] = output
+	return output
 }
@@ -5,14 +5,14 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"os"
 	"os/exec"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/spf13/cobra"
-	"golang.org/x/crypto/ssh/terminal"
+	"github.com/tobischo/gokeepasslib/v3"
 
 	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
@@ -27,7 +27,22 @@ var keePassXCCmd = &cobra.Command{
 type keePassXCCmdConfig struct {
 	Command  string
 	Database string
+	KeyFile  string
+	Mode     string
 	Args     []string
+	CacheTTL time.Duration
+	database *gokeepasslib.Database
+}
+
+// KeePassXC modes.
+const (
+	keePassXCModeBuiltin = "builtin"
+	keePassXCModeCLI     = "cli"
+)
+
+type keePassXCAttachmentCacheKey struct {
+	entry string
+	name  string
 }
 
 type keePassXCAttributeCacheKey struct {
@@ -38,6 +53,7 @@ type keePassXCAttributeCacheKey struct {
 var (
 	keePassXCVersion                     *semver.Version
 	keePassXCCache                       = make(map[string]map[string]string)
+	keePassXCAttachmentCache             = make(map[keePassXCAttachmentCacheKey]string)
 	keePassXCAttributeCache              = make(map[keePassXCAttributeCacheKey]string)
 	keePassXCPairRegexp                  = regexp.MustCompile(`^([^:]+): (.*)$`)
 	keePassXCPassword                    string
@@ -46,8 +62,10 @@ var (
 
 func init() {
 	config.KeePassXC.Command = "keepassxc-cli"
-	config.addTemplateFunc("keepassxc", config.keePassXCFunc)
-	config.addTemplateFunc("keepassxcAttribute", config.keePassXCAttributeFunc)
+	config.KeePassXC.Mode = keePassXCModeCLI
+	config.addSecretTemplateFunc("keepassxc", config.keePassXCFunc)
+	config.addSecretTemplateFunc("keepassxcAttachment", config.keePassXCAttachmentFunc)
+	config.addSecretTemplateFunc("keepassxcAttribute", config.keePassXCAttributeFunc)
 
 	secretCmd.AddCommand(keePassXCCmd)
 }
@@ -78,16 +96,20 @@ func (c *Config) keePassXCFunc(entry string) map[string]string {
 	if data, ok := keePassXCCache[entry]; ok {
 		return data
 	}
-	if c.KeePassXC.Database == "" {
-		panic(errors.New("keepassxc: keepassxc.database not set"))
+	if c.keePassXCBuiltin() {
+		data := make(map[string]string)
+		for _, value := range c.keePassXCBuiltinEntry(entry).Values {
+			data[value.Key] = value.Value.Content
+		}
+		keePassXCCache[entry] = data
+		return data
 	}
 	name := c.KeePassXC.Command
 	args := []string{"show"}
 	if c.getKeePassXCVersion().Compare(keePassXCNeedShowProtectedArgVersion) >= 0 {
 		args = append(args, "--show-protected")
 	}
-	args = append(args, c.KeePassXC.Args...)
-	args = append(args, c.KeePassXC.Database, entry)
+	args = append(args, c.keePassXCCLIArgs(entry)...)
 	output, err := c.runKeePassXCCLICommand(name, args)
 	if err != nil {
 		panic(fmt.Errorf("keepassxc: %s %s: %w", name, chezmoi.ShellQuoteArgs(args), err))
@@ -108,16 +130,20 @@ func (c *Config) keePassXCAttributeFunc(entry, attribute string) string {
 	if data, ok := keePassXCAttributeCache[key]; ok {
 		return data
 	}
-	if c.KeePassXC.Database == "" {
-		panic(errors.New("keepassxc: keepassxc.database not set"))
+	if c.keePassXCBuiltin() {
+		value := c.keePassXCBuiltinEntry(entry).Get(attribute)
+		if value == nil {
+			panic(fmt.Errorf("keepassxc: %s: attribute %s not found", entry, attribute))
+		}
+		keePassXCAttributeCache[key] = value.Value.Content
+		return value.Value.Content
 	}
 	name := c.KeePassXC.Command
 	args := []string{"show", "--attributes", attribute, "--quiet"}
 	if c.getKeePassXCVersion().Compare(keePassXCNeedShowProtectedArgVersion) >= 0 {
 		args = append(args, "--show-protected")
 	}
-	args = append(args, c.KeePassXC.Args...)
-	args = append(args, c.KeePassXC.Database, entry)
+	args = append(args, c.keePassXCCLIArgs(entry)...)
 	output, err := c.runKeePassXCCLICommand(name, args)
 	if err != nil {
 		panic(fmt.Errorf("keepassxc: %s %s: %w", name, chezmoi.ShellQuoteArgs(args), err))
@@ -127,20 +153,183 @@ func (c *Config) keePassXCAttributeFunc(entry, attribute string) string {
 	return outputStr
 }
 
-func (c *Config) runKeePassXCCLICommand(name string, args []string) ([]byte, error) {
+// keePassXCAttachmentFunc returns the contents of the attachment name of
+// entry.
+func (c *Config) keePassXCAttachmentFunc(entry, name string) string {
+	key := keePassXCAttachmentCacheKey{
+		entry: entry,
+		name:  name,
+	}
+	if data, ok := keePassXCAttachmentCache[key]; ok {
+		return data
+	}
+	if c.keePassXCBuiltin() {
+		kpEntry := c.keePassXCBuiltinEntry(entry)
+		for _, binaryReference := range kpEntry.Binaries {
+			if binaryReference.Name != name {
+				continue
+			}
+			binary := binaryReference.Find(c.KeePassXC.database)
+			if binary == nil {
+				panic(fmt.Errorf("keepassxc: %s: attachment %s: binary %d not found", entry, name, binaryReference.Value.ID))
+			}
+			data, err := binary.GetContent()
+			if err != nil {
+				panic(fmt.Errorf("keepassxc: %s: attachment %s: %w", entry, name, err))
+			}
+			keePassXCAttachmentCache[key] = data
+			return data
+		}
+		panic(fmt.Errorf("keepassxc: %s: attachment %s not found", entry, name))
+	}
+	command := c.KeePassXC.Command
+	args := append([]string{"attachment-export", "--stdout"}, c.keePassXCCLIArgs(entry, name)...)
+	output, err := c.runKeePassXCCLICommand(command, args)
+	if err != nil {
+		panic(fmt.Errorf("keepassxc: %s %s: %w", command, chezmoi.ShellQuoteArgs(args), err))
+	}
+	keePassXCAttachmentCache[key] = string(output)
+	return string(output)
+}
+
+// keePassXCBuiltin returns whether the KeePassXC database should be read
+// directly instead of with the KeePassXC CLI. In cli mode the database is read
+// directly if the KeePassXC CLI cannot be found.
+func (c *Config) keePassXCBuiltin() bool {
+	if c.KeePassXC.Database == "" {
+		panic(errors.New("keepassxc: keepassxc.database not set"))
+	}
+	switch c.KeePassXC.Mode {
+	case keePassXCModeBuiltin:
+		return true
+	case keePassXCModeCLI:
+		_, err := exec.LookPath(c.KeePassXC.Command)
+		return err != nil
+	default:
+		panic(fmt.Errorf("keepassxc: %s: invalid mode", c.KeePassXC.Mode))
+	}
+}
+
+// keePassXCBuiltinEntry returns entry from the KeePassXC database, which is
+// read and unlocked the first time it is needed.
+func (c *Config) keePassXCBuiltinEntry(entry string) *gokeepasslib.Entry {
+	if c.KeePassXC.database == nil {
+		database, err := c.readKeePassXCDatabase()
+		if err != nil {
+			panic(fmt.Errorf("keepassxc: %s: %w", c.KeePassXC.Database, err))
+		}
+		c.KeePassXC.database = database
+	}
+	kpEntry := findKeePassXCEntry(c.KeePassXC.database.Content.Root, entry)
+	if kpEntry == nil {
+		panic(fmt.Errorf("keepassxc: %s: entry %s not found", c.KeePassXC.Database, entry))
+	}
+	return kpEntry
+}
+
+// keePassXCCLIArgs returns the KeePassXC CLI arguments for the key file,
+// extra arguments, database, and args.
+func (c *Config) keePassXCCLIArgs(args ...string) []string {
+	var result []string
+	if c.KeePassXC.KeyFile != "" {
+		result = append(result, "--key-file", c.KeePassXC.KeyFile)
+	}
+	result = append(result, c.KeePassXC.Args...)
+	result = append(result, c.KeePassXC.Database)
+	return append(result, args...)
+}
+
+// readKeePassXCDatabase reads and unlocks the KeePassXC database.
+func (c *Config) readKeePassXCDatabase() (*gokeepasslib.Database, error) {
+	data, err := c.fs.ReadFile(c.KeePassXC.Database)
+	if err != nil {
+		return nil, err
+	}
+	password, err := c.readKeePassXCPassword()
+	if err != nil {
+		return nil, err
+	}
+	database := gokeepasslib.NewDatabase()
+	if c.KeePassXC.KeyFile == "" {
+		database.Credentials = gokeepasslib.NewPasswordCredentials(password)
+	} else {
+		keyData, err := c.fs.ReadFile(c.KeePassXC.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if password == "" {
+			database.Credentials, err = gokeepasslib.NewKeyDataCredentials(keyData)
+		} else {
+			database.Credentials, err = gokeepasslib.NewPasswordAndKeyDataCredentials(password, keyData)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := gokeepasslib.NewDecoder(bytes.NewReader(data)).Decode(database); err != nil {
+		return nil, err
+	}
+	if err := database.UnlockProtectedEntries(); err != nil {
+		return nil, err
+	}
+	return database, nil
+}
+
+// readKeePassXCPassword returns the password of the KeePassXC database,
+// prompting the user for it the first time it is needed.
+func (c *Config) readKeePassXCPassword() (string, error) {
 	if keePassXCPassword == "" {
-		fmt.Printf("Insert password to unlock %s: ", c.KeePassXC.Database)
-		password, err := terminal.ReadPassword(int(os.Stdout.Fd()))
-		fmt.Println()
+		password, err := c.readPassword(fmt.Sprintf("Insert password to unlock %s: ", c.KeePassXC.Database))
 		if err != nil {
+			return "", err
+		}
+		keePassXCPassword = password
+	}
+	return keePassXCPassword, nil
+}
+
+func (c *Config) runKeePassXCCLICommand(name string, args []string) ([]byte, error) {
+	return c.cachedSecretOutput(c.KeePassXC.CacheTTL, append([]string{name}, args...), func() ([]byte, error) {
+		if _, err := c.readKeePassXCPassword(); err != nil {
 			return nil, err
 		}
-		keePassXCPassword = string(password)
+		cmd := exec.Command(name, args...)
+		cmd.Stdin = bytes.NewBufferString(keePassXCPassword + "\n")
+		cmd.Stderr = c.Stderr
+		return c.mutator.IdempotentCmdOutput(cmd)
+	})
+}
+
+// findKeePassXCEntry returns the entry at path, relative to the root group like
+// the KeePassXC CLI.
+func findKeePassXCEntry(root *gokeepasslib.RootData, path string) *gokeepasslib.Entry {
+	components := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i := range root.Groups {
+		if entry := findKeePassXCEntryByPath(&root.Groups[i], components); entry != nil {
+			return entry
+		}
 	}
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = bytes.NewBufferString(keePassXCPassword + "\n")
-	cmd.Stderr = c.Stderr
-	return c.mutator.IdempotentCmdOutput(cmd)
+	return nil
+}
+
+func findKeePassXCEntryByPath(group *gokeepasslib.Group, components []string) *gokeepasslib.Entry {
+	if len(components) == 1 {
+		for i := range group.Entries {
+			if group.Entries[i].GetTitle() == components[0] {
+				return &group.Entries[i]
+			}
+		}
+		return nil
+	}
+	for i := range group.Groups {
+		if group.Groups[i].Name != components[0] {
+			continue
+		}
+		if entry := findKeePassXCEntryByPath(&group.Groups[i], components[1:]); entry != nil {
+			return entry
+		}
+	}
+	return nil
 }
 
 func parseKeyPassXCOutput(output []byte) (map[string]string, error) {
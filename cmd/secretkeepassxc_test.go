@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tobischo/gokeepasslib/v3"
+	"github.com/tobischo/gokeepasslib/v3/wrappers"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestKeePassXCBuiltin(t *testing.T) {
+	keyData := bytes.Repeat([]byte{'k'}, 32)
+	credentials, err := gokeepasslib.NewPasswordAndKeyDataCredentials("password", keyData)
+	require.NoError(t, err)
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/secrets.kdbx": newTestKeePassXCDatabase(t, credentials),
+		"/home/user/secrets.key":  keyData,
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	defer func(password string) {
+		keePassXCPassword = password
+	}(keePassXCPassword)
+	keePassXCPassword = "password"
+
+	c := newTestConfig(fs)
+	c.KeePassXC.Database = "/home/user/secrets.kdbx"
+	c.KeePassXC.KeyFile = "/home/user/secrets.key"
+	c.KeePassXC.Mode = keePassXCModeBuiltin
+
+	assert.Equal(t, map[string]string{
+		"Title":    "entry",
+		"UserName": "user",
+		"Password": "secret",
+		"custom":   "custom value",
+	}, c.keePassXCFunc("group/entry"))
+	assert.Equal(t, "custom value", c.keePassXCAttributeFunc("/group/entry", "custom"))
+	assert.Equal(t, "attachment contents", c.keePassXCAttachmentFunc("group/entry", "attachment.txt"))
+	assert.Panics(t, func() {
+		c.keePassXCFunc("group/missing")
+	})
+	assert.Panics(t, func() {
+		c.keePassXCFunc("entry")
+	})
+	assert.Panics(t, func() {
+		c.keePassXCAttachmentFunc("group/entry", "missing.txt")
+	})
+}
+
+func TestKeePassXCCLIFallback(t *testing.T) {
+	// Passwords may contain leading and trailing spaces.
+	password := " pass word "
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/secrets.kdbx": newTestKeePassXCDatabase(t, gokeepasslib.NewPasswordCredentials(password)),
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	defer func(password string) {
+		keePassXCPassword = password
+	}(keePassXCPassword)
+	keePassXCPassword = ""
+
+	c := newTestConfig(
+		fs,
+		withStdin(strings.NewReader(password+"\n")),
+	)
+	c.KeePassXC.Command = "chezmoi-test-no-such-keepassxc-cli"
+	c.KeePassXC.Database = "/home/user/secrets.kdbx"
+	c.KeePassXC.Mode = keePassXCModeCLI
+
+	assert.Equal(t, "custom value", c.keePassXCAttributeFunc("group/entry", "custom"))
+	assert.Equal(t, password, keePassXCPassword)
+}
+
+func TestKeePassXCCLIArgs(t *testing.T) {
+	c := newConfig()
+	c.KeePassXC.Database = "secrets.kdbx"
+	c.KeePassXC.Args = []string{"--no-password"}
+	assert.Equal(t, []string{"--no-password", "secrets.kdbx", "entry"}, c.keePassXCCLIArgs("entry"))
+	c.KeePassXC.KeyFile = "secrets.key"
+	assert.Equal(t, []string{"--key-file", "secrets.key", "--no-password", "secrets.kdbx", "entry", "attachment"}, c.keePassXCCLIArgs("entry", "attachment"))
+}
+
+// newTestKeePassXCDatabase returns a KeePassXC database, locked with
+// credentials, containing the entry group/entry.
+func newTestKeePassXCDatabase(t *testing.T, credentials *gokeepasslib.DBCredentials) []byte {
+	t.Helper()
+	database := gokeepasslib.NewDatabase()
+	database.Credentials = credentials
+	attachment := database.Content.Meta.Binaries.Add([]byte("attachment contents"))
+	entry := gokeepasslib.NewEntry()
+	entry.Values = []gokeepasslib.ValueData{
+		{Key: "Title", Value: gokeepasslib.V{Content: "entry"}},
+		{Key: "UserName", Value: gokeepasslib.V{Content: "user"}},
+		{Key: "Password", Value: gokeepasslib.V{Content: "secret", Protected: wrappers.NewBoolWrapper(true)}},
+		{Key: "custom", Value: gokeepasslib.V{Content: "custom value"}},
+	}
+	entry.Binaries = []gokeepasslib.BinaryReference{attachment.CreateReference("attachment.txt")}
+	group := gokeepasslib.NewGroup()
+	group.Name = "group"
+	group.Entries = []gokeepasslib.Entry{entry}
+	root := gokeepasslib.NewGroup()
+	root.Name = "Root"
+	root.Groups = []gokeepasslib.Group{group}
+	database.Content.Root.Groups = []gokeepasslib.Group{root}
+	require.NoError(t, database.LockProtectedEntries())
+	buffer := &bytes.Buffer{}
+	require.NoError(t, gokeepasslib.NewEncoder(buffer).Encode(database))
+	return buffer.Bytes()
+}
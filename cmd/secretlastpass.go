@@ -10,6 +10,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 	"unicode"
 
 	"github.com/coreos/go-semver/semver"
@@ -35,6 +36,7 @@ var (
 
 type lastpassCmdConfig struct {
 	Command          string
+	CacheTTL         time.Duration
 	versionCheckOnce sync.Once
 }
 
@@ -42,8 +44,8 @@ var lastPassCache = make(map[string][]map[string]interface{})
 
 func init() {
 	config.Lastpass.Command = "lpass"
-	config.addTemplateFunc("lastpass", config.lastpassFunc)
-	config.addTemplateFunc("lastpassRaw", config.lastpassRawFunc)
+	config.addSecretTemplateFunc("lastpass", config.lastpassFunc)
+	config.addSecretTemplateFunc("lastpassRaw", config.lastpassRawFunc)
 
 	secretCmd.AddCommand(lastpassCmd)
 }
@@ -65,13 +67,16 @@ func (c *Config) lastpassOutput(args ...string) ([]byte, error) {
 }
 
 func (c *Config) lastpassRawFunc(id string) []map[string]interface{} {
-	c.Lastpass.versionCheckOnce.Do(func() {
-		panicOnError(c.lastpassVersionCheck())
-	})
 	if data, ok := lastPassCache[id]; ok {
 		return data
 	}
-	output, err := c.lastpassOutput("show", "--json", id)
+	args := []string{"show", "--json", id}
+	output, err := c.cachedSecretOutput(c.Lastpass.CacheTTL, append([]string{c.Lastpass.Command}, args...), func() ([]byte, error) {
+		c.Lastpass.versionCheckOnce.Do(func() {
+			panicOnError(c.lastpassVersionCheck())
+		})
+		return c.lastpassOutput(args...)
+	})
 	panicOnError(err)
 	var data []map[string]interface{}
 	if err := json.Unmarshal(output, &data); err != nil {
@@ -5,7 +5,12 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/coreos/go-semver/semver"
 	"github.com/spf13/cobra"
 
 	"github.com/twpayne/chezmoi/internal/chezmoi"
@@ -19,18 +24,39 @@ var onepasswordCmd = &cobra.Command{
 }
 
 type onepasswordCmdConfig struct {
-	Command string
+	Command     string
+	CacheTTL    time.Duration
+	sessionEnv  string
+	signinOnce  sync.Once
+	signinErr   error
+	version     *semver.Version
+	versionOnce sync.Once
+	versionErr  error
 }
 
 var (
-	onepasswordCache         = make(map[string]interface{})
+	// Version 2 of the 1Password CLI changed the command syntax from op <verb>
+	// <object> to op <object> <verb> and the format of items.
+	onepasswordVersion2      = semver.Version{Major: 2}
+	onepasswordVersionRegexp = regexp.MustCompile(`^(\d+\.\d+\.\d+)`)
+
+	// op signin prints a shell command to set the session token for the
+	// account in an environment variable, for example export
+	// OP_SESSION_my="token".
+	onepasswordSessionRegexp = regexp.MustCompile(`(OP_SESSION_\w+)="([^"]+)"`)
+)
+
+var (
+	onepasswordCache         = make(map[string]map[string]interface{})
 	onepasswordDocumentCache = make(map[string]string)
 )
 
 func init() {
 	config.Onepassword.Command = "op"
-	config.addTemplateFunc("onepassword", config.onepasswordFunc)
-	config.addTemplateFunc("onepasswordDocument", config.onepasswordDocumentFunc)
+	config.addSecretTemplateFunc("onepassword", config.onepasswordFunc)
+	config.addSecretTemplateFunc("onepasswordDetailsFields", config.onepasswordDetailsFieldsFunc)
+	config.addSecretTemplateFunc("onepasswordDocument", config.onepasswordDocumentFunc)
+	config.addSecretTemplateFunc("onepasswordItemFields", config.onepasswordItemFieldsFunc)
 
 	secretCmd.AddCommand(onepasswordCmd)
 }
@@ -39,40 +65,236 @@ func (c *Config) runOnepasswordCmd(cmd *cobra.Command, args []string) error {
 	return c.run("", c.Onepassword.Command, args...)
 }
 
-func (c *Config) onepasswordFunc(item string) interface{} {
-	if data, ok := onepasswordCache[item]; ok {
+func (c *Config) onepasswordFunc(uuid string, args ...string) map[string]interface{} {
+	key := strings.Join(append([]string{uuid}, args...), "\x00")
+	if data, ok := onepasswordCache[key]; ok {
 		return data
 	}
 	name := c.Onepassword.Command
-	args := []string{"get", "item", item}
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	args = append([]string{uuid}, onepasswordSelectionArgs(args)...)
+	output, err := c.cachedSecretOutput(c.Onepassword.CacheTTL, append([]string{name, "item"}, args...), func() ([]byte, error) {
+		return c.onepasswordOutput("item", "get", args)
+	})
 	if err != nil {
-		panic(fmt.Errorf("onepassword: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
+		panic(fmt.Errorf("onepassword: %s item %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
 	}
-	var data interface{}
+	var data map[string]interface{}
 	if err := json.Unmarshal(output, &data); err != nil {
-		panic(fmt.Errorf("onepassword: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
+		panic(fmt.Errorf("onepassword: %s item %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
 	}
-	onepasswordCache[item] = data
+	onepasswordCache[key] = data
 	return data
 }
 
-func (c *Config) onepasswordDocumentFunc(item string) interface{} {
-	if output, ok := onepasswordDocumentCache[item]; ok {
+// onepasswordDetailsFieldsFunc returns the built-in fields of an item, like
+// its username and password, keyed by their designation.
+func (c *Config) onepasswordDetailsFieldsFunc(uuid string, args ...string) map[string]interface{} {
+	item := c.onepasswordFunc(uuid, args...)
+	result := make(map[string]interface{})
+	if details, ok := item["details"].(map[string]interface{}); ok {
+		// Version 1 of the 1Password CLI.
+		fields, _ := details["fields"].([]interface{})
+		for _, field := range fields {
+			field, ok := field.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if designation, ok := field["designation"].(string); ok && designation != "" {
+				result[designation] = field
+			}
+		}
+		return result
+	}
+	fields, _ := item["fields"].([]interface{})
+	for _, field := range fields {
+		field, ok := field.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if purpose, ok := field["purpose"].(string); ok && purpose != "" {
+			result[strings.ToLower(purpose)] = field
+		}
+	}
+	return result
+}
+
+func (c *Config) onepasswordDocumentFunc(uuid string, args ...string) string {
+	key := strings.Join(append([]string{uuid}, args...), "\x00")
+	if output, ok := onepasswordDocumentCache[key]; ok {
 		return output
 	}
 	name := c.Onepassword.Command
-	args := []string{"get", "document", item}
-	cmd := exec.Command(name, args...)
+	args = append([]string{uuid}, onepasswordSelectionArgs(args)...)
+	output, err := c.cachedSecretOutput(c.Onepassword.CacheTTL, append([]string{name, "document"}, args...), func() ([]byte, error) {
+		return c.onepasswordOutput("document", "get", args)
+	})
+	if err != nil {
+		panic(fmt.Errorf("onepassword: %s document %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
+	}
+	onepasswordDocumentCache[key] = string(output)
+	return string(output)
+}
+
+// onepasswordItemFieldsFunc returns the fields in an item's sections keyed by
+// their label.
+func (c *Config) onepasswordItemFieldsFunc(uuid string, args ...string) map[string]interface{} {
+	item := c.onepasswordFunc(uuid, args...)
+	result := make(map[string]interface{})
+	if details, ok := item["details"].(map[string]interface{}); ok {
+		// Version 1 of the 1Password CLI.
+		sections, _ := details["sections"].([]interface{})
+		for _, section := range sections {
+			section, ok := section.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			fields, _ := section["fields"].([]interface{})
+			for _, field := range fields {
+				field, ok := field.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if label, ok := field["t"].(string); ok && label != "" {
+					result[label] = field
+				}
+			}
+		}
+		return result
+	}
+	fields, _ := item["fields"].([]interface{})
+	for _, field := range fields {
+		field, ok := field.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if purpose, ok := field["purpose"].(string); ok && purpose != "" {
+			continue
+		}
+		if label, ok := field["label"].(string); ok && label != "" {
+			result[label] = field
+		}
+	}
+	return result
+}
+
+// onepasswordArgs returns the 1Password CLI arguments to run verb on object
+// with args, using the command syntax of the installed version.
+func (c *Config) onepasswordArgs(object, verb string, args []string) ([]string, error) {
+	version, err := c.onepasswordVersion()
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	if version.LessThan(onepasswordVersion2) {
+		result = append([]string{verb, object}, args...)
+	} else {
+		result = append([]string{object, verb}, args...)
+		if object != "document" {
+			result = append(result, "--format", "json")
+		}
+	}
+	return result, nil
+}
+
+// onepasswordOutput returns the output of running verb on object with args
+// with the 1Password CLI, signing in first if needed.
+func (c *Config) onepasswordOutput(object, verb string, args []string) ([]byte, error) {
+	c.Onepassword.signinOnce.Do(func() {
+		c.Onepassword.signinErr = c.onepasswordSignin()
+	})
+	if c.Onepassword.signinErr != nil {
+		return nil, c.Onepassword.signinErr
+	}
+	cmdArgs, err := c.onepasswordArgs(object, verb, args)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(c.Onepassword.Command, cmdArgs...)
+	cmd.Env = c.onepasswordEnv()
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	return c.mutator.IdempotentCmdOutput(cmd)
+}
+
+// onepasswordSignedIn returns whether the 1Password CLI is signed in.
+func (c *Config) onepasswordSignedIn() bool {
+	args, err := c.onepasswordArgs("account", "get", nil)
+	if err != nil {
+		return false
+	}
+	cmd := exec.Command(c.Onepassword.Command, args...)
+	cmd.Env = c.onepasswordEnv()
+	_, err = c.mutator.IdempotentCmdOutput(cmd)
+	return err == nil
+}
+
+// onepasswordEnv returns the environment for running the 1Password CLI, which
+// includes the session token if the user was signed in by chezmoi. The session
+// token is passed in the environment so that it is not visible in the process
+// list.
+func (c *Config) onepasswordEnv() []string {
+	if c.Onepassword.sessionEnv == "" {
+		return nil
+	}
+	return append(os.Environ(), c.Onepassword.sessionEnv)
+}
+
+// onepasswordSignin signs in to 1Password if needed, prompting the user for
+// their master password. The session token is used for all subsequent
+// 1Password CLI commands.
+func (c *Config) onepasswordSignin() error {
+	if c.onepasswordSignedIn() {
+		return nil
+	}
+	name := c.Onepassword.Command
+	cmd := exec.Command(name, "signin")
 	cmd.Stdin = os.Stdin
 	cmd.Stderr = os.Stderr
 	output, err := c.mutator.IdempotentCmdOutput(cmd)
 	if err != nil {
-		panic(fmt.Errorf("onepassword: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
+		return fmt.Errorf("onepassword: %s signin: %w", name, err)
 	}
-	onepasswordDocumentCache[item] = string(output)
-	return string(output)
+	m := onepasswordSessionRegexp.FindSubmatch(output)
+	if m == nil {
+		return fmt.Errorf("onepassword: %s signin: no session token", name)
+	}
+	c.redactor.Add(string(m[2]))
+	c.Onepassword.sessionEnv = string(m[1]) + "=" + string(m[2])
+	return nil
+}
+
+// onepasswordVersion returns the version of the 1Password CLI.
+func (c *Config) onepasswordVersion() (*semver.Version, error) {
+	c.Onepassword.versionOnce.Do(func() {
+		name := c.Onepassword.Command
+		cmd := exec.Command(name, "--version")
+		output, err := c.mutator.IdempotentCmdOutput(cmd)
+		if err != nil {
+			c.Onepassword.versionErr = fmt.Errorf("onepassword: %s --version: %w", name, err)
+			return
+		}
+		m := onepasswordVersionRegexp.FindSubmatch(output)
+		if m == nil {
+			c.Onepassword.versionErr = fmt.Errorf("onepassword: could not extract version from %q", output)
+			return
+		}
+		c.Onepassword.version, c.Onepassword.versionErr = semver.NewVersion(string(m[1]))
+	})
+	return c.Onepassword.version, c.Onepassword.versionErr
+}
+
+// onepasswordSelectionArgs returns the 1Password CLI arguments to select the
+// vault and account given in args.
+func onepasswordSelectionArgs(args []string) []string {
+	if len(args) > 2 {
+		panic(fmt.Errorf("onepassword: expected at most 2 arguments (vault and account), got %d", len(args)))
+	}
+	var result []string
+	if len(args) > 0 && args[0] != "" {
+		result = append(result, "--vault", args[0])
+	}
+	if len(args) > 1 && args[1] != "" {
+		result = append(result, "--account", args[1])
+	}
+	return result
 }
@@ -0,0 +1,124 @@
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestOnepassword(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		version          string
+		item             string
+		expectedEvidence []string
+	}{
+		{
+			name:    "v1",
+			version: "1.12.2",
+			item: `{"uuid":"v1","details":{` +
+				`"fields":[{"designation":"username","name":"username","type":"T","value":"user"},{"designation":"password","name":"password","type":"P","value":"pass"}],` +
+				`"sections":[{"name":"section","title":"","fields":[{"k":"string","n":"field","t":"API key","v":"key"}]}]}}`,
+			expectedEvidence: []string{
+				"--version",
+				"get account",
+				"get account",
+				"signin",
+				"get document v1",
+				"get item v1 --vault vault",
+				"get account",
+			},
+		},
+		{
+			name:    "v2",
+			version: "2.0.0",
+			item: `{"id":"v2","fields":[` +
+				`{"id":"username","type":"STRING","purpose":"USERNAME","label":"username","value":"user"},` +
+				`{"id":"password","type":"CONCEALED","purpose":"PASSWORD","label":"password","value":"pass"},` +
+				`{"id":"field","section":{"id":"section"},"type":"STRING","label":"API key","value":"key"}]}`,
+			expectedEvidence: []string{
+				"--version",
+				"account get --format json",
+				"account get --format json",
+				"signin",
+				"document get v2",
+				"item get v2 --vault vault --format json",
+				"account get --format json",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir, err := ioutil.TempDir("", "chezmoi")
+			require.NoError(t, err)
+			defer func() {
+				require.NoError(t, os.RemoveAll(tempDir))
+			}()
+			op := filepath.Join(tempDir, "op")
+			evidence := filepath.Join(tempDir, "evidence")
+			item := filepath.Join(tempDir, "item.json")
+			require.NoError(t, ioutil.WriteFile(item, []byte(tc.item), 0o644))
+			// The fake op requires the session token in the environment and
+			// fails if it is passed as an argument.
+			require.NoError(t, ioutil.WriteFile(op, []byte(strings.Join([]string{
+				"#!/bin/sh",
+				"echo \"$*\" >>" + evidence,
+				"case \"$1\" in",
+				"--version) echo " + tc.version + "; exit 0 ;;",
+				"signin) echo 'export OP_SESSION_my=\"session-token\"'; exit 0 ;;",
+				"esac",
+				"case \"$*\" in",
+				"*session-token*) exit 1 ;;",
+				"esac",
+				"[ \"$OP_SESSION_my\" = session-token ] || exit 1",
+				"case \"$1 $2\" in",
+				"\"get account\"|\"account get\") echo '{}' ;;",
+				"\"get document\"|\"document get\") echo \"$3\" ;;",
+				"\"get item\"|\"item get\") cat " + item + " ;;",
+				"esac",
+			}, "\n")), 0o755))
+
+			c := newConfig(
+				withMutator(chezmoi.NullMutator{}),
+			)
+			c.Onepassword.Command = op
+
+			assert.False(t, c.onepasswordSignedIn())
+			assert.Equal(t, tc.name+"\n", c.onepasswordDocumentFunc(tc.name))
+			detailsFields := c.onepasswordDetailsFieldsFunc(tc.name, "vault")
+			assert.Len(t, detailsFields, 2)
+			assert.Equal(t, "user", detailsFields["username"].(map[string]interface{})["value"])
+			assert.Equal(t, "pass", detailsFields["password"].(map[string]interface{})["value"])
+			itemFields := c.onepasswordItemFieldsFunc(tc.name, "vault")
+			assert.Len(t, itemFields, 1)
+			assert.Contains(t, itemFields, "API key")
+			assert.True(t, c.onepasswordSignedIn())
+
+			// The user is only signed in once and items are only fetched once.
+			actual, err := ioutil.ReadFile(evidence)
+			require.NoError(t, err)
+			assert.Equal(t, strings.Join(tc.expectedEvidence, "\n")+"\n", string(actual))
+
+			// The session token is redacted.
+			assert.Equal(t, chezmoi.Redacted, c.redactor.RedactString("session-token"))
+		})
+	}
+}
+
+func TestOnepasswordSelectionArgs(t *testing.T) {
+	assert.Equal(t, []string(nil), onepasswordSelectionArgs(nil))
+	assert.Equal(t, []string{"--vault", "vault"}, onepasswordSelectionArgs([]string{"vault"}))
+	assert.Equal(t, []string{"--account", "account"}, onepasswordSelectionArgs([]string{"", "account"}))
+	assert.Equal(t, []string{"--vault", "vault", "--account", "account"}, onepasswordSelectionArgs([]string{"vault", "account"}))
+	assert.Panics(t, func() {
+		onepasswordSelectionArgs([]string{"vault", "account", "extra"})
+	})
+}
@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
+	"os"
 	"os/exec"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -18,39 +22,131 @@ var passCmd = &cobra.Command{
 }
 
 type passCmdConfig struct {
-	Command string
+	Command  string
+	CacheTTL time.Duration
+	StoreDir string
+	Stores   map[string]string
 }
 
-var passCache = make(map[string]string)
+var passCache = make(map[string][]byte)
 
 func init() {
 	secretCmd.AddCommand(passCmd)
 
 	config.Pass.Command = "pass"
-	config.addTemplateFunc("pass", config.passFunc)
+	config.addSecretTemplateFunc("pass", config.passFunc)
+	config.addSecretTemplateFunc("passFields", config.passFieldsFunc)
+	config.addSecretTemplateFunc("passRaw", config.passRawFunc)
 }
 
 func (c *Config) runSecretPassCmd(cmd *cobra.Command, args []string) error {
-	return c.run("", c.Pass.Command, args...)
+	execCmd := c.newPassCmd(c.Pass.StoreDir, args...)
+	execCmd.Stdin = c.Stdin
+	execCmd.Stdout = c.Stdout
+	execCmd.Stderr = c.Stderr
+	return c.mutator.RunCmd(execCmd)
 }
 
-func (c *Config) passFunc(id string) string {
-	if s, ok := passCache[id]; ok {
-		return s
+// passFunc returns the password, the first line of the entry id, in the
+// optional store.
+func (c *Config) passFunc(id string, store ...string) string {
+	return string(firstLine(c.passOutput("pass", id, store)))
+}
+
+// passFieldsFunc returns the key: value fields of the entry id, following the
+// password, in the optional store.
+func (c *Config) passFieldsFunc(id string, store ...string) map[string]string {
+	return parsePassFields(c.passOutput("passFields", id, store))
+}
+
+// passRawFunc returns the entire entry id in the optional store.
+func (c *Config) passRawFunc(id string, store ...string) string {
+	return string(c.passOutput("passRaw", id, store))
+}
+
+// passOutput returns the output of pass show id in the optional store. funcName
+// is the name of the calling template function, used in errors.
+func (c *Config) passOutput(funcName, id string, store []string) []byte {
+	storeDir, err := c.passStoreDir(store)
+	if err != nil {
+		panic(fmt.Errorf("%s: %w", funcName, err))
+	}
+	key := storeDir + "\x00" + id
+	if output, ok := passCache[key]; ok {
+		return output
 	}
 	name := c.Pass.Command
 	args := []string{"show", id}
-	cmd := exec.Command(name, args...)
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	cacheKeyArgs := append([]string{name}, args...)
+	if storeDir != "" {
+		cacheKeyArgs = append([]string{"PASSWORD_STORE_DIR=" + storeDir}, cacheKeyArgs...)
+	}
+	output, err := c.cachedSecretOutput(c.Pass.CacheTTL, cacheKeyArgs, func() ([]byte, error) {
+		return c.mutator.IdempotentCmdOutput(c.newPassCmd(storeDir, args...))
+	})
 	if err != nil {
-		panic(fmt.Errorf("pass: %s %s: %w", name, chezmoi.ShellQuoteArgs(args), err))
+		panic(fmt.Errorf("%s: %s %s: %w", funcName, name, chezmoi.ShellQuoteArgs(args), err))
+	}
+	passCache[key] = output
+	return output
+}
+
+// passStoreDir returns the password store directory for store, which is either
+// empty, for the default store, or the name of a store in c.Pass.Stores. Store
+// names are case-insensitive because the keys of c.Pass.Stores are lowercased
+// when the config file is read.
+func (c *Config) passStoreDir(store []string) (string, error) {
+	switch len(store) {
+	case 0:
+		return c.Pass.StoreDir, nil
+	case 1:
+		storeDir, ok := c.Pass.Stores[strings.ToLower(store[0])]
+		if !ok {
+			return "", fmt.Errorf("%s: unknown store", store[0])
+		}
+		return storeDir, nil
+	default:
+		return "", fmt.Errorf("expected 1 or 2 arguments, got %d", len(store)+1)
+	}
+}
+
+// newPassCmd returns a command that runs pass with args in storeDir. If
+// storeDir is empty then pass uses its default store.
+func (c *Config) newPassCmd(storeDir string, args ...string) *exec.Cmd {
+	cmd := exec.Command(c.Pass.Command, args...)
+	if storeDir != "" {
+		cmd.Env = append(os.Environ(), "PASSWORD_STORE_DIR="+storeDir)
 	}
-	var password string
+	return cmd
+}
+
+// firstLine returns the first line of output, without the trailing newline.
+func firstLine(output []byte) []byte {
 	if index := bytes.IndexByte(output, '\n'); index != -1 {
-		password = string(output[:index])
-	} else {
-		password = string(output)
+		return output[:index]
+	}
+	return output
+}
+
+// parsePassFields returns the fields in output, which follows the convention of
+// the password on the first line followed by key: value lines. Other lines are
+// ignored.
+func parsePassFields(output []byte) map[string]string {
+	fields := make(map[string]string)
+	s := bufio.NewScanner(bytes.NewReader(output))
+	// Skip the password.
+	s.Scan()
+	for s.Scan() {
+		line := s.Text()
+		index := strings.IndexByte(line, ':')
+		if index == -1 {
+			continue
+		}
+		key := strings.TrimSpace(line[:index])
+		if key == "" {
+			continue
+		}
+		fields[key] = strings.TrimSpace(line[index+1:])
 	}
-	passCache[id] = password
-	return passCache[id]
+	return fields
 }
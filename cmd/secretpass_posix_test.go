@@ -0,0 +1,154 @@
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestPass(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+	pass := filepath.Join(tempDir, "pass")
+	evidence := filepath.Join(tempDir, "evidence")
+	require.NoError(t, ioutil.WriteFile(pass, []byte(strings.Join([]string{
+		"#!/bin/sh",
+		"echo \"$PASSWORD_STORE_DIR $*\" >>" + evidence,
+		"case \"$PASSWORD_STORE_DIR $*\" in",
+		"\"/home/user/.password-store show example.com\")",
+		"  echo 'password'",
+		"  echo 'login: user'",
+		"  echo 'url: https://example.com/login'",
+		"  echo 'a note'",
+		"  ;;",
+		"\"/home/user/.password-store-work show example.com\")",
+		"  echo 'work-password'",
+		"  ;;",
+		"*)",
+		"  exit 1",
+		"  ;;",
+		"esac",
+	}, "\n")), 0o755))
+
+	c := newConfig(
+		withMutator(chezmoi.NullMutator{}),
+	)
+	c.Pass.Command = pass
+	c.Pass.StoreDir = "/home/user/.password-store"
+	c.Pass.Stores = map[string]string{
+		"work": "/home/user/.password-store-work",
+	}
+
+	assert.Equal(t, "password", c.passFunc("example.com"))
+	assert.Equal(t, map[string]string{
+		"login": "user",
+		"url":   "https://example.com/login",
+	}, c.passFieldsFunc("example.com"))
+	assert.Equal(t, "password\nlogin: user\nurl: https://example.com/login\na note\n", c.passRawFunc("example.com"))
+	assert.Equal(t, "work-password", c.passFunc("example.com", "work"))
+	assert.Equal(t, map[string]string{}, c.passFieldsFunc("example.com", "work"))
+	assert.Panics(t, func() {
+		c.passFunc("example.com", "home")
+	})
+
+	// Each entry is only read once.
+	actual, err := ioutil.ReadFile(evidence)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"/home/user/.password-store show example.com",
+		"/home/user/.password-store-work show example.com",
+	}, "\n")+"\n", string(actual))
+}
+
+func TestPassStoresConfigFile(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+	configFile := filepath.Join(tempDir, "chezmoi.toml")
+	require.NoError(t, ioutil.WriteFile(configFile, []byte(strings.Join([]string{
+		"[pass.stores]",
+		"  Work = \"/home/user/.password-store-work\"",
+	}, "\n")), 0o644))
+
+	c := newConfig()
+	require.NoError(t, readTestConfigFile(c, configFile))
+
+	for _, store := range []string{"Work", "work"} {
+		storeDir, err := c.passStoreDir([]string{store})
+		require.NoError(t, err)
+		assert.Equal(t, "/home/user/.password-store-work", storeDir)
+	}
+	_, err = c.passStoreDir([]string{"home"})
+	assert.Error(t, err)
+}
+
+func TestSecretPassCmdStoreDir(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+	pass := filepath.Join(tempDir, "pass")
+	require.NoError(t, ioutil.WriteFile(pass, []byte(strings.Join([]string{
+		"#!/bin/sh",
+		"echo \"$PASSWORD_STORE_DIR $*\"",
+		"echo 'error' >&2",
+	}, "\n")), 0o755))
+
+	defer func(storeDir string, ok bool) {
+		if ok {
+			require.NoError(t, os.Setenv("PASSWORD_STORE_DIR", storeDir))
+		} else {
+			require.NoError(t, os.Unsetenv("PASSWORD_STORE_DIR"))
+		}
+	}(os.LookupEnv("PASSWORD_STORE_DIR"))
+	require.NoError(t, os.Setenv("PASSWORD_STORE_DIR", "/home/user/.password-store-env"))
+
+	for _, tc := range []struct {
+		name           string
+		storeDir       string
+		expectedStdout string
+	}{
+		{
+			name:           "default",
+			expectedStdout: "/home/user/.password-store-env ls\n",
+		},
+		{
+			name:           "store_dir",
+			storeDir:       "/home/user/.password-store",
+			expectedStdout: "/home/user/.password-store ls\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			c := newConfig(
+				withMutator(chezmoi.NewFSMutator(vfs.OSFS)),
+				withStdout(stdout),
+				withStderr(stderr),
+			)
+			c.Pass.Command = pass
+			c.Pass.StoreDir = tc.storeDir
+
+			require.NoError(t, c.runSecretPassCmd(nil, []string{"ls"}))
+			assert.Equal(t, tc.expectedStdout, stdout.String())
+			assert.Equal(t, "error\n", stderr.String())
+		})
+	}
+}
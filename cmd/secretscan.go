@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	"github.com/twpayne/chezmoi/internal/git"
+)
+
+// Actions taken when possible secrets are found in files added to the source
+// state.
+const (
+	secretsError   = "error"
+	secretsIgnore  = "ignore"
+	secretsWarning = "warning"
+)
+
+const secretsSuggestion = "add it with --encrypt or use a template with a secret manager function"
+
+// getSecretsFoundFunc returns the function called when possible secrets are
+// found in a file, according to c.Secrets.
+func (c *Config) getSecretsFoundFunc() (func(string, []chezmoi.SecretFinding) error, error) {
+	switch c.Secrets {
+	case secretsError:
+		return func(name string, findings []chezmoi.SecretFinding) error {
+			finding := findings[0]
+			return fmt.Errorf("%s: line %d: possible %s, %s", c.secretsFileName(name), finding.Line, finding.Description, secretsSuggestion)
+		}, nil
+	case secretsIgnore:
+		return nil, nil
+	case secretsWarning:
+		return func(name string, findings []chezmoi.SecretFinding) error {
+			name = c.secretsFileName(name)
+			for _, finding := range findings {
+				fmt.Fprintf(c.Stderr, "warning: %s: line %d: possible %s\n", name, finding.Line, finding.Description)
+			}
+			fmt.Fprintf(c.Stderr, "warning: %s: %s\n", name, secretsSuggestion)
+			return nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("%s: invalid secrets value", c.Secrets)
+	}
+}
+
+// checkSourceSecrets checks the files added or modified in the source directory
+// according to status for possible secrets.
+func (c *Config) checkSourceSecrets(status interface{}) error {
+	gitStatus, ok := status.(*git.Status)
+	if !ok {
+		return nil
+	}
+	secretsFound, err := c.getSecretsFoundFunc()
+	if err != nil || secretsFound == nil {
+		return err
+	}
+	var paths []string
+	for _, s := range gitStatus.Ordinary {
+		paths = append(paths, s.Path)
+	}
+	for _, s := range gitStatus.RenamedOrCopied {
+		paths = append(paths, s.Path)
+	}
+	for _, s := range gitStatus.Untracked {
+		paths = append(paths, s.Path)
+	}
+	reportedSourceNames, err := c.getSecretsReportedSourceNames()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		base := filepath.Base(path)
+		if base == chezmoi.ArchiveName || reportedSourceNames[path] {
+			continue
+		}
+		if fa := chezmoi.ParseFileAttributes(base); fa.Encrypted || fa.Sops {
+			continue
+		}
+		contents, err := c.fs.ReadFile(filepath.Join(c.SourceDir, path))
+		switch {
+		case os.IsNotExist(err):
+			continue
+		case err != nil:
+			return err
+		}
+		if findings := chezmoi.FindSecrets(contents); len(findings) > 0 {
+			if err := secretsFound(filepath.Join(c.SourceDir, path), findings); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getSecretsReportedSourceNames returns the source names of the targets whose
+// possible secrets have already been reported, for example by add.
+func (c *Config) getSecretsReportedSourceNames() (map[string]bool, error) {
+	if len(c.secretsReported) == 0 {
+		return nil, nil
+	}
+	ts, err := c.getTargetState(&chezmoi.PopulateOptions{
+		ExecuteTemplates: false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sourceNames := make(map[string]bool)
+	for _, entry := range ts.AllEntries() {
+		if c.secretsReported[entry.TargetName()] {
+			sourceNames[entry.SourceName()] = true
+		}
+	}
+	return sourceNames, nil
+}
+
+// secretsFileName returns the file name to report in warnings and errors about
+// possible secrets. Relative names are relative to the destination directory.
+func (c *Config) secretsFileName(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(c.DestDir, name)
+}
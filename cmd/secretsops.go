@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var sopsCmd = &cobra.Command{
+	Use:     "sops [args...]",
+	Short:   "Execute sops",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runSopsCmd,
+}
+
+var (
+	sopsDecryptCache     = make(map[string]string)
+	sopsDecryptDataCache = make(map[string]interface{})
+)
+
+func init() {
+	config.addSecretTemplateFunc("sopsDecrypt", config.sopsDecryptFunc)
+	config.addSecretTemplateFunc("sopsDecryptData", config.sopsDecryptDataFunc)
+
+	secretCmd.AddCommand(sopsCmd)
+}
+
+func (c *Config) runSopsCmd(cmd *cobra.Command, args []string) error {
+	return c.run("", c.Sops.Command, args...)
+}
+
+// sopsDecryptFunc returns the decrypted contents of the sops-encrypted file
+// filename. Relative filenames are relative to the source directory.
+func (c *Config) sopsDecryptFunc(filename string) string {
+	filename = c.sopsFilename(filename)
+	if plaintext, ok := sopsDecryptCache[filename]; ok {
+		return plaintext
+	}
+	ciphertext, err := c.fs.ReadFile(filename)
+	if err != nil {
+		panic(fmt.Errorf("sopsDecrypt: %w", err))
+	}
+	plaintext, err := c.Sops.Decrypt(filename, ciphertext)
+	if err != nil {
+		panic(fmt.Errorf("sopsDecrypt: %s: %w", filename, err))
+	}
+	sopsDecryptCache[filename] = string(plaintext)
+	return string(plaintext)
+}
+
+// sopsDecryptDataFunc returns the decrypted contents of the sops-encrypted
+// file filename as structured data. Relative filenames are relative to the
+// source directory.
+func (c *Config) sopsDecryptDataFunc(filename string) interface{} {
+	filename = c.sopsFilename(filename)
+	if data, ok := sopsDecryptDataCache[filename]; ok {
+		return data
+	}
+	ciphertext, err := c.fs.ReadFile(filename)
+	if err != nil {
+		panic(fmt.Errorf("sopsDecryptData: %w", err))
+	}
+	plaintext, err := c.Sops.DecryptOutputType(filename, ciphertext, "json")
+	if err != nil {
+		panic(fmt.Errorf("sopsDecryptData: %s: %w", filename, err))
+	}
+	var data interface{}
+	if err := json.Unmarshal(plaintext, &data); err != nil {
+		panic(fmt.Errorf("sopsDecryptData: %s: %w", filename, err))
+	}
+	sopsDecryptDataCache[filename] = data
+	return data
+}
+
+func (c *Config) sopsFilename(filename string) string {
+	if filepath.IsAbs(filename) {
+		return filename
+	}
+	return filepath.Join(c.SourceDir, filename)
+}
@@ -0,0 +1,82 @@
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestSops(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+	sops := filepath.Join(tempDir, "sops")
+	evidence := filepath.Join(tempDir, "evidence")
+	require.NoError(t, ioutil.WriteFile(sops, []byte(strings.Join([]string{
+		"#!/bin/sh",
+		"for file; do :; done",
+		"echo \"$* $(basename \"$file\")\" >>" + evidence,
+		"if [ \"$1\" = --output-type ]; then",
+		"  echo '{\"password\":\"secret\"}'",
+		"else",
+		"  sed 's/ENC\\[\\(.*\\)\\]/\\1/' \"$file\"",
+		"fi",
+	}, "\n")), 0o755))
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			"sops_dot_secrets.yaml.tmpl": "password: ENC[{{ \"secret\" }}]\n",
+			".secrets.json":              "{\"password\":\"ENC[secret]\"}\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(fs)
+	c.Sops.Command = sops
+	assert.NoError(t, c.runApplyCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.secrets.yaml",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("password: secret\n"),
+		),
+	)
+
+	assert.Equal(t, "{\"password\":\"secret\"}\n", c.sopsDecryptFunc(".secrets.json"))
+	assert.Equal(t, map[string]interface{}{"password": "secret"}, c.sopsDecryptDataFunc(".secrets.json"))
+
+	// sops is passed the file with its target extension.
+	actual, err := ioutil.ReadFile(evidence)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(actual)), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasSuffix(lines[0], " .secrets.yaml"))
+	assert.True(t, strings.HasPrefix(lines[2], "--output-type json --decrypt "))
+}
+
+func TestSopsAddRefused(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.secrets.yaml":                              "password: secret\n",
+		"/home/user/.local/share/chezmoi/sops_dot_secrets.yaml": "password: ENC[secret]\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(fs)
+	assert.Error(t, c.runAddCmd(nil, []string{"/home/user/.secrets.yaml"}))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/sops_dot_secrets.yaml",
+			vfst.TestContentsString("password: ENC[secret]\n"),
+		),
+	)
+}
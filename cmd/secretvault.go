@@ -1,10 +1,15 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -19,14 +24,23 @@ var vaultCmd = &cobra.Command{
 }
 
 type vaultCmdConfig struct {
-	Command string
+	Command   string
+	Address   string
+	Namespace string
+	Mount     string
+	CacheTTL  time.Duration
+	WriteTTL  time.Duration
 }
 
 var vaultCache = make(map[string]interface{})
 
 func init() {
 	config.Vault.Command = "vault"
-	config.addTemplateFunc("vault", config.vaultFunc)
+	config.Vault.WriteTTL = 24 * time.Hour
+	config.addSecretTemplateFunc("vault", config.vaultFunc)
+	config.addSecretTemplateFunc("vaultField", config.vaultFieldFunc)
+	config.addSecretTemplateFunc("vaultRead", config.vaultReadFunc)
+	config.addSecretTemplateFunc("vaultWrite", config.vaultWriteFunc)
 
 	secretCmd.AddCommand(vaultCmd)
 }
@@ -35,23 +49,122 @@ func (c *Config) runVaultCmd(cmd *cobra.Command, args []string) error {
 	return c.run("", c.Vault.Command, args...)
 }
 
-func (c *Config) vaultFunc(key string) interface{} {
+// vaultFunc returns the secret key from the KV secrets engine, optionally at a
+// specific version.
+func (c *Config) vaultFunc(key string, version ...int) interface{} {
+	args := []string{"kv", "get", "-format=json"}
+	args = append(args, c.vaultGlobalArgs()...)
+	if c.Vault.Mount != "" {
+		args = append(args, "-mount="+c.Vault.Mount)
+	}
+	switch len(version) {
+	case 0:
+	case 1:
+		args = append(args, "-version="+strconv.Itoa(version[0]))
+	default:
+		panic(fmt.Errorf("vault: expected at most 1 version, got %d", len(version)))
+	}
+	return c.vaultJSON(append(args, key))
+}
+
+// vaultFieldFunc returns the value of field in the secret key from the KV
+// secrets engine. Both version 1 and version 2 of the KV secrets engine are
+// supported.
+func (c *Config) vaultFieldFunc(key, field string, version ...int) interface{} {
+	secret, _ := c.vaultFunc(key, version...).(map[string]interface{})
+	data, _ := secret["data"].(map[string]interface{})
+	if _, ok := data["metadata"]; ok {
+		// Version 2 of the KV secrets engine nests the data with its metadata.
+		data, _ = data["data"].(map[string]interface{})
+	}
+	value, ok := data[field]
+	if !ok {
+		panic(fmt.Errorf("vault: %s: field %s not found", key, field))
+	}
+	return value
+}
+
+// vaultReadFunc returns the secret at path, which can be in any secrets
+// engine. args are passed to vault read as key=value pairs.
+func (c *Config) vaultReadFunc(path string, args ...string) interface{} {
+	return c.vaultJSON(append(append(append([]string{"read", "-format=json"}, c.vaultGlobalArgs()...), path), args...))
+}
+
+// vaultWriteFunc returns the output of writing args to path, which is needed
+// to generate some dynamic secrets like certificates. Writing changes the
+// state of the Vault server, for example by issuing a new certificate, so the
+// output is cached in the persistent state for c.Vault.WriteTTL and reused
+// until it expires. When chezmoi is not making changes, for example with
+// --dry-run or in chezmoi diff, the cached output is used and it is an error
+// if there is none.
+func (c *Config) vaultWriteFunc(path string, args ...string) interface{} {
+	cmdArgs := append(append(append([]string{"write", "-format=json"}, c.vaultGlobalArgs()...), path), args...)
+	key := strings.Join(cmdArgs, "\x00")
+	if data, ok := vaultCache[key]; ok {
+		return data
+	}
+	name := c.Vault.Command
+	output, err := c.cachedSecretOutput(c.Vault.WriteTTL, append([]string{name}, cmdArgs...), func() ([]byte, error) {
+		stdout := &bytes.Buffer{}
+		cmd := exec.Command(name, cmdArgs...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = stdout
+		cmd.Stderr = os.Stderr
+		if err := c.mutator.RunCmd(cmd); err != nil {
+			return stdout.Bytes(), err
+		}
+		if cmd.ProcessState == nil {
+			return nil, errors.New("not run because chezmoi is not making changes and there is no cached output")
+		}
+		return stdout.Bytes(), nil
+	})
+	if err != nil {
+		panic(fmt.Errorf("vault: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(cmdArgs), err, output))
+	}
+	data := c.vaultParseJSON(cmdArgs, output)
+	vaultCache[key] = data
+	return data
+}
+
+// vaultGlobalArgs returns the arguments for the configured Vault server and
+// namespace.
+func (c *Config) vaultGlobalArgs() []string {
+	var args []string
+	if c.Vault.Address != "" {
+		args = append(args, "-address="+c.Vault.Address)
+	}
+	if c.Vault.Namespace != "" {
+		args = append(args, "-namespace="+c.Vault.Namespace)
+	}
+	return args
+}
+
+// vaultJSON returns the output of the Vault CLI with args parsed as JSON.
+func (c *Config) vaultJSON(args []string) interface{} {
+	key := strings.Join(args, "\x00")
 	if data, ok := vaultCache[key]; ok {
 		return data
 	}
 	name := c.Vault.Command
-	args := []string{"kv", "get", "-format=json", key}
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.cachedSecretOutput(c.Vault.CacheTTL, append([]string{name}, args...), func() ([]byte, error) {
+		cmd := exec.Command(name, args...)
+		cmd.Stdin = os.Stdin
+		cmd.Stderr = os.Stderr
+		return c.mutator.IdempotentCmdOutput(cmd)
+	})
 	if err != nil {
 		panic(fmt.Errorf("vault: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
 	}
+	data := c.vaultParseJSON(args, output)
+	vaultCache[key] = data
+	return data
+}
+
+// vaultParseJSON returns output of the Vault CLI with args parsed as JSON.
+func (c *Config) vaultParseJSON(args []string, output []byte) interface{} {
 	var data interface{}
 	if err := json.Unmarshal(output, &data); err != nil {
-		panic(fmt.Errorf("vault: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
+		panic(fmt.Errorf("vault: %s %s: %w\n%s", c.Vault.Command, chezmoi.ShellQuoteArgs(args), err, output))
 	}
-	vaultCache[key] = data
 	return data
 }
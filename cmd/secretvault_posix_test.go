@@ -0,0 +1,142 @@
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestVault(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+	vault := filepath.Join(tempDir, "vault")
+	evidence := filepath.Join(tempDir, "evidence")
+	require.NoError(t, ioutil.WriteFile(vault, []byte(strings.Join([]string{
+		"#!/bin/sh",
+		"echo \"$*\" >>" + evidence,
+		"case \"$*\" in",
+		"\"kv get -format=json -address=https://vault.example.com -namespace=team -mount=secret kv2\")",
+		"  echo '{\"data\":{\"data\":{\"password\":\"v2\"},\"metadata\":{\"version\":2}}}'",
+		"  ;;",
+		"\"kv get -format=json -address=https://vault.example.com -namespace=team -mount=secret -version=1 kv2\")",
+		"  echo '{\"data\":{\"data\":{\"password\":\"v1\"},\"metadata\":{\"version\":1}}}'",
+		"  ;;",
+		"\"kv get -format=json -address=https://vault.example.com -namespace=team -mount=secret kv1\")",
+		"  echo '{\"data\":{\"password\":\"kv1\"}}'",
+		"  ;;",
+		"\"read -format=json -address=https://vault.example.com -namespace=team database/creds/role\")",
+		"  echo '{\"data\":{\"username\":\"user\",\"password\":\"pass\"}}'",
+		"  ;;",
+		"*)",
+		"  exit 1",
+		"  ;;",
+		"esac",
+	}, "\n")), 0o755))
+
+	c := newConfig(
+		withMutator(chezmoi.NullMutator{}),
+	)
+	c.Vault.Command = vault
+	c.Vault.Address = "https://vault.example.com"
+	c.Vault.Namespace = "team"
+	c.Vault.Mount = "secret"
+
+	assert.Equal(t, "v2", c.vaultFieldFunc("kv2", "password"))
+	assert.Equal(t, "v1", c.vaultFieldFunc("kv2", "password", 1))
+	assert.Equal(t, "kv1", c.vaultFieldFunc("kv1", "password"))
+	assert.Equal(t, map[string]interface{}{
+		"data": map[string]interface{}{
+			"password": "kv1",
+		},
+	}, c.vaultFunc("kv1"))
+	assert.Panics(t, func() {
+		c.vaultFieldFunc("kv1", "username")
+	})
+	assert.Equal(t, map[string]interface{}{
+		"data": map[string]interface{}{
+			"username": "user",
+			"password": "pass",
+		},
+	}, c.vaultReadFunc("database/creds/role"))
+
+	// Without cached output, vaultWrite is not run by a read-only mutator.
+	assert.Panics(t, func() {
+		c.vaultWriteFunc("pki/issue/role", "common_name=example.com")
+	})
+
+	// Each secret is only read once.
+	actual, err := ioutil.ReadFile(evidence)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"kv get -format=json -address=https://vault.example.com -namespace=team -mount=secret kv2",
+		"kv get -format=json -address=https://vault.example.com -namespace=team -mount=secret -version=1 kv2",
+		"kv get -format=json -address=https://vault.example.com -namespace=team -mount=secret kv1",
+		"read -format=json -address=https://vault.example.com -namespace=team database/creds/role",
+	}, "\n")+"\n", string(actual))
+}
+
+func TestVaultWrite(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+	vault := filepath.Join(tempDir, "vault")
+	evidence := filepath.Join(tempDir, "evidence")
+	require.NoError(t, ioutil.WriteFile(vault, []byte(strings.Join([]string{
+		"#!/bin/sh",
+		"echo \"$*\" >>" + evidence,
+		"echo '{\"data\":{\"certificate\":\"cert\"}}'",
+	}, "\n")), 0o755))
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0o755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	newVaultConfig := func(mutator chezmoi.Mutator) *Config {
+		vaultCache = make(map[string]interface{})
+		c := newTestConfig(fs, withMutator(mutator))
+		c.Vault.Command = vault
+		c.Vault.WriteTTL = time.Hour
+		return c
+	}
+	expected := map[string]interface{}{
+		"data": map[string]interface{}{
+			"certificate": "cert",
+		},
+	}
+
+	// Without cached output, vaultWrite is not run by a read-only mutator.
+	assert.Panics(t, func() {
+		newVaultConfig(chezmoi.NullMutator{}).vaultWriteFunc("pki/issue/role", "common_name=example.com")
+	})
+
+	c := newVaultConfig(chezmoi.NewFSMutator(fs))
+	assert.Equal(t, expected, c.vaultWriteFunc("pki/issue/role", "common_name=example.com"))
+	assert.Equal(t, expected, c.vaultWriteFunc("pki/issue/role", "common_name=example.com"))
+
+	// Later invocations, including those that do not make changes, reuse the
+	// cached output until it expires.
+	assert.Equal(t, expected, newVaultConfig(chezmoi.NullMutator{}).vaultWriteFunc("pki/issue/role", "common_name=example.com"))
+	assert.Equal(t, expected, newVaultConfig(chezmoi.NewFSMutator(fs)).vaultWriteFunc("pki/issue/role", "common_name=example.com"))
+
+	actual, err := ioutil.ReadFile(evidence)
+	require.NoError(t, err)
+	assert.Equal(t, "write -format=json pki/issue/role common_name=example.com\n", string(actual))
+}
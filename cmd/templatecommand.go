@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+// Output formats of template commands.
+const (
+	templateCommandFormatJSON = "json"
+	templateCommandFormatRaw  = "raw"
+	templateCommandFormatTrim = "trim"
+	templateCommandFormatYAML = "yaml"
+)
+
+var templateCommandCache = make(map[string]interface{})
+
+type templateCommandConfig struct {
+	Command  string
+	Args     []string
+	Format   string
+	Secret   bool
+	CacheTTL time.Duration
+}
+
+// addTemplateCommandFuncs adds a template function for each template command.
+// Template commands that have already been added are skipped.
+func (c *Config) addTemplateCommandFuncs() error {
+	names := make([]string, 0, len(c.TemplateCommands))
+	for name := range c.TemplateCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if c.templateCmdFuncs[name] {
+			continue
+		}
+		f, err := c.newTemplateCommandFunc(name, c.TemplateCommands[name])
+		if err != nil {
+			return fmt.Errorf("templateCommands.%s: %w", name, err)
+		}
+		if c.TemplateCommands[name].Secret {
+			c.addSecretTemplateFunc(name, f)
+		} else {
+			c.addTemplateFunc(name, f)
+		}
+		if c.templateCmdFuncs == nil {
+			c.templateCmdFuncs = make(map[string]bool)
+		}
+		c.templateCmdFuncs[name] = true
+	}
+	return nil
+}
+
+// newTemplateCommandFunc returns the template function for the template
+// command name. Each of tc.Args is a template which is executed with the
+// function's arguments as data. If tc.Args is empty then the function's
+// arguments are passed to the command directly. The command is run at most once
+// per invocation for each set of arguments and, if tc.CacheTTL is set, its
+// output is cached in the persistent state.
+func (c *Config) newTemplateCommandFunc(name string, tc templateCommandConfig) (func(...string) interface{}, error) {
+	if !identifierRegexp.MatchString(name) {
+		return nil, fmt.Errorf("invalid name")
+	}
+	if _, ok := c.templateFuncs[name]; ok {
+		return nil, fmt.Errorf("template function already defined")
+	}
+	if tc.Command == "" {
+		return nil, fmt.Errorf("command not set")
+	}
+	format := strings.ToLower(tc.Format)
+	switch format {
+	case "":
+		format = templateCommandFormatTrim
+	case templateCommandFormatJSON, templateCommandFormatRaw, templateCommandFormatTrim, templateCommandFormatYAML:
+	case "yml":
+		format = templateCommandFormatYAML
+	default:
+		return nil, fmt.Errorf("%s: unknown format", tc.Format)
+	}
+	argTmpls := make([]*template.Template, 0, len(tc.Args))
+	for i, arg := range tc.Args {
+		argTmpl, err := template.New(fmt.Sprintf("args[%d]", i)).Funcs(sprig.TxtFuncMap()).Parse(arg)
+		if err != nil {
+			return nil, err
+		}
+		argTmpls = append(argTmpls, argTmpl)
+	}
+
+	return func(funcArgs ...string) interface{} {
+		args := funcArgs
+		if len(argTmpls) > 0 {
+			args = make([]string, 0, len(argTmpls))
+			for _, argTmpl := range argTmpls {
+				sb := &strings.Builder{}
+				if err := argTmpl.Execute(sb, funcArgs); err != nil {
+					panic(fmt.Errorf("%s: %w", name, err))
+				}
+				args = append(args, sb.String())
+			}
+		}
+		key := strings.Join(append([]string{name}, args...), "\x00")
+		if value, ok := templateCommandCache[key]; ok {
+			return value
+		}
+		output, err := c.cachedSecretOutput(tc.CacheTTL, append([]string{tc.Command}, args...), func() ([]byte, error) {
+			cmd := exec.Command(tc.Command, args...)
+			cmd.Stdin = c.Stdin
+			cmd.Stderr = c.Stderr
+			return c.mutator.IdempotentCmdOutput(cmd)
+		})
+		if err != nil {
+			panic(fmt.Errorf("%s: %s %s: %w", name, tc.Command, chezmoi.ShellQuoteArgs(args), err))
+		}
+		var value interface{}
+		switch format {
+		case templateCommandFormatRaw:
+			value = string(output)
+		case templateCommandFormatTrim:
+			value = string(bytes.TrimSpace(output))
+		default:
+			value, err = decodeData(format, output)
+			if err != nil {
+				panic(fmt.Errorf("%s: %s %s: %w", name, tc.Command, chezmoi.ShellQuoteArgs(args), err))
+			}
+		}
+		templateCommandCache[key] = value
+		return value
+	}, nil
+}
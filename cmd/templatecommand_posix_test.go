@@ -0,0 +1,259 @@
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestTemplateCommands(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		templateCommands map[string]templateCommandConfig
+		template         string
+		expectedStr      string
+		expectedErr      bool
+	}{
+		{
+			name: "trim",
+			templateCommands: map[string]templateCommandConfig{
+				"tool": {
+					Command: "echo",
+				},
+			},
+			template:    `{{ tool "a" "b" | quote }}`,
+			expectedStr: `"a b"`,
+		},
+		{
+			name: "raw",
+			templateCommands: map[string]templateCommandConfig{
+				"tool": {
+					Command: "echo",
+					Format:  "raw",
+				},
+			},
+			template:    `{{ tool "a" | quote }}`,
+			expectedStr: `"a\n"`,
+		},
+		{
+			name: "json",
+			templateCommands: map[string]templateCommandConfig{
+				"tool": {
+					Command: "echo",
+					Format:  "json",
+				},
+			},
+			template:    `{{ (tool "{\"key\":\"value\"}").key }}`,
+			expectedStr: "value",
+		},
+		{
+			name: "yaml",
+			templateCommands: map[string]templateCommandConfig{
+				"tool": {
+					Command: "echo",
+					Format:  "yaml",
+				},
+			},
+			template:    `{{ (tool "key: value").key }}`,
+			expectedStr: "value",
+		},
+		{
+			name: "args",
+			templateCommands: map[string]templateCommandConfig{
+				"tool": {
+					Command: "echo",
+					Args:    []string{"get", "--field={{ index . 1 }}", "{{ index . 0 | upper }}"},
+				},
+			},
+			template:    `{{ tool "item" "password" }}`,
+			expectedStr: "get --field=password ITEM",
+		},
+		{
+			name: "secret",
+			templateCommands: map[string]templateCommandConfig{
+				"tool": {
+					Command: "echo",
+					Args:    []string{"hunter2-{{ index . 0 }}"},
+					Secret:  true,
+				},
+			},
+			template:    `{{ tool "item" }}`,
+			expectedStr: "hunter2-item",
+		},
+		{
+			name: "command_error",
+			templateCommands: map[string]templateCommandConfig{
+				"tool": {
+					Command: "false",
+				},
+			},
+			template:    `{{ tool }}`,
+			expectedErr: true,
+		},
+		{
+			name: "invalid_output",
+			templateCommands: map[string]templateCommandConfig{
+				"tool": {
+					Command: "echo",
+					Format:  "json",
+				},
+			},
+			template:    `{{ tool "not json" }}`,
+			expectedErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			templateCommandCache = make(map[string]interface{})
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user": &vfst.Dir{Perm: 0o755},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			c := newTestConfig(
+				fs,
+				withTemplateCommands(tc.templateCommands),
+			)
+			require.NoError(t, c.addTemplateCommandFuncs())
+			tmpl, err := template.New(tc.name).Funcs(c.templateFuncs).Parse(tc.template)
+			require.NoError(t, err)
+			sb := &strings.Builder{}
+			err = tmpl.Execute(sb, nil)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedStr, sb.String())
+			if tc.templateCommands["tool"].Secret {
+				assert.Equal(t, chezmoi.Redacted, c.redactor.RedactString(tc.expectedStr))
+			}
+		})
+	}
+}
+
+func TestTemplateCommandsConfigFile(t *testing.T) {
+	templateCommandCache = make(map[string]interface{})
+	tempDir, err := ioutil.TempDir("", "chezmoi")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+	configFile := filepath.Join(tempDir, "chezmoi.toml")
+	require.NoError(t, ioutil.WriteFile(configFile, []byte(strings.Join([]string{
+		"[templateCommands.corpSecret]",
+		"  command = \"echo\"",
+		"  args = [\"{{ index . 0 | upper }}\"]",
+	}, "\n")), 0o644))
+
+	c := newConfig(
+		withMutator(chezmoi.NullMutator{}),
+	)
+	c.configFile = configFile
+	require.NoError(t, readTestConfigFile(c, configFile))
+
+	// Config file keys are converted to lowercase when they are read, so names
+	// that are not lowercase are rejected.
+	assert.Error(t, c.checkConfigFileCommandNames())
+
+	require.NoError(t, ioutil.WriteFile(configFile, []byte(strings.Join([]string{
+		"[templateCommands.corpsecret]",
+		"  command = \"echo\"",
+		"  args = [\"{{ index . 0 | upper }}\"]",
+	}, "\n")), 0o644))
+	require.NoError(t, c.checkConfigFileCommandNames())
+	require.NoError(t, readTestConfigFile(c, configFile))
+	require.NoError(t, c.addTemplateCommandFuncs())
+	// Adding the template commands again skips those already added.
+	require.NoError(t, c.addTemplateCommandFuncs())
+
+	tmpl, err := template.New("lowercase").Funcs(c.templateFuncs).Parse(`{{ corpsecret "github" }}`)
+	require.NoError(t, err)
+	sb := &strings.Builder{}
+	require.NoError(t, tmpl.Execute(sb, nil))
+	assert.Equal(t, "GITHUB", sb.String())
+}
+
+func TestCreateConfigFileTemplateCommands(t *testing.T) {
+	templateCommandCache = make(map[string]interface{})
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi/.chezmoi.toml.tmpl": strings.Join([]string{
+			`[templateCommands.tool]`,
+			`  command = "echo"`,
+		}, "\n"),
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(fs)
+	require.NoError(t, c.createConfigFile())
+
+	tmpl, err := template.New("tool").Funcs(c.templateFuncs).Parse(`{{ tool "value" }}`)
+	require.NoError(t, err)
+	sb := &strings.Builder{}
+	require.NoError(t, tmpl.Execute(sb, nil))
+	assert.Equal(t, "value", sb.String())
+}
+
+func TestCreateConfigFileTemplateCommandsNotLowercase(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi/.chezmoi.toml.tmpl": strings.Join([]string{
+			`[templateCommands.myTool]`,
+			`  command = "echo"`,
+		}, "\n"),
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(fs)
+	assert.Error(t, c.createConfigFile())
+}
+
+func TestTemplateCommandsInvalid(t *testing.T) {
+	for name, templateCommands := range map[string]map[string]templateCommandConfig{
+		"already_defined": {
+			"include": {
+				Command: "echo",
+			},
+		},
+		"invalid_name": {
+			"my-tool": {
+				Command: "echo",
+			},
+		},
+		"no_command": {
+			"tool": {},
+		},
+		"unknown_format": {
+			"tool": {
+				Command: "echo",
+				Format:  "xml",
+			},
+		},
+		"invalid_args": {
+			"tool": {
+				Command: "echo",
+				Args:    []string{"{{"},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := newConfig(
+				withTemplateCommands(templateCommands),
+			)
+			c.addTemplateFunc("include", func() {})
+			assert.Error(t, c.addTemplateCommandFuncs())
+		})
+	}
+}
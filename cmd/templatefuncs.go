@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+	"github.com/pelletier/go-toml"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var outputCache = make(map[string]string)
+
+func init() {
+	config.addTemplateFunc("fromJson", fromJSONFunc)
+	config.addTemplateFunc("fromToml", fromTOMLFunc)
+	config.addTemplateFunc("fromYaml", fromYAMLFunc)
+	config.addTemplateFunc("glob", config.globFunc)
+	config.addTemplateFunc("include", config.includeFunc)
+	config.addTemplateFunc("jsonPath", jsonPathFunc)
+	config.addTemplateFunc("lookPath", config.lookPathFunc)
+	config.addTemplateFunc("output", config.outputFunc)
+	config.addTemplateFunc("stat", config.statFunc)
+	config.addTemplateFunc("toToml", toTOMLFunc)
+	config.addTemplateFunc("toYaml", toYAMLFunc)
+}
+
+func (c *Config) globFunc(pattern string) []string {
+	matches, err := doublestar.GlobOS(c.fs, pattern)
+	if err != nil {
+		panic(fmt.Errorf("glob: %s: %w", pattern, err))
+	}
+	return matches
+}
+
+func (c *Config) includeFunc(filename string) string {
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(c.SourceDir, filename)
+	}
+	contents, err := c.fs.ReadFile(filename)
+	if err != nil {
+		panic(fmt.Errorf("include: %w", err))
+	}
+	return string(contents)
+}
+
+func (c *Config) lookPathFunc(file string) string {
+	path, err := exec.LookPath(file)
+	switch {
+	case err == nil:
+		return path
+	case errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist):
+		return ""
+	default:
+		panic(fmt.Errorf("lookPath: %s: %w", file, err))
+	}
+}
+
+func (c *Config) outputFunc(name string, args ...string) string {
+	key := strings.Join(append([]string{name}, args...), "\x00")
+	if output, ok := outputCache[key]; ok {
+		return output
+	}
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = c.Stdin
+	cmd.Stderr = c.Stderr
+	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	if err != nil {
+		panic(fmt.Errorf("output: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
+	}
+	outputCache[key] = string(output)
+	return outputCache[key]
+}
+
+func (c *Config) statFunc(name string) interface{} {
+	info, err := c.fs.Stat(name)
+	switch {
+	case err == nil:
+		return map[string]interface{}{
+			"name":    info.Name(),
+			"size":    info.Size(),
+			"mode":    int(info.Mode()),
+			"perm":    int(info.Mode().Perm()),
+			"modTime": info.ModTime().Unix(),
+			"isDir":   info.IsDir(),
+		}
+	case os.IsNotExist(err):
+		return nil
+	default:
+		panic(fmt.Errorf("stat: %w", err))
+	}
+}
+
+func fromJSONFunc(s string) interface{} {
+	var data interface{}
+	if err := json.Unmarshal([]byte(s), &data); err != nil {
+		panic(fmt.Errorf("fromJson: %w", err))
+	}
+	return data
+}
+
+func fromTOMLFunc(s string) interface{} {
+	tree, err := toml.Load(s)
+	if err != nil {
+		panic(fmt.Errorf("fromToml: %w", err))
+	}
+	return tree.ToMap()
+}
+
+func fromYAMLFunc(s string) interface{} {
+	var data interface{}
+	if err := yaml.Unmarshal([]byte(s), &data); err != nil {
+		panic(fmt.Errorf("fromYaml: %w", err))
+	}
+	return normalizeYAML(data)
+}
+
+func toTOMLFunc(data interface{}) string {
+	sb := &strings.Builder{}
+	if err := toml.NewEncoder(sb).Encode(data); err != nil {
+		panic(fmt.Errorf("toToml: %w", err))
+	}
+	return sb.String()
+}
+
+func toYAMLFunc(data interface{}) string {
+	output, err := yaml.Marshal(data)
+	if err != nil {
+		panic(fmt.Errorf("toYaml: %w", err))
+	}
+	return string(output)
+}
+
+// normalizeYAML converts the map[interface{}]interface{}s returned by
+// yaml.Unmarshal into map[string]interface{}s so that they can be used like
+// any other template data.
+func normalizeYAML(data interface{}) interface{} {
+	switch data := data.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(data))
+		for key, value := range data {
+			result[fmt.Sprint(key)] = normalizeYAML(value)
+		}
+		return result
+	case []interface{}:
+		for i, value := range data {
+			data[i] = normalizeYAML(value)
+		}
+		return data
+	default:
+		return data
+	}
+}
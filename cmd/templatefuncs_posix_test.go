@@ -0,0 +1,49 @@
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestOutputFunc(t *testing.T) {
+	outputCache = make(map[string]string)
+	tempDir, err := ioutil.TempDir("", "chezmoi")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+	command := filepath.Join(tempDir, "command")
+	evidence := filepath.Join(tempDir, "evidence")
+	require.NoError(t, ioutil.WriteFile(command, []byte(strings.Join([]string{
+		"#!/bin/sh",
+		"echo \"$*\" >>" + evidence,
+		"echo \"output $*\"",
+	}, "\n")), 0o755))
+
+	// The command is run, even when chezmoi is not making changes, at most
+	// once for each list of arguments.
+	c := newConfig(
+		withMutator(chezmoi.NullMutator{}),
+	)
+	assert.Equal(t, "output a b\n", c.outputFunc(command, "a", "b"))
+	assert.Equal(t, "output a b\n", c.outputFunc(command, "a", "b"))
+	assert.Equal(t, "output a\n", c.outputFunc(command, "a"))
+	assert.Equal(t, "output ab\n", c.outputFunc(command, "ab"))
+	actualEvidence, err := ioutil.ReadFile(evidence)
+	require.NoError(t, err)
+	assert.Equal(t, "a b\na\nab\n", string(actualEvidence))
+
+	assert.Panics(t, func() {
+		c.outputFunc(filepath.Join(tempDir, "missing"))
+	})
+}
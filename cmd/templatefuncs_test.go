@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestFileSystemTemplateFuncs(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".local/share/chezmoi/.include/header": "# header\n",
+			".config/foo.conf":                     "foo",
+			".config/bar.conf":                     "bar",
+			".config/dir":                          &vfst.Dir{Perm: 0o755},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(fs)
+
+	assert.Equal(t, "# header\n", c.includeFunc(".include/header"))
+	assert.Equal(t, "foo", c.includeFunc("/home/user/.config/foo.conf"))
+	assert.Panics(t, func() {
+		c.includeFunc("missing")
+	})
+
+	assert.Equal(t, []string{
+		"/home/user/.config/bar.conf",
+		"/home/user/.config/foo.conf",
+	}, c.globFunc("/home/user/.config/*.conf"))
+
+	assert.Nil(t, c.statFunc("/home/user/missing"))
+	fooInfo, ok := c.statFunc("/home/user/.config/foo.conf").(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "foo.conf", fooInfo["name"])
+	assert.Equal(t, int64(3), fooInfo["size"])
+	assert.Equal(t, false, fooInfo["isDir"])
+	dirInfo, ok := c.statFunc("/home/user/.config/dir").(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, true, dirInfo["isDir"])
+
+	assert.Equal(t, "", c.lookPathFunc("chezmoi-test-command-that-does-not-exist"))
+}
+
+func TestStructuredDataTemplateFuncs(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{
+		"a": []interface{}{float64(1), "b"},
+	}, fromJSONFunc(`{"a":[1,"b"]}`))
+	assert.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": []interface{}{1, "c"},
+		},
+	}, fromYAMLFunc("a:\n  b:\n  - 1\n  - c\n"))
+	assert.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": "c",
+		},
+	}, fromTOMLFunc("[a]\nb = \"c\"\n"))
+	assert.Equal(t, "a:\n  b: c\n", toYAMLFunc(map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": "c",
+		},
+	}))
+	assert.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": "c",
+		},
+	}, fromTOMLFunc(toTOMLFunc(map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": "c",
+		},
+	})))
+	assert.Panics(t, func() {
+		fromJSONFunc("{")
+	})
+}
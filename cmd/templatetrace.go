@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+// startTemplateTrace starts recording the data accesses, function calls, and
+// template inclusions made by templates.
+func (c *Config) startTemplateTrace() {
+	redactFuncs := c.secretFuncs
+	if c.showSecrets {
+		redactFuncs = nil
+	}
+	c.templateTracer = chezmoi.NewTemplateTracer(redactFuncs)
+}
+
+// writeTemplateTrace writes the recorded template trace to w.
+func (c *Config) writeTemplateTrace(w io.Writer) error {
+	if c.templateTracer == nil {
+		return nil
+	}
+	redactor := c.getRedactor()
+	for _, event := range c.templateTracer.Events {
+		templateName := event.Template
+		if relPath, err := filepath.Rel(c.SourceDir, templateName); err == nil && filepath.IsAbs(templateName) && !strings.HasPrefix(relPath, "..") {
+			templateName = filepath.ToSlash(relPath)
+		}
+		var sb strings.Builder
+		sb.WriteString(templateName + ": " + event.Type + " " + event.Name)
+		for _, arg := range event.Args {
+			sb.WriteString(" " + formatTraceValue(redactor.RedactValue(arg)))
+		}
+		if event.Type != chezmoi.TemplateTraceTemplate {
+			sb.WriteString(" = " + formatTraceValue(redactor.RedactValue(event.Value)))
+		}
+		if _, err := fmt.Fprintln(w, sb.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatTraceValue returns a compact representation of value.
+func formatTraceValue(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "<no value>"
+	case string:
+		if value == chezmoi.Redacted {
+			return value
+		}
+		return strconv.Quote(value)
+	}
+	if data, err := json.Marshal(value); err == nil {
+		return string(data)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestTemplateTrace(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".local/share/chezmoi": map[string]interface{}{
+				".chezmoitemplates/partial": "{{ .email }}",
+				"dot_gitconfig.tmpl":        "{{ template \"partial\" . }} {{ testSecret \"key\" }}\n",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	c := newTestConfig(
+		fs,
+		withData(map[string]interface{}{
+			"email": "user@example.com",
+		}),
+		withStdout(stdout),
+		withStderr(stderr),
+	)
+	c.addSecretTemplateFunc("testSecret", func(string) string {
+		return "password"
+	})
+	c.cat.trace = true
+	require.NoError(t, c.runCatCmd(&cobra.Command{}, []string{"/home/user/.gitconfig"}))
+	assert.Equal(t, "user@example.com password\n", stdout.String())
+	assert.Equal(t, strings.Join([]string{
+		"dot_gitconfig.tmpl: template partial",
+		"partial: data .email = \"user@example.com\"",
+		"dot_gitconfig.tmpl: func testSecret \"key\" = <redacted>",
+		"",
+	}, "\n"), stderr.String())
+}
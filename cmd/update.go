@@ -59,7 +59,7 @@ func (c *Config) runUpdateCmd(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
-		defer persistentState.Close()
+		defer c.closePersistentState()
 		if err := c.applyArgs(nil, persistentState); err != nil {
 			return err
 		}
@@ -11,6 +11,7 @@ type VCS interface {
 	ParseStatusOutput([]byte) (interface{}, error)
 	PullArgs() []string
 	PushArgs() []string
+	ShallowCloneArgs(string, string) []string
 	StatusArgs() []string
 	VersionArgs() []string
 	VersionRegexp() *regexp.Regexp
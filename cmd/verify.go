@@ -32,7 +32,7 @@ func (c *Config) runVerifyCmd(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	defer persistentState.Close()
+	defer c.closePersistentState()
 
 	if err := c.applyArgs(args, persistentState); err != nil {
 		return err
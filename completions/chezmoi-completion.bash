@@ -336,8 +336,11 @@ _chezmoi_add()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--archive")
     flags+=("--autotemplate")
     flags+=("-a")
+    flags+=("--autotemplate-min-length=")
+    two_word_flags+=("--autotemplate-min-length")
     flags+=("--empty")
     flags+=("-e")
     flags+=("--encrypt")
@@ -345,6 +348,7 @@ _chezmoi_add()
     flags+=("-x")
     flags+=("--force")
     flags+=("-f")
+    flags+=("--preview")
     flags+=("--prompt")
     flags+=("-p")
     flags+=("--recursive")
@@ -364,6 +368,7 @@ _chezmoi_add()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -402,6 +407,7 @@ _chezmoi_apply()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -447,6 +453,7 @@ _chezmoi_archive()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -472,6 +479,7 @@ _chezmoi_cat()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--trace")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -485,6 +493,7 @@ _chezmoi_cat()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -523,6 +532,7 @@ _chezmoi_cd()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -561,6 +571,7 @@ _chezmoi_chattr()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -609,6 +620,7 @@ _chezmoi_completion()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -653,6 +665,7 @@ _chezmoi_data()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -695,6 +708,7 @@ _chezmoi_diff()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -733,6 +747,7 @@ _chezmoi_docs()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -771,6 +786,7 @@ _chezmoi_doctor()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -814,6 +830,7 @@ _chezmoi_dump()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -858,6 +875,7 @@ _chezmoi_edit()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -896,6 +914,7 @@ _chezmoi_edit-config()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -926,9 +945,16 @@ _chezmoi_execute-template()
     flags+=("--output=")
     two_word_flags+=("--output")
     two_word_flags+=("-o")
+    flags+=("--promptBool=")
+    two_word_flags+=("--promptBool")
+    flags+=("--promptChoice=")
+    two_word_flags+=("--promptChoice")
+    flags+=("--promptInt=")
+    two_word_flags+=("--promptInt")
     flags+=("--promptString=")
     two_word_flags+=("--promptString")
     two_word_flags+=("-p")
+    flags+=("--trace")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -942,6 +968,7 @@ _chezmoi_execute-template()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -980,6 +1007,7 @@ _chezmoi_forget()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1018,6 +1046,7 @@ _chezmoi_git()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1056,6 +1085,7 @@ _chezmoi_hg()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1100,6 +1130,7 @@ _chezmoi_import()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1126,6 +1157,21 @@ _chezmoi_init()
     flags_completion=()
 
     flags+=("--apply")
+    flags+=("--one-shot")
+    flags+=("--promptBool=")
+    two_word_flags+=("--promptBool")
+    flags+=("--promptChoice=")
+    two_word_flags+=("--promptChoice")
+    flags+=("--promptFile=")
+    two_word_flags+=("--promptFile")
+    flags_with_completion+=("--promptFile")
+    flags_completion+=("__chezmoi_handle_filename_extension_flag json|toml|yaml|yml")
+    flags+=("--promptInt=")
+    two_word_flags+=("--promptInt")
+    flags+=("--promptString=")
+    two_word_flags+=("--promptString")
+    flags+=("--skip-persistent-state")
+    flags+=("--skip-scripts")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1139,6 +1185,49 @@ _chezmoi_init()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_lint()
+{
+    last_command="chezmoi_lint"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1180,6 +1269,7 @@ _chezmoi_managed()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1218,6 +1308,7 @@ _chezmoi_merge()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1258,6 +1349,46 @@ _chezmoi_purge()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_rekey()
+{
+    last_command="chezmoi_rekey"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1298,6 +1429,7 @@ _chezmoi_remove()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1336,6 +1468,86 @@ _chezmoi_secret_bitwarden()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_secret_cache_clear()
+{
+    last_command="chezmoi_secret_cache_clear"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--show-secrets")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_secret_cache()
+{
+    last_command="chezmoi_secret_cache"
+
+    command_aliases=()
+
+    commands=()
+    commands+=("clear")
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1374,6 +1586,7 @@ _chezmoi_secret_generic()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1412,6 +1625,7 @@ _chezmoi_secret_gopass()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1450,6 +1664,7 @@ _chezmoi_secret_keepassxc()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1490,6 +1705,7 @@ _chezmoi_secret_keyring_get()
     flags+=("--remove")
     flags+=("--service=")
     two_word_flags+=("--service")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1534,6 +1750,7 @@ _chezmoi_secret_keyring_set()
     flags+=("--remove")
     flags+=("--service=")
     two_word_flags+=("--service")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1580,6 +1797,7 @@ _chezmoi_secret_keyring()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1620,6 +1838,7 @@ _chezmoi_secret_lastpass()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1658,6 +1877,7 @@ _chezmoi_secret_onepassword()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1696,6 +1916,46 @@ _chezmoi_secret_pass()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_secret_sops()
+{
+    last_command="chezmoi_secret_sops"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    two_word_flags+=("-c")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1734,6 +1994,7 @@ _chezmoi_secret_vault()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1753,6 +2014,7 @@ _chezmoi_secret()
 
     commands=()
     commands+=("bitwarden")
+    commands+=("cache")
     commands+=("generic")
     commands+=("gopass")
     commands+=("keepassxc")
@@ -1760,6 +2022,7 @@ _chezmoi_secret()
     commands+=("lastpass")
     commands+=("onepassword")
     commands+=("pass")
+    commands+=("sops")
     commands+=("vault")
 
     flags=()
@@ -1781,6 +2044,7 @@ _chezmoi_secret()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1819,6 +2083,7 @@ _chezmoi_source()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1857,6 +2122,7 @@ _chezmoi_source-path()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1895,6 +2161,7 @@ _chezmoi_unmanaged()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1935,6 +2202,7 @@ _chezmoi_update()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1984,6 +2252,7 @@ _chezmoi_upgrade()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -2022,6 +2291,7 @@ _chezmoi_verify()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -2068,9 +2338,15 @@ _chezmoi_root_command()
     commands+=("hg")
     commands+=("import")
     commands+=("init")
+    commands+=("lint")
+    if [[ -z "${BASH_VERSION}" || "${BASH_VERSINFO[0]}" -gt 3 ]]; then
+        command_aliases+=("check")
+        aliashash["check"]="lint"
+    fi
     commands+=("managed")
     commands+=("merge")
     commands+=("purge")
+    commands+=("rekey")
     commands+=("remove")
     if [[ -z "${BASH_VERSION}" || "${BASH_VERSINFO[0]}" -gt 3 ]]; then
         command_aliases+=("rm")
@@ -2103,6 +2379,7 @@ _chezmoi_root_command()
     flags+=("-n")
     flags+=("--follow")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
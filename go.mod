@@ -3,6 +3,7 @@ module github.com/twpayne/chezmoi
 go 1.13
 
 require (
+	filippo.io/age v1.0.0-beta4
 	github.com/Masterminds/goutils v1.1.0 // indirect
 	github.com/Masterminds/semver v1.5.0 // indirect
 	github.com/Masterminds/sprig v2.22.0+incompatible
@@ -38,6 +39,7 @@ require (
 	github.com/spf13/viper v1.6.3
 	github.com/stretchr/objx v0.2.0 // indirect
 	github.com/stretchr/testify v1.4.0
+	github.com/tobischo/gokeepasslib/v3 v3.1.0
 	github.com/twpayne/go-shell v0.2.0
 	github.com/twpayne/go-vfs v1.4.0
 	github.com/twpayne/go-vfsafero v1.0.0
@@ -45,10 +47,10 @@ require (
 	github.com/yuin/goldmark v1.1.28 // indirect
 	github.com/zalando/go-keyring v0.0.0-20200121091418-667557018717
 	go.etcd.io/bbolt v1.3.4
-	golang.org/x/crypto v0.0.0-20200406173513-056763e48d71
+	golang.org/x/crypto v0.0.0-20200429183012-4b2356b1ed79
 	golang.org/x/net v0.0.0-20200324143707-d3edc9973b7e // indirect
 	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d
-	golang.org/x/sys v0.0.0-20200420163511-1957bb5e6d1f
+	golang.org/x/sys v0.0.0-20200501145240-bc7a7d42d5c3
 	google.golang.org/appengine v1.6.5 // indirect
 	gopkg.in/ini.v1 v1.55.0 // indirect
 	gopkg.in/yaml.v2 v2.2.8
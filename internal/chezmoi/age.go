@@ -0,0 +1,221 @@
+package chezmoi
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// An AgeEncryption uses age for encryption and decryption. Files are encrypted
+// to X25519 recipients or, if Passphrase is set, with a passphrase.
+type AgeEncryption struct {
+	Identity   string
+	Identities []string
+	Recipient  string
+	Recipients []string
+	Passphrase bool
+
+	// FS is used to read identity files.
+	FS vfs.FS
+	// PassphraseFunc is called to get the passphrase.
+	PassphraseFunc func() (string, error)
+}
+
+// Decrypt decrypts ciphertext.
+func (e *AgeEncryption) Decrypt(filename string, ciphertext []byte) ([]byte, error) {
+	identities, err := e.identities()
+	if err != nil {
+		return nil, err
+	}
+	var r io.Reader = bytes.NewReader(ciphertext)
+	if bytes.HasPrefix(ciphertext, []byte(armor.Header)) {
+		r = armor.NewReader(r)
+	}
+	plaintextReader, err := age.Decrypt(r, identities...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return ioutil.ReadAll(plaintextReader)
+}
+
+// Encrypt encrypts plaintext. The ciphertext is ASCII-armored.
+func (e *AgeEncryption) Encrypt(filename string, plaintext []byte) ([]byte, error) {
+	recipients, err := e.recipients()
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := &bytes.Buffer{}
+	armorWriter := armor.NewWriter(ciphertext)
+	w, err := age.Encrypt(armorWriter, recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := armorWriter.Close(); err != nil {
+		return nil, err
+	}
+	return ciphertext.Bytes(), nil
+}
+
+// RecipientNames returns the names of e's recipients.
+func (e *AgeEncryption) RecipientNames() ([]string, error) {
+	if e.Passphrase {
+		return []string{"passphrase"}, nil
+	}
+	recipientNames := e.recipientStrs()
+	if len(recipientNames) == 0 {
+		identities, err := e.x25519Identities()
+		if err != nil {
+			return nil, err
+		}
+		for _, identity := range identities {
+			recipientNames = append(recipientNames, identity.Recipient().String())
+		}
+	}
+	sort.Strings(recipientNames)
+	return recipientNames, nil
+}
+
+// identities returns e's identities.
+func (e *AgeEncryption) identities() ([]age.Identity, error) {
+	if e.Passphrase {
+		passphrase, err := e.passphrase()
+		if err != nil {
+			return nil, err
+		}
+		identity, err := age.NewScryptIdentity(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Identity{identity}, nil
+	}
+	x25519Identities, err := e.x25519Identities()
+	if err != nil {
+		return nil, err
+	}
+	identities := make([]age.Identity, 0, len(x25519Identities))
+	for _, identity := range x25519Identities {
+		identities = append(identities, identity)
+	}
+	return identities, nil
+}
+
+// passphrase returns the passphrase.
+func (e *AgeEncryption) passphrase() (string, error) {
+	if e.PassphraseFunc == nil {
+		return "", errors.New("age: passphrase required")
+	}
+	return e.PassphraseFunc()
+}
+
+// recipients returns e's recipients. If no recipients are configured then the
+// recipients of e's identities are used.
+func (e *AgeEncryption) recipients() ([]age.Recipient, error) {
+	if e.Passphrase {
+		passphrase, err := e.passphrase()
+		if err != nil {
+			return nil, err
+		}
+		recipient, err := age.NewScryptRecipient(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Recipient{recipient}, nil
+	}
+	var recipients []age.Recipient
+	for _, s := range e.recipientStrs() {
+		recipient, err := age.ParseX25519Recipient(s)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+	if len(recipients) == 0 {
+		identities, err := e.x25519Identities()
+		if err != nil {
+			return nil, err
+		}
+		for _, identity := range identities {
+			recipients = append(recipients, identity.Recipient())
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("age: no recipients or identities configured")
+	}
+	return recipients, nil
+}
+
+// recipientStrs returns all of e's configured recipients.
+func (e *AgeEncryption) recipientStrs() []string {
+	var recipientStrs []string
+	if e.Recipient != "" {
+		recipientStrs = append(recipientStrs, e.Recipient)
+	}
+	return append(recipientStrs, e.Recipients...)
+}
+
+// x25519Identities returns the X25519 identities in e's identity files.
+func (e *AgeEncryption) x25519Identities() ([]*age.X25519Identity, error) {
+	var filenames []string
+	if e.Identity != "" {
+		filenames = append(filenames, e.Identity)
+	}
+	filenames = append(filenames, e.Identities...)
+	if len(filenames) == 0 {
+		return nil, errors.New("age: no identities configured")
+	}
+	var identities []*age.X25519Identity
+	for _, filename := range filenames {
+		data, err := e.FS.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		fileIdentities, err := parseAgeIdentities(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		identities = append(identities, fileIdentities...)
+	}
+	return identities, nil
+}
+
+// parseAgeIdentities parses the X25519 identities in data, one per line,
+// ignoring empty lines and comments.
+func parseAgeIdentities(data []byte) ([]*age.X25519Identity, error) {
+	var identities []*age.X25519Identity
+	s := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for s.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		identity, err := age.ParseX25519Identity(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		identities = append(identities, identity)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(identities) == 0 {
+		return nil, errors.New("no identities found")
+	}
+	return identities, nil
+}
@@ -0,0 +1,107 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestAgeEncryption(t *testing.T) {
+	identity1, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	identity2, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/key1.txt": "# created: 2020-01-01T00:00:00Z\n" + identity1.String() + "\n",
+		"/home/user/key2.txt": identity2.String() + "\n",
+		"/home/user/bad.txt":  "not an identity\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	for _, tc := range []struct {
+		name       string
+		encryption *AgeEncryption
+		decryption *AgeEncryption
+	}{
+		{
+			name: "identity",
+			encryption: &AgeEncryption{
+				Identity: "/home/user/key1.txt",
+			},
+		},
+		{
+			name: "recipient",
+			encryption: &AgeEncryption{
+				Recipient: identity2.Recipient().String(),
+			},
+			decryption: &AgeEncryption{
+				Identity: "/home/user/key2.txt",
+			},
+		},
+		{
+			name: "multiple_identities",
+			encryption: &AgeEncryption{
+				Recipients: []string{
+					identity1.Recipient().String(),
+				},
+			},
+			decryption: &AgeEncryption{
+				Identities: []string{
+					"/home/user/key2.txt",
+					"/home/user/key1.txt",
+				},
+			},
+		},
+		{
+			name: "passphrase",
+			encryption: &AgeEncryption{
+				Passphrase: true,
+				PassphraseFunc: func() (string, error) {
+					return "passphrase", nil
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.encryption.FS = fs
+			decryption := tc.decryption
+			if decryption == nil {
+				decryption = tc.encryption
+			}
+			decryption.FS = fs
+
+			plaintext := []byte("plaintext\n")
+			ciphertext, err := tc.encryption.Encrypt("file", plaintext)
+			require.NoError(t, err)
+			assert.Contains(t, string(ciphertext), armor.Header)
+			assert.NotContains(t, string(ciphertext), string(plaintext))
+
+			actualPlaintext, err := decryption.Decrypt("file", ciphertext)
+			require.NoError(t, err)
+			assert.Equal(t, plaintext, actualPlaintext)
+		})
+	}
+
+	t.Run("wrong_identity", func(t *testing.T) {
+		ciphertext, err := (&AgeEncryption{FS: fs, Identity: "/home/user/key1.txt"}).Encrypt("file", []byte("plaintext\n"))
+		require.NoError(t, err)
+		_, err = (&AgeEncryption{FS: fs, Identity: "/home/user/key2.txt"}).Decrypt("file", ciphertext)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid_identity", func(t *testing.T) {
+		_, err := (&AgeEncryption{FS: fs, Identity: "/home/user/bad.txt"}).Encrypt("file", []byte("plaintext\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("no_recipients", func(t *testing.T) {
+		_, err := (&AgeEncryption{FS: fs}).Encrypt("file", []byte("plaintext\n"))
+		assert.Error(t, err)
+	})
+}
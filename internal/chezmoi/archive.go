@@ -0,0 +1,230 @@
+package chezmoi
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// ArchiveName is the name of the encrypted archive that stores the contents of
+// a directory in the source state.
+const ArchiveName = ".chezmoiarchive"
+
+// InArchive returns true if entry is stored in an encrypted archive.
+func InArchive(entry Entry) bool {
+	return filepath.Base(entry.SourceName()) == ArchiveName
+}
+
+// HasArchive returns true if entry is a directory whose contents are stored in
+// an encrypted archive.
+func HasArchive(entry Entry) bool {
+	dir, ok := entry.(*Dir)
+	return ok && dir.archived
+}
+
+// addArchive adds the entries in the encrypted archive at path, relPath in the
+// source state, to the directory dns.
+func (ts *TargetState) addArchive(fs vfs.FS, path, relPath string, dns []string) error {
+	if len(dns) > 0 {
+		entry, err := ts.findEntry(filepath.Join(dns...))
+		if err != nil {
+			return err
+		}
+		dir, ok := entry.(*Dir)
+		if !ok {
+			return fmt.Errorf("%s: not a directory", filepath.Join(dns...))
+		}
+		dir.archived = true
+	}
+	ciphertext, err := fs.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	plaintext, err := ts.Encryption.Decrypt(path, ciphertext)
+	if err != nil {
+		return err
+	}
+	r := tar.NewReader(bytes.NewReader(plaintext))
+	for {
+		header, err := r.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%s: %w", relPath, err)
+		}
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		if name == "." {
+			continue
+		}
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%s: %s: invalid name", relPath, header.Name)
+		}
+		components := splitPathList(name)
+		entries, err := ts.archiveEntries(relPath, dns, components[:len(components)-1])
+		if err != nil {
+			return err
+		}
+		base := components[len(components)-1]
+		targetName := filepath.Join(append(append([]string{}, dns...), components...)...)
+		perm := os.FileMode(header.Mode).Perm()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if dir, ok := entries[base].(*Dir); !ok || !InArchive(dir) {
+				if err := addEntry(entries, base, newDir(relPath, targetName, false, perm)); err != nil {
+					return err
+				}
+			}
+		case tar.TypeReg:
+			contents, err := ioutil.ReadAll(r)
+			if err != nil {
+				return fmt.Errorf("%s: %w", relPath, err)
+			}
+			if err := addEntry(entries, base, &File{
+				sourceName: relPath,
+				targetName: targetName,
+				Empty:      true,
+				Encrypted:  true,
+				Perm:       perm,
+				evaluateContents: func() ([]byte, error) {
+					return contents, nil
+				},
+			}); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			linkname := header.Linkname
+			if err := addEntry(entries, base, &Symlink{
+				sourceName: relPath,
+				targetName: targetName,
+				evaluateLinkname: func() (string, error) {
+					return linkname, nil
+				},
+			}); err != nil {
+				return err
+			}
+		case tar.TypeXGlobalHeader:
+		default:
+			return fmt.Errorf("%s: %s: unsupported typeflag '%c'", relPath, header.Name, header.Typeflag)
+		}
+	}
+}
+
+// archiveEntries returns the entries of the directory components in the
+// directory dns, creating directories from the archive relPath as needed.
+func (ts *TargetState) archiveEntries(relPath string, dns, components []string) (map[string]Entry, error) {
+	entries, err := ts.findEntries(dns)
+	if err != nil {
+		return nil, err
+	}
+	for i, component := range components {
+		switch entry := entries[component].(type) {
+		case *Dir:
+			if !InArchive(entry) {
+				return nil, fmt.Errorf("%s: duplicate target (%s, %s)", entry.TargetName(), entry.SourceName(), relPath)
+			}
+			entries = entry.Entries
+		case nil:
+			targetName := filepath.Join(append(append([]string{}, dns...), components[:i+1]...)...)
+			dir := newDir(relPath, targetName, false, 0o777)
+			entries[component] = dir
+			entries = dir.Entries
+		default:
+			return nil, fmt.Errorf("%s: %s: not a directory", relPath, filepath.Join(components[:i+1]...))
+		}
+	}
+	return entries, nil
+}
+
+// addArchiveDir adds the directory at targetPath to the source state as a
+// directory containing an encrypted archive of its contents.
+func (ts *TargetState) addArchiveDir(fs vfs.FS, targetName, targetPath string, entries map[string]Entry, parentDirSourceName string, perm os.FileMode, mutator Mutator) error {
+	plaintext, err := newArchive(fs, targetPath)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := ts.Encryption.Encrypt(targetPath, plaintext)
+	if err != nil {
+		return err
+	}
+	if err := ts.addDir(targetName, entries, parentDirSourceName, false, perm, false, mutator); err != nil {
+		return err
+	}
+	dir := entries[filepath.Base(targetName)].(*Dir)
+	// Adding the archive to a directory whose contents are already managed by
+	// other source entries would manage the same targets twice.
+	for _, entry := range dir.Entries {
+		if !InArchive(entry) {
+			return fmt.Errorf("%s: already added without --archive", targetName)
+		}
+	}
+	archivePath := filepath.Join(ts.SourceDir, dir.sourceName, ArchiveName)
+	currData, err := fs.ReadFile(archivePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return mutator.WriteFile(archivePath, ciphertext, 0o666&^ts.Umask, currData)
+}
+
+// newArchive returns a tar archive of the contents of the directory dir. File
+// ownership and modification times are not included.
+func newArchive(fs vfs.FS, dir string) ([]byte, error) {
+	b := &bytes.Buffer{}
+	w := tar.NewWriter(b)
+	if err := vfs.Walk(fs, dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		linkname := ""
+		if info.Mode()&os.ModeType == os.ModeSymlink {
+			linkname, err = fs.Readlink(path)
+			if err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, linkname)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relPath)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		header.ModTime = time.Time{}
+		header.Uid, header.Gid = 0, 0
+		header.Uname, header.Gname = "", ""
+		if err := w.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			contents, err := fs.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(contents); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
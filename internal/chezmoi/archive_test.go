@@ -0,0 +1,313 @@
+package chezmoi
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestArchive(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			"key.txt": identity.String() + "\n",
+			".aws": &vfst.Dir{
+				Perm: 0o700,
+				Entries: map[string]interface{}{
+					"credentials": &vfst.File{
+						Perm:     0o600,
+						Contents: []byte("# credentials\n"),
+					},
+					"cli/alias": "# alias\n",
+					"link":      &vfst.Symlink{Target: "credentials"},
+				},
+			},
+			".local/share/chezmoi": &vfst.Dir{Perm: 0o755},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	newTargetState := func() *TargetState {
+		return NewTargetState(
+			WithDestDir("/home/user"),
+			WithSourceDir("/home/user/.local/share/chezmoi"),
+			WithUmask(0o22),
+			WithEncryption(&AgeEncryption{
+				Identity: "/home/user/key.txt",
+				FS:       fs,
+			}),
+		)
+	}
+
+	ts := newTargetState()
+	require.NoError(t, ts.Populate(fs, nil))
+	addOptions := AddOptions{
+		Archive: true,
+		Encrypt: true,
+	}
+	require.NoError(t, ts.Add(fs, addOptions, "/home/user/.aws", nil, false, NewFSMutator(fs)))
+
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/private_dot_aws",
+			vfst.TestIsDir,
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi/private_dot_aws/.chezmoiarchive",
+			vfst.TestModeIsRegular,
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi/private_dot_aws/credentials",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi/private_dot_aws/cli",
+			vfst.TestDoesNotExist,
+		),
+	)
+	ciphertext, err := fs.ReadFile("/home/user/.local/share/chezmoi/private_dot_aws/.chezmoiarchive")
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(ciphertext, []byte("credentials")))
+
+	ts = newTargetState()
+	require.NoError(t, ts.Populate(fs, nil))
+	require.NoError(t, ts.Evaluate())
+
+	for _, tc := range []struct {
+		targetPath string
+		wantType   interface{}
+		wantPerm   os.FileMode
+		want       string
+	}{
+		{
+			targetPath: "/home/user/.aws/credentials",
+			wantType:   &File{},
+			wantPerm:   0o600,
+			want:       "# credentials\n",
+		},
+		{
+			targetPath: "/home/user/.aws/cli",
+			wantType:   &Dir{},
+			wantPerm:   0o755,
+		},
+		{
+			targetPath: "/home/user/.aws/cli/alias",
+			wantType:   &File{},
+			wantPerm:   0o644,
+			want:       "# alias\n",
+		},
+		{
+			targetPath: "/home/user/.aws/link",
+			wantType:   &Symlink{},
+			want:       "credentials",
+		},
+	} {
+		t.Run(filepath.Base(tc.targetPath), func(t *testing.T) {
+			entry, err := ts.Get(fs, tc.targetPath)
+			require.NoError(t, err)
+			assert.IsType(t, tc.wantType, entry)
+			assert.True(t, InArchive(entry))
+			switch entry := entry.(type) {
+			case *Dir:
+				assert.Equal(t, tc.wantPerm, entry.Perm)
+			case *File:
+				assert.Equal(t, tc.wantPerm, entry.Perm)
+				contents, err := entry.Contents()
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, string(contents))
+			case *Symlink:
+				linkname, err := entry.Linkname()
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, linkname)
+			}
+		})
+	}
+
+	require.NoError(t, fs.RemoveAll("/home/user/.aws"))
+	require.NoError(t, ts.Apply(fs, NewFSMutator(fs), false, &ApplyOptions{
+		DestDir:           ts.DestDir,
+		Ignore:            ts.TargetIgnore.Match,
+		ScriptStateBucket: []byte("script"),
+		Stdout:            os.Stdout,
+		Umask:             0o22,
+	}))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.aws",
+			vfst.TestIsDir,
+			vfst.TestModePerm(0o700),
+		),
+		vfst.TestPath("/home/user/.aws/credentials",
+			vfst.TestModeIsRegular,
+			vfst.TestModePerm(0o600),
+			vfst.TestContentsString("# credentials\n"),
+		),
+		vfst.TestPath("/home/user/.aws/cli/alias",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("# alias\n"),
+		),
+		vfst.TestPath("/home/user/.aws/link",
+			vfst.TestModeType(os.ModeSymlink),
+			vfst.TestSymlinkTarget("credentials"),
+		),
+	)
+}
+
+func TestArchiveManagedDir(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			"key.txt": identity.String() + "\n",
+			".aws": &vfst.Dir{
+				Perm: 0o700,
+				Entries: map[string]interface{}{
+					"credentials": &vfst.File{
+						Perm:     0o600,
+						Contents: []byte("# credentials\n"),
+					},
+				},
+			},
+			".local/share/chezmoi": &vfst.Dir{Perm: 0o755},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	newTargetState := func() *TargetState {
+		return NewTargetState(
+			WithDestDir("/home/user"),
+			WithSourceDir("/home/user/.local/share/chezmoi"),
+			WithUmask(0o22),
+			WithEncryption(&AgeEncryption{
+				Identity: "/home/user/key.txt",
+				FS:       fs,
+			}),
+		)
+	}
+	archiveAddOptions := AddOptions{
+		Archive: true,
+		Encrypt: true,
+	}
+
+	// Re-adding a directory that is already managed as an archive updates the
+	// archive.
+	ts := newTargetState()
+	require.NoError(t, ts.Populate(fs, nil))
+	require.NoError(t, ts.Add(fs, archiveAddOptions, "/home/user/.aws", nil, false, NewFSMutator(fs)))
+	ts = newTargetState()
+	require.NoError(t, ts.Populate(fs, nil))
+	require.NoError(t, ts.Add(fs, archiveAddOptions, "/home/user/.aws", nil, false, NewFSMutator(fs)))
+
+	// Adding a file to a directory that is managed as an archive fails.
+	require.NoError(t, fs.WriteFile("/home/user/.aws/config", []byte("# config\n"), 0o600))
+	ts = newTargetState()
+	require.NoError(t, ts.Populate(fs, nil))
+	assert.Error(t, ts.Add(fs, AddOptions{}, "/home/user/.aws/config", nil, false, NewFSMutator(fs)))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/private_dot_aws/private_config",
+			vfst.TestDoesNotExist,
+		),
+	)
+
+	// Adding a directory as an archive when its contents are already managed
+	// without an archive fails.
+	require.NoError(t, fs.RemoveAll("/home/user/.local/share/chezmoi/private_dot_aws"))
+	ts = newTargetState()
+	require.NoError(t, ts.Populate(fs, nil))
+	require.NoError(t, ts.Add(fs, AddOptions{}, "/home/user/.aws/credentials", nil, false, NewFSMutator(fs)))
+	ts = newTargetState()
+	require.NoError(t, ts.Populate(fs, nil))
+	assert.Error(t, ts.Add(fs, archiveAddOptions, "/home/user/.aws", nil, false, NewFSMutator(fs)))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/private_dot_aws/.chezmoiarchive",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi/private_dot_aws/private_credentials",
+			vfst.TestModeIsRegular,
+		),
+	)
+}
+
+func TestArchiveDuplicateTarget(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			"key.txt": identity.String() + "\n",
+			".aws": &vfst.Dir{
+				Perm: 0o700,
+				Entries: map[string]interface{}{
+					"credentials": "# credentials\n",
+				},
+			},
+			".local/share/chezmoi": &vfst.Dir{Perm: 0o755},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	newTargetState := func() *TargetState {
+		return NewTargetState(
+			WithDestDir("/home/user"),
+			WithSourceDir("/home/user/.local/share/chezmoi"),
+			WithUmask(0o22),
+			WithEncryption(&AgeEncryption{
+				Identity: "/home/user/key.txt",
+				FS:       fs,
+			}),
+		)
+	}
+
+	ts := newTargetState()
+	require.NoError(t, ts.Populate(fs, nil))
+	require.NoError(t, ts.Add(fs, AddOptions{Archive: true, Encrypt: true}, "/home/user/.aws", nil, false, NewFSMutator(fs)))
+
+	// A plain source file with the same target as an archived file is an
+	// error.
+	require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/private_dot_aws/credentials", []byte("# plaintext\n"), 0o644))
+	assert.Error(t, newTargetState().Populate(fs, nil))
+}
+
+func TestArchiveInvalidName(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := tar.NewWriter(b)
+	require.NoError(t, w.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     "../evil",
+		Mode:     0o644,
+	}))
+	require.NoError(t, w.Close())
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	encryption := &AgeEncryption{
+		Recipient: identity.Recipient().String(),
+	}
+	ciphertext, err := encryption.Encrypt(ArchiveName, b.Bytes())
+	require.NoError(t, err)
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/key.txt": identity.String() + "\n",
+		"/home/user/.local/share/chezmoi/dir/.chezmoiarchive": ciphertext,
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+		WithEncryption(&AgeEncryption{
+			Identity: "/home/user/key.txt",
+			FS:       fs,
+		}),
+	)
+	assert.Error(t, ts.Populate(fs, nil))
+}
@@ -3,10 +3,23 @@ package chezmoi
 import (
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 )
 
-var delimiterRegexp = regexp.MustCompile(`\{\{+|\}\}+`)
+var (
+	delimiterRegexp  = regexp.MustCompile(`\{\{+|\}\}+`)
+	identifierRegexp = regexp.MustCompile(`\A[\pL_][\pL\p{Nd}_]*\z`)
+)
+
+// An AutoTemplateSubstitution is a substitution made when automatically
+// generating a template.
+type AutoTemplateSubstitution struct {
+	Value       string
+	Reference   string
+	Replacement string
+	Count       int
+}
 
 type templateVariable struct {
 	name  string
@@ -29,76 +42,175 @@ func (b byValueLength) Less(i, j int) bool {
 }
 func (b byValueLength) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
 
-func autoTemplate(contents []byte, data map[string]interface{}) []byte {
-	// FIXME this naive approach will generate incorrect templates if the
-	// variable names match variable values
-	// FIXME the algorithm here is probably O(N^2), we can do better
-	variables := extractVariables(nil, nil, data)
+// autoTemplate generates a template from contents by replacing values in data
+// with references to them. Values shorter than minLength are ignored. It
+// returns the template and the substitutions made.
+func autoTemplate(contents []byte, data map[string]interface{}, minLength int) ([]byte, []AutoTemplateSubstitution) {
+	var variables []templateVariable
+	for _, variable := range extractVariables(nil, nil, data) {
+		if variable.value != "" && len(variable.value) >= minLength {
+			variables = append(variables, variable)
+		}
+	}
 	sort.Sort(sort.Reverse(byValueLength(variables)))
-	contentsStr := string(templateEscape(contents))
+
+	// Respect any template directives in contents, and leave the directives
+	// themselves unchanged.
+	td, _, err := parseTemplateDirectives(contents)
+	if err != nil {
+		td, _, _ = parseTemplateDirectives(nil)
+	}
+	counts := make(map[string]int)
+	n := templateDirectivesLen(contents)
+	result := append([]byte{}, contents[:n]...)
+	result = append(result, autoTemplateChunk(contents[n:], variables, td, counts)...)
+
+	var substitutions []AutoTemplateSubstitution
 	for _, variable := range variables {
-		if variable.value == "" {
-			continue
+		if count := counts[variable.name]; count != 0 {
+			substitutions = append(substitutions, AutoTemplateSubstitution{
+				Value:       variable.value,
+				Reference:   variable.name,
+				Replacement: templateAction(variable.name, td),
+				Count:       count,
+			})
 		}
-		index := strings.Index(contentsStr, variable.value)
-		for index != -1 && index != len(contentsStr) {
-			if !inWord(contentsStr, index) && !inWord(contentsStr, index+len(variable.value)) {
-				// Replace variable.value which is on word boundaries at both
-				// ends.
-				replacement := "{{ ." + variable.name + " }}"
-				contentsStr = contentsStr[:index] + replacement + contentsStr[index+len(variable.value):]
-				index += len(replacement)
-			} else {
-				// Otherwise, keep looking. Consume at least one byte so we
-				// make progress.
-				index++
-			}
-			// Look for the next occurrence of variable.value.
-			j := strings.Index(contentsStr[index:], variable.value)
-			if j == -1 {
-				// No more occurrences found, so terminate the loop.
+	}
+	return result, substitutions
+}
+
+// autoTemplateChunk replaces variables in contents using td's delimiters and
+// escapes everything else. Variables are matched against the original
+// contents, longest first, so replacements are never themselves replaced.
+// counts is updated with the number of replacements of each variable.
+func autoTemplateChunk(contents []byte, variables []templateVariable, td *templateDirective, counts map[string]int) []byte {
+	type replacement struct {
+		start, end int
+		name       string
+	}
+	var replacements []replacement
+	contentsStr := string(contents)
+	for _, variable := range variables {
+		for index := 0; index < len(contentsStr); {
+			i := strings.Index(contentsStr[index:], variable.value)
+			if i == -1 {
 				break
-			} else {
-				// Advance to the next occurrence.
-				index += j
 			}
+			start := index + i
+			end := start + len(variable.value)
+			index = start + 1
+			// Only replace variable.value if it is on word boundaries at both
+			// ends and it does not overlap an existing replacement.
+			if inWord(contentsStr, start) || inWord(contentsStr, end) {
+				continue
+			}
+			overlaps := false
+			for _, r := range replacements {
+				if start < r.end && r.start < end {
+					overlaps = true
+					break
+				}
+			}
+			if overlaps {
+				continue
+			}
+			replacements = append(replacements, replacement{start: start, end: end, name: variable.name})
+			counts[variable.name]++
+			index = end
 		}
 	}
-	return []byte(contentsStr)
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].start < replacements[j].start
+	})
+
+	var result []byte
+	prev := 0
+	for _, r := range replacements {
+		result = append(result, templateEscape(contents[prev:r.start], td)...)
+		result = append(result, templateAction(r.name, td)...)
+		prev = r.end
+	}
+	return append(result, templateEscape(contents[prev:], td)...)
 }
 
-func extractVariables(variables []templateVariable, parent []string, data map[string]interface{}) []templateVariable {
-	for name, value := range data {
-		switch value := value.(type) {
-		case string:
-			variables = append(variables, templateVariable{
-				name:  strings.Join(append(parent, name), "."),
-				value: value,
-			})
-		case map[string]interface{}:
-			variables = extractVariables(variables, append(parent, name), value)
+// extractVariables appends all string values in data, including those in nested
+// maps and lists, to variables. parent is the path to data.
+func extractVariables(variables []templateVariable, parent []interface{}, data interface{}) []templateVariable {
+	switch data := data.(type) {
+	case string:
+		variables = append(variables, templateVariable{
+			name:  templateReference(parent),
+			value: data,
+		})
+	case map[string]interface{}:
+		for key, value := range data {
+			variables = extractVariables(variables, append(parent[:len(parent):len(parent)], key), value)
+		}
+	case []interface{}:
+		for i, value := range data {
+			variables = extractVariables(variables, append(parent[:len(parent):len(parent)], i), value)
 		}
 	}
 	return variables
 }
 
+// templateReference returns a template expression that references the value
+// at path, where each element of path is either a string map key or an int
+// list index.
+func templateReference(path []interface{}) string {
+	var fields []string
+	for _, element := range path {
+		key, ok := element.(string)
+		if !ok || !identifierRegexp.MatchString(key) {
+			break
+		}
+		fields = append(fields, key)
+	}
+	if len(fields) == len(path) {
+		return "." + strings.Join(fields, ".")
+	}
+	args := []string{"index", "." + strings.Join(fields, ".")}
+	for _, element := range path[len(fields):] {
+		switch element := element.(type) {
+		case string:
+			args = append(args, strconv.Quote(element))
+		case int:
+			args = append(args, strconv.Itoa(element))
+		}
+	}
+	return strings.Join(args, " ")
+}
+
 // inWord returns true if splitting s at position i would split a word.
 func inWord(s string, i int) bool {
 	return i > 0 && i < len(s) && isWord(s[i-1]) && isWord(s[i])
 }
 
-// isWord returns true if b is a word byte.
+// isWord returns true if b is a word byte. Bytes of multibyte UTF-8 sequences
+// are considered word bytes.
 func isWord(b byte) bool {
-	return '0' <= b && b <= '9' || 'A' <= b && b <= 'Z' || 'a' <= b && b <= 'z'
+	return '0' <= b && b <= '9' || 'A' <= b && b <= 'Z' || 'a' <= b && b <= 'z' || b == '_' || b >= 0x80
+}
+
+// templateAction returns the template action that evaluates reference using
+// td's delimiters.
+func templateAction(reference string, td *templateDirective) string {
+	return td.leftDelimiter + " " + reference + " " + td.rightDelimiter
 }
 
 // templateEscape escapes any template delimiters in data.
-func templateEscape(data []byte) []byte {
-	return delimiterRegexp.ReplaceAllFunc(data, func(match []byte) []byte {
-		result := make([]byte, 0, len(match)+8)
-		result = append(result, '{', '{', ' ', '"')
+func templateEscape(data []byte, td *templateDirective) []byte {
+	re := delimiterRegexp
+	if td.leftDelimiter != "{{" || td.rightDelimiter != "}}" {
+		re = regexp.MustCompile(regexp.QuoteMeta(td.leftDelimiter) + "|" + regexp.QuoteMeta(td.rightDelimiter))
+	}
+	return re.ReplaceAllFunc(data, func(match []byte) []byte {
+		result := make([]byte, 0, len(td.leftDelimiter)+len(match)+len(td.rightDelimiter)+4)
+		result = append(result, td.leftDelimiter...)
+		result = append(result, ' ', '"')
 		result = append(result, match...)
-		result = append(result, '"', ' ', '}', '}')
+		result = append(result, '"', ' ')
+		result = append(result, td.rightDelimiter...)
 		return result
 	})
 }
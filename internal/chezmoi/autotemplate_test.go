@@ -11,6 +11,7 @@ func TestAutoTemplate(t *testing.T) {
 		name        string
 		contentsStr string
 		data        map[string]interface{}
+		minLength   int
 		wantStr     string
 	}{
 		{
@@ -122,13 +123,91 @@ func TestAutoTemplate(t *testing.T) {
 			},
 			wantStr: "a",
 		},
+		{
+			name:        "template_directive",
+			contentsStr: "# chezmoi:template:left-delimiter=[[ right-delimiter=]]\nemail = john.smith@company.com {{ }}\n",
+			data: map[string]interface{}{
+				"email": "john.smith@company.com",
+			},
+			wantStr: "# chezmoi:template:left-delimiter=[[ right-delimiter=]]\nemail = [[ .email ]] {{ }}\n",
+		},
+		{
+			name:        "nested_lists",
+			contentsStr: "server = host2.example.com\n",
+			data: map[string]interface{}{
+				"servers": []interface{}{
+					"host1.example.com",
+					map[string]interface{}{
+						"name": "host2.example.com",
+					},
+				},
+			},
+			wantStr: "server = {{ index .servers 1 \"name\" }}\n",
+		},
+		{
+			name:        "non_identifier_keys",
+			contentsStr: "email = john.smith@company.com\n",
+			data: map[string]interface{}{
+				"work-account": map[string]interface{}{
+					"email": "john.smith@company.com",
+				},
+			},
+			wantStr: "email = {{ index . \"work-account\" \"email\" }}\n",
+		},
+		{
+			name:        "underscore_is_word",
+			contentsStr: "/home/user/user_backup",
+			data: map[string]interface{}{
+				"username": "user",
+			},
+			wantStr: "/home/{{ .username }}/user_backup",
+		},
+		{
+			name:        "min_length",
+			contentsStr: "/home/user\nname = John Smith\n",
+			data: map[string]interface{}{
+				"username": "user",
+				"name":     "John Smith",
+			},
+			minLength: 5,
+			wantStr:   "/home/user\nname = {{ .name }}\n",
+		},
+		{
+			name:        "names_matching_values",
+			contentsStr: "email = john.smith@company.com\n",
+			data: map[string]interface{}{
+				"email": "john.smith@company.com",
+				"word":  "email",
+			},
+			wantStr: "{{ .word }} = {{ .email }}\n",
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			assert.Equal(t, tc.wantStr, string(autoTemplate([]byte(tc.contentsStr), tc.data)))
+			actual, _ := autoTemplate([]byte(tc.contentsStr), tc.data, tc.minLength)
+			assert.Equal(t, tc.wantStr, string(actual))
 		})
 	}
 }
 
+func TestAutoTemplateSubstitutions(t *testing.T) {
+	_, actual := autoTemplate([]byte("name = John Smith\nfirstName = John\nJohn\n"), map[string]interface{}{
+		"name":      "John Smith",
+		"firstName": "John",
+		"missing":   "missing",
+	}, 0)
+	assert.Equal(t, []AutoTemplateSubstitution{
+		{Value: "John Smith", Reference: ".name", Replacement: "{{ .name }}", Count: 1},
+		{Value: "John", Reference: ".firstName", Replacement: "{{ .firstName }}", Count: 2},
+	}, actual)
+
+	_, actual = autoTemplate([]byte("# chezmoi:template:left-delimiter=[[ right-delimiter=]]\nname = John Smith\n"), map[string]interface{}{
+		"name": "John Smith",
+	}, 0)
+	assert.Equal(t, []AutoTemplateSubstitution{
+		{Value: "John Smith", Reference: ".name", Replacement: "[[ .name ]]", Count: 1},
+	}, actual)
+}
+
 func TestInWord(t *testing.T) {
 	for _, tc := range []struct {
 		s    string
@@ -205,6 +284,6 @@ func TestTemplateEscape(t *testing.T) {
 			expectedStr: `" vim: set foldmethod=marker foldmarker={{ "{{" }},{{ "}}" }}`,
 		},
 	} {
-		assert.Equal(t, tc.expectedStr, string(templateEscape([]byte(tc.inputStr))))
+		assert.Equal(t, tc.expectedStr, string(templateEscape([]byte(tc.inputStr), &templateDirective{leftDelimiter: "{{", rightDelimiter: "}}"})))
 	}
 }
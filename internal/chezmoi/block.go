@@ -0,0 +1,281 @@
+package chezmoi
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// Managed block markers.
+const (
+	blockBeginMarker = "# BEGIN chezmoi managed block"
+	blockEndMarker   = "# END chezmoi managed block"
+)
+
+// A BlockAttributes holds attributes parsed from a source block name.
+type BlockAttributes struct {
+	Name     string
+	Template bool
+}
+
+// A Block represents the target state of a managed block within a file that
+// is otherwise not managed by chezmoi.
+type Block struct {
+	sourceName       string
+	targetName       string
+	Template         bool
+	contents         []byte
+	contentsErr      error
+	evaluateContents func() ([]byte, error)
+}
+
+type blockConcreteValue struct {
+	Type       string `json:"type" yaml:"type"`
+	SourcePath string `json:"sourcePath" yaml:"sourcePath"`
+	TargetPath string `json:"targetPath" yaml:"targetPath"`
+	Template   bool   `json:"template" yaml:"template"`
+	Contents   string `json:"contents" yaml:"contents"`
+}
+
+// ParseBlockAttributes parses a source block file name.
+func ParseBlockAttributes(sourceName string) BlockAttributes {
+	name := strings.TrimPrefix(sourceName, blockPrefix)
+	template := false
+	if strings.HasPrefix(name, dotPrefix) {
+		name = "." + strings.TrimPrefix(name, dotPrefix)
+	}
+	if strings.HasSuffix(name, TemplateSuffix) {
+		name = strings.TrimSuffix(name, TemplateSuffix)
+		template = true
+	}
+	return BlockAttributes{
+		Name:     name,
+		Template: template,
+	}
+}
+
+// SourceName returns ba's source name.
+func (ba BlockAttributes) SourceName() string {
+	sourceName := blockPrefix
+	if strings.HasPrefix(ba.Name, ".") {
+		sourceName += dotPrefix + strings.TrimPrefix(ba.Name, ".")
+	} else {
+		sourceName += ba.Name
+	}
+	if ba.Template {
+		sourceName += TemplateSuffix
+	}
+	return sourceName
+}
+
+// AppendAllEntries appends b to allEntries.
+func (b *Block) AppendAllEntries(allEntries []Entry) []Entry {
+	return append(allEntries, b)
+}
+
+// Apply ensures that the managed block in b's target in fs matches b, leaving
+// the rest of the target untouched.
+func (b *Block) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
+	if applyOptions.Ignore(b.targetName) {
+		return nil
+	}
+	contents, err := b.Contents()
+	if err != nil {
+		return err
+	}
+	targetPath := filepath.Join(applyOptions.DestDir, b.targetName)
+	var info os.FileInfo
+	if follow {
+		info, err = fs.Stat(targetPath)
+	} else {
+		info, err = fs.Lstat(targetPath)
+	}
+	var currData []byte
+	perm := 0o666 &^ applyOptions.Umask
+	switch {
+	case err == nil && info.Mode().IsRegular():
+		currData, err = fs.ReadFile(targetPath)
+		if err != nil {
+			return err
+		}
+		perm = info.Mode().Perm()
+	case err == nil:
+		return fmt.Errorf("%s: not a regular file", targetPath)
+	case os.IsNotExist(err):
+		if isEmpty(contents) {
+			return nil
+		}
+	default:
+		return err
+	}
+	newData, err := replaceBlock(currData, contents)
+	if err != nil {
+		return fmt.Errorf("%s: %w", targetPath, err)
+	}
+	if bytes.Equal(currData, newData) {
+		return nil
+	}
+	return mutator.WriteFile(targetPath, newData, perm, currData)
+}
+
+// ConcreteValue implements Entry.ConcreteValue.
+func (b *Block) ConcreteValue(ignore func(string) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
+	if ignore(b.targetName) {
+		return nil, nil
+	}
+	contents, err := b.Contents()
+	if err != nil {
+		return nil, err
+	}
+	return &blockConcreteValue{
+		Type:       "block",
+		SourcePath: filepath.Join(sourceDir, b.SourceName()),
+		TargetPath: b.TargetName(),
+		Template:   b.Template,
+		Contents:   string(contents),
+	}, nil
+}
+
+// Contents returns b's contents.
+func (b *Block) Contents() ([]byte, error) {
+	if b.evaluateContents != nil {
+		b.contents, b.contentsErr = b.evaluateContents()
+		b.evaluateContents = nil
+	}
+	return b.contents, b.contentsErr
+}
+
+// Evaluate evaluates b's contents.
+func (b *Block) Evaluate(ignore func(string) bool) error {
+	if ignore(b.targetName) {
+		return nil
+	}
+	_, err := b.Contents()
+	return err
+}
+
+// Remove removes b's managed block, including its markers, from b's target in
+// destDir, leaving the rest of the target untouched.
+func (b *Block) Remove(fs vfs.FS, mutator Mutator, destDir string) error {
+	targetPath := filepath.Join(destDir, b.targetName)
+	info, err := fs.Lstat(targetPath)
+	switch {
+	case err == nil && info.Mode().IsRegular():
+	case err == nil:
+		return fmt.Errorf("%s: not a regular file", targetPath)
+	case os.IsNotExist(err):
+		return nil
+	default:
+		return err
+	}
+	currData, err := fs.ReadFile(targetPath)
+	if err != nil {
+		return err
+	}
+	newData, err := replaceBlock(currData, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", targetPath, err)
+	}
+	if bytes.Equal(currData, newData) {
+		return nil
+	}
+	return mutator.WriteFile(targetPath, newData, info.Mode().Perm(), currData)
+}
+
+// SourceName implements Entry.SourceName.
+func (b *Block) SourceName() string {
+	return b.sourceName
+}
+
+// TargetName implements Entry.TargetName.
+func (b *Block) TargetName() string {
+	return b.targetName
+}
+
+// archive writes b, surrounded by its markers, to w.
+func (b *Block) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error {
+	if ignore(b.targetName) {
+		return nil
+	}
+	contents, err := b.Contents()
+	if err != nil {
+		return err
+	}
+	if isEmpty(contents) {
+		return nil
+	}
+	data, err := replaceBlock(nil, contents)
+	if err != nil {
+		return err
+	}
+	header := *headerTemplate
+	header.Typeflag = tar.TypeReg
+	header.Name = b.targetName
+	header.Size = int64(len(data))
+	header.Mode = int64(0o666 &^ umask)
+	if err := w.WriteHeader(&header); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// findLine returns the start and end offsets of the first line in data at or
+// after from that is equal to line, or -1, -1 if there is no such line. The
+// end offset includes any trailing newline.
+func findLine(data []byte, line string, from int) (int, int) {
+	for start := from; start < len(data); {
+		end := bytes.IndexByte(data[start:], '\n')
+		if end == -1 {
+			end = len(data)
+		} else {
+			end += start + 1
+		}
+		if string(bytes.TrimRight(data[start:end], "\r\n")) == line {
+			return start, end
+		}
+		start = end
+	}
+	return -1, -1
+}
+
+// replaceBlock returns data with its managed block replaced by block. If data
+// does not contain a managed block then block is appended. If block is empty
+// then any existing managed block, including its markers, is removed.
+func replaceBlock(data, block []byte) ([]byte, error) {
+	newBlock := &bytes.Buffer{}
+	if !isEmpty(block) {
+		newBlock.WriteString(blockBeginMarker + "\n")
+		newBlock.Write(block)
+		if block[len(block)-1] != '\n' {
+			newBlock.WriteByte('\n')
+		}
+		newBlock.WriteString(blockEndMarker + "\n")
+	}
+
+	beginStart, beginEnd := findLine(data, blockBeginMarker, 0)
+	if beginStart == -1 {
+		if newBlock.Len() == 0 {
+			return data, nil
+		}
+		result := make([]byte, 0, len(data)+1+newBlock.Len())
+		result = append(result, data...)
+		if len(result) != 0 && result[len(result)-1] != '\n' {
+			result = append(result, '\n')
+		}
+		return append(result, newBlock.Bytes()...), nil
+	}
+	_, endEnd := findLine(data, blockEndMarker, beginEnd)
+	if endEnd == -1 {
+		return nil, fmt.Errorf("unterminated managed block, missing %q", blockEndMarker)
+	}
+	result := make([]byte, 0, len(data)-(endEnd-beginStart)+newBlock.Len())
+	result = append(result, data[:beginStart]...)
+	result = append(result, newBlock.Bytes()...)
+	return append(result, data[endEnd:]...), nil
+}
@@ -0,0 +1,181 @@
+package chezmoi
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestBlockAttributes(t *testing.T) {
+	for _, tc := range []struct {
+		sourceName string
+		ba         BlockAttributes
+	}{
+		{
+			sourceName: "block_foo",
+			ba: BlockAttributes{
+				Name: "foo",
+			},
+		},
+		{
+			sourceName: "block_dot_bashrc",
+			ba: BlockAttributes{
+				Name: ".bashrc",
+			},
+		},
+		{
+			sourceName: "block_dot_profile.tmpl",
+			ba: BlockAttributes{
+				Name:     ".profile",
+				Template: true,
+			},
+		},
+	} {
+		t.Run(tc.sourceName, func(t *testing.T) {
+			assert.Equal(t, tc.ba, ParseBlockAttributes(tc.sourceName))
+			assert.Equal(t, tc.sourceName, tc.ba.SourceName())
+		})
+	}
+}
+
+func TestReplaceBlock(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		data      string
+		block     string
+		want      string
+		wantError bool
+	}{
+		{
+			name:  "empty",
+			data:  "",
+			block: "foo\n",
+			want:  "# BEGIN chezmoi managed block\nfoo\n# END chezmoi managed block\n",
+		},
+		{
+			name:  "append",
+			data:  "bar",
+			block: "foo",
+			want:  "bar\n# BEGIN chezmoi managed block\nfoo\n# END chezmoi managed block\n",
+		},
+		{
+			name:  "replace",
+			data:  "bar\n# BEGIN chezmoi managed block\nfoo\n# END chezmoi managed block\nbaz\n",
+			block: "qux\n",
+			want:  "bar\n# BEGIN chezmoi managed block\nqux\n# END chezmoi managed block\nbaz\n",
+		},
+		{
+			name:  "remove",
+			data:  "bar\n# BEGIN chezmoi managed block\nfoo\n# END chezmoi managed block\nbaz\n",
+			block: "",
+			want:  "bar\nbaz\n",
+		},
+		{
+			name:  "unchanged",
+			data:  "bar\n",
+			block: "",
+			want:  "bar\n",
+		},
+		{
+			name:      "unterminated",
+			data:      "bar\n# BEGIN chezmoi managed block\nfoo\n",
+			block:     "foo\n",
+			wantError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := replaceBlock([]byte(tc.data), []byte(tc.block))
+			if tc.wantError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, string(got))
+		})
+	}
+}
+
+func TestBlockApply(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.bashrc": &vfst.File{
+			Perm:     0o600,
+			Contents: []byte("# installer line\n"),
+		},
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			"block_dot_bashrc":       "export EDITOR=vi\n",
+			"block_dot_profile.tmpl": "export NAME={{ .name }}\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+		WithTemplateData(map[string]interface{}{
+			"name": "John Smith",
+		}),
+		WithUmask(0o22),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+	applyOptions := &ApplyOptions{
+		DestDir: ts.DestDir,
+		Ignore:  ts.TargetIgnore.Match,
+		Stdout:  os.Stdout,
+		Umask:   0o22,
+	}
+	require.NoError(t, ts.Apply(fs, NewFSMutator(fs), false, applyOptions))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestModeIsRegular,
+			vfst.TestModePerm(0o600),
+			vfst.TestContentsString("# installer line\n# BEGIN chezmoi managed block\nexport EDITOR=vi\n# END chezmoi managed block\n"),
+		),
+		vfst.TestPath("/home/user/.profile",
+			vfst.TestModeIsRegular,
+			vfst.TestModePerm(0o644),
+			vfst.TestContentsString("# BEGIN chezmoi managed block\nexport NAME=John Smith\n# END chezmoi managed block\n"),
+		),
+	)
+
+	// A second apply must not change anything.
+	anyMutator := NewAnyMutator(NullMutator{})
+	require.NoError(t, ts.Apply(fs, anyMutator, false, applyOptions))
+	assert.False(t, anyMutator.Mutated())
+}
+
+func TestBlockDuplicateTarget(t *testing.T) {
+	for name, root := range map[string]map[string]interface{}{
+		"block_and_file": {
+			"block_dot_bashrc": "export EDITOR=vi\n",
+			"dot_bashrc":       "# bashrc\n",
+		},
+		"block_and_symlink": {
+			"block_dot_bashrc":   "export EDITOR=vi\n",
+			"symlink_dot_bashrc": "bashrc",
+		},
+		"block_and_private_file": {
+			"block_dot_bashrc":   "export EDITOR=vi\n",
+			"private_dot_bashrc": "# bashrc\n",
+		},
+		"block_and_script": {
+			"block_run.sh": "echo block\n",
+			"run_run.sh":   "echo run\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/.local/share/chezmoi": root,
+			})
+			require.NoError(t, err)
+			defer cleanup()
+			ts := NewTargetState(
+				WithDestDir("/home/user"),
+				WithSourceDir("/home/user/.local/share/chezmoi"),
+			)
+			assert.Error(t, ts.Populate(fs, nil))
+		})
+	}
+}
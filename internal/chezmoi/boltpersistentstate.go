@@ -67,6 +67,20 @@ func (b *BoltPersistentState) Delete(bucket, key []byte) error {
 	})
 }
 
+// DeleteBucket deletes bucket and all the keys and values in it. If bucket
+// does not exist then DeleteBucket does nothing.
+func (b *BoltPersistentState) DeleteBucket(bucket []byte) error {
+	if b.db == nil {
+		return nil
+	}
+	return b.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(bucket); err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+		return nil
+	})
+}
+
 // Get returns the value associated with key in bucket.
 func (b *BoltPersistentState) Get(bucket, key []byte) ([]byte, error) {
 	var value []byte
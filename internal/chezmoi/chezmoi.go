@@ -14,6 +14,7 @@ import (
 
 // Suffixes and prefixes.
 const (
+	blockPrefix      = "block_"
 	dotPrefix        = "dot_"
 	emptyPrefix      = "empty_"
 	encryptedPrefix  = "encrypted_"
@@ -22,6 +23,7 @@ const (
 	oncePrefix       = "once_"
 	privatePrefix    = "private_"
 	runPrefix        = "run_"
+	sopsPrefix       = "sops_"
 	symlinkPrefix    = "symlink_"
 	TemplateSuffix   = ".tmpl"
 )
@@ -30,6 +32,7 @@ const (
 type PersistentState interface {
 	Close() error
 	Delete(bucket, key []byte) error
+	DeleteBucket(bucket []byte) error
 	Get(bucket, key []byte) ([]byte, error)
 	Set(bucket, key, value []byte) error
 }
@@ -42,12 +45,13 @@ type ApplyOptions struct {
 	PersistentState   PersistentState
 	Remove            bool
 	ScriptStateBucket []byte
+	SkipScripts       bool
 	Stdout            io.Writer
 	Umask             os.FileMode
 	Verbose           bool
 }
 
-// An Entry is either a Dir, a File, or a Symlink.
+// An Entry is either a Block, a Dir, a File, a Script, or a Symlink.
 type Entry interface {
 	AppendAllEntries(allEntries []Entry) []Entry
 	Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error
@@ -59,6 +63,7 @@ type Entry interface {
 }
 
 type parsedSourceFilePath struct {
+	blockAttributes  *BlockAttributes
 	dirAttributes    []DirAttributes
 	fileAttributes   *FileAttributes
 	scriptAttributes *ScriptAttributes
@@ -93,6 +98,13 @@ func parseSourceFilePath(path string) parsedSourceFilePath {
 	components := splitPathList(path)
 	das := parseDirNameComponents(components[0 : len(components)-1])
 	sourceName := components[len(components)-1]
+	if strings.HasPrefix(sourceName, blockPrefix) {
+		ba := ParseBlockAttributes(sourceName)
+		return parsedSourceFilePath{
+			blockAttributes: &ba,
+			dirAttributes:   das,
+		}
+	}
 	if strings.HasPrefix(sourceName, runPrefix) {
 		sa := ParseScriptAttributes(sourceName)
 		return parsedSourceFilePath{
@@ -9,13 +9,16 @@ import (
 
 // A DebugMutator wraps a Mutator and logs all of the actions it executes.
 type DebugMutator struct {
-	m Mutator
+	m        Mutator
+	redactor *Redactor
 }
 
-// NewDebugMutator returns a new DebugMutator.
-func NewDebugMutator(m Mutator) *DebugMutator {
+// NewDebugMutator returns a new DebugMutator. Secrets recorded by redactor are
+// redacted from logged commands.
+func NewDebugMutator(m Mutator, redactor *Redactor) *DebugMutator {
 	return &DebugMutator{
-		m: m,
+		m:        m,
+		redactor: redactor,
 	}
 }
 
@@ -29,7 +32,7 @@ func (m *DebugMutator) Chmod(name string, mode os.FileMode) error {
 // IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
 func (m *DebugMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
 	var output []byte
-	cmdStr := ShellQuoteArgs(append([]string{cmd.Path}, cmd.Args[1:]...))
+	cmdStr := m.redactor.RedactString(ShellQuoteArgs(append([]string{cmd.Path}, cmd.Args[1:]...)))
 	err := Debugf("IdempotentCmdOutput(%q)", []interface{}{cmdStr}, func() error {
 		var err error
 		output, err = m.m.IdempotentCmdOutput(cmd)
@@ -61,7 +64,7 @@ func (m *DebugMutator) Rename(oldpath, newpath string) error {
 
 // RunCmd implements Mutator.RunCmd.
 func (m *DebugMutator) RunCmd(cmd *exec.Cmd) error {
-	cmdStr := ShellQuoteArgs(append([]string{cmd.Path}, cmd.Args[1:]...))
+	cmdStr := m.redactor.RedactString(ShellQuoteArgs(append([]string{cmd.Path}, cmd.Args[1:]...)))
 	return Debugf("Run(%q)", []interface{}{cmdStr}, func() error {
 		return m.m.RunCmd(cmd)
 	})
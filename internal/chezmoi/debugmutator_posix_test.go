@@ -0,0 +1,30 @@
+// +build !windows
+
+package chezmoi
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugMutatorRedact(t *testing.T) {
+	b := &bytes.Buffer{}
+	log.SetOutput(b)
+	defer log.SetOutput(os.Stderr)
+
+	r := NewRedactor()
+	r.Add("session-token")
+	m := NewDebugMutator(NullMutator{}, r)
+	require.NoError(t, m.RunCmd(exec.Command("true", "--session", "session-token")))
+	_, err := m.IdempotentCmdOutput(exec.Command("echo", "session-token"))
+	require.NoError(t, err)
+
+	assert.NotContains(t, b.String(), "session-token")
+	assert.Contains(t, b.String(), Redacted)
+}
@@ -23,6 +23,7 @@ type Dir struct {
 	Exact      bool
 	Perm       os.FileMode
 	Entries    map[string]Entry
+	archived   bool
 }
 
 type dirConcreteValue struct {
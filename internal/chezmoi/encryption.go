@@ -0,0 +1,16 @@
+package chezmoi
+
+// An Encryption encrypts and decrypts data.
+type Encryption interface {
+	// Decrypt decrypts ciphertext. filename is used as a hint for naming
+	// temporary files.
+	Decrypt(filename string, ciphertext []byte) ([]byte, error)
+
+	// Encrypt encrypts plaintext. filename is used as a hint for naming
+	// temporary files.
+	Encrypt(filename string, plaintext []byte) ([]byte, error)
+
+	// RecipientNames returns the names of the recipients that Encrypt
+	// encrypts for, in a stable order.
+	RecipientNames() ([]string, error)
+}
@@ -17,6 +17,7 @@ type FileAttributes struct {
 	Mode      os.FileMode
 	Empty     bool
 	Encrypted bool
+	Sops      bool
 	Template  bool
 }
 
@@ -27,6 +28,7 @@ type File struct {
 	Empty            bool
 	Encrypted        bool
 	Perm             os.FileMode
+	Sops             bool
 	Template         bool
 	contents         []byte
 	contentsErr      error
@@ -40,6 +42,7 @@ type fileConcreteValue struct {
 	Empty      bool   `json:"empty" yaml:"empty"`
 	Encrypted  bool   `json:"encrypted" yaml:"encrypted"`
 	Perm       int    `json:"perm" yaml:"perm"`
+	Sops       bool   `json:"sops" yaml:"sops"`
 	Template   bool   `json:"template" yaml:"template"`
 	Contents   string `json:"contents" yaml:"contents"`
 }
@@ -50,6 +53,7 @@ func ParseFileAttributes(sourceName string) FileAttributes {
 	mode := os.FileMode(0o666)
 	empty := false
 	encrypted := false
+	sops := false
 	template := false
 	if strings.HasPrefix(name, symlinkPrefix) {
 		name = strings.TrimPrefix(name, symlinkPrefix)
@@ -59,6 +63,9 @@ func ParseFileAttributes(sourceName string) FileAttributes {
 		if strings.HasPrefix(name, encryptedPrefix) {
 			name = strings.TrimPrefix(name, encryptedPrefix)
 			encrypted = true
+		} else if strings.HasPrefix(name, sopsPrefix) {
+			name = strings.TrimPrefix(name, sopsPrefix)
+			sops = true
 		}
 		if strings.HasPrefix(name, privatePrefix) {
 			name = strings.TrimPrefix(name, privatePrefix)
@@ -88,6 +95,7 @@ func ParseFileAttributes(sourceName string) FileAttributes {
 		Mode:      mode,
 		Empty:     empty,
 		Encrypted: encrypted,
+		Sops:      sops,
 		Template:  template,
 	}
 }
@@ -99,6 +107,8 @@ func (fa FileAttributes) SourceName() string {
 	case 0:
 		if fa.Encrypted {
 			sourceName += encryptedPrefix
+		} else if fa.Sops {
+			sourceName += sopsPrefix
 		}
 		if fa.Mode.Perm()&os.FileMode(0o77) == os.FileMode(0) {
 			sourceName += privatePrefix
@@ -195,6 +205,7 @@ func (f *File) ConcreteValue(ignore func(string) bool, sourceDir string, umask o
 		Empty:      f.Empty,
 		Encrypted:  f.Encrypted,
 		Perm:       int(f.Perm &^ umask),
+		Sops:       f.Sops,
 		Template:   f.Template,
 		Contents:   string(contents),
 	}, nil
@@ -114,6 +114,15 @@ func TestFileAttributes(t *testing.T) {
 				Encrypted: true,
 			},
 		},
+		{
+			sourceName: "sops_private_dot_secrets.yaml.tmpl",
+			fa: FileAttributes{
+				Name:     ".secrets.yaml",
+				Mode:     0o600,
+				Sops:     true,
+				Template: true,
+			},
+		},
 	} {
 		t.Run(tc.sourceName, func(t *testing.T) {
 			assert.Equal(t, tc.fa, ParseFileAttributes(tc.sourceName))
@@ -18,14 +18,17 @@ type GitDiffMutator struct {
 	m              Mutator
 	prefix         string
 	unifiedEncoder *diff.UnifiedEncoder
+	redactor       *Redactor
 }
 
-// NewGitDiffMutator returns a new GitDiffMutator.
-func NewGitDiffMutator(unifiedEncoder *diff.UnifiedEncoder, m Mutator, prefix string) *GitDiffMutator {
+// NewGitDiffMutator returns a new GitDiffMutator. Secrets recorded by redactor
+// are redacted from diffs.
+func NewGitDiffMutator(unifiedEncoder *diff.UnifiedEncoder, m Mutator, prefix string, redactor *Redactor) *GitDiffMutator {
 	return &GitDiffMutator{
 		m:              m,
 		prefix:         prefix,
 		unifiedEncoder: unifiedEncoder,
+		redactor:       redactor,
 	}
 }
 
@@ -147,7 +150,7 @@ func (m *GitDiffMutator) WriteFile(filename string, data []byte, perm os.FileMod
 	isBinary := isBinary(currData) || isBinary(data)
 	var chunks []diff.Chunk
 	if !isBinary {
-		chunks = diffChunks(string(currData), string(data))
+		chunks = diffChunks(m.redactor.RedactString(string(currData)), m.redactor.RedactString(string(data)))
 	}
 	return m.unifiedEncoder.Encode(&gitDiffPatch{
 		filePatches: []diff.FilePatch{
@@ -181,7 +184,7 @@ func (m *GitDiffMutator) WriteSymlink(oldname, newname string) error {
 				},
 				chunks: []diff.Chunk{
 					&gitDiffChunk{
-						content:   oldname,
+						content:   m.redactor.RedactString(oldname),
 						operation: diff.Add,
 					},
 				},
@@ -5,13 +5,15 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 )
 
 // GPG interfaces with gpg.
 type GPG struct {
-	Command   string
-	Recipient string
-	Symmetric bool
+	Command    string
+	Recipient  string
+	Recipients []string
+	Symmetric  bool
 }
 
 // Decrypt decrypts ciphertext. filename is used as a hint for naming temporary
@@ -46,7 +48,7 @@ func (g *GPG) Decrypt(filename string, ciphertext []byte) ([]byte, error) {
 	return ioutil.ReadFile(outputFilename)
 }
 
-// Encrypt encrypts plaintext for ts's recipient. filename is used as a hint for
+// Encrypt encrypts plaintext for g's recipients. filename is used as a hint for
 // naming temporary files.
 func (g *GPG) Encrypt(filename string, plaintext []byte) ([]byte, error) {
 	tempDir, err := ioutil.TempDir("", "chezmoi-encrypt")
@@ -72,9 +74,12 @@ func (g *GPG) Encrypt(filename string, plaintext []byte) ([]byte, error) {
 		if g.Recipient != "" {
 			args = append(args, "--recipient", g.Recipient)
 		}
+		for _, recipient := range g.Recipients {
+			args = append(args, "--recipient", recipient)
+		}
 		args = append(args, "--encrypt")
 	}
-	args = append(args, filename)
+	args = append(args, inputFilename)
 
 	//nolint:gosec
 	cmd := exec.Command(g.Command, args...)
@@ -87,3 +92,17 @@ func (g *GPG) Encrypt(filename string, plaintext []byte) ([]byte, error) {
 
 	return ioutil.ReadFile(outputFilename)
 }
+
+// RecipientNames returns the names of g's recipients.
+func (g *GPG) RecipientNames() ([]string, error) {
+	if g.Symmetric {
+		return []string{"symmetric"}, nil
+	}
+	var recipientNames []string
+	if g.Recipient != "" {
+		recipientNames = append(recipientNames, g.Recipient)
+	}
+	recipientNames = append(recipientNames, g.Recipients...)
+	sort.Strings(recipientNames)
+	return recipientNames, nil
+}
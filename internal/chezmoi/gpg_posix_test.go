@@ -0,0 +1,54 @@
+// +build !windows
+
+package chezmoi
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGPGEncryptPlaintext(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+
+	// The fake gpg "encrypts" its input file by copying it to its output file.
+	command := filepath.Join(tempDir, "gpg")
+	require.NoError(t, ioutil.WriteFile(command, []byte(strings.Join([]string{
+		"#!/bin/sh",
+		"while [ $# -gt 1 ]; do",
+		"  case \"$1\" in",
+		"  --output)",
+		"    output=\"$2\"",
+		"    shift",
+		"    ;;",
+		"  esac",
+		"  shift",
+		"done",
+		"cp \"$1\" \"$output\"",
+	}, "\n")), 0o755))
+	g := &GPG{
+		Command:   command,
+		Recipient: "user@example.com",
+	}
+
+	// Encrypt must encrypt plaintext, not the file named by filename, which is
+	// only a hint and may not exist or may have different contents.
+	filename := filepath.Join(tempDir, "dot_netrc")
+	ciphertext, err := g.Encrypt(filename, []byte("plaintext"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("plaintext"), ciphertext)
+
+	require.NoError(t, ioutil.WriteFile(filename, []byte("other contents"), 0o600))
+	ciphertext, err = g.Encrypt(filename, []byte("plaintext"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("plaintext"), ciphertext)
+}
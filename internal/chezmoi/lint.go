@@ -0,0 +1,387 @@
+package chezmoi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// Lint problem types.
+const (
+	LintDuplicateTarget   = "duplicateTarget"
+	LintError             = "error"
+	LintInvalidAttributes = "invalidAttributes"
+	LintSyntaxError       = "syntaxError"
+	LintUndefinedFunction = "undefinedFunction"
+	LintUndefinedKey      = "undefinedKey"
+	LintUnusedTemplate    = "unusedTemplate"
+)
+
+var (
+	attributePrefixes = []string{
+		blockPrefix,
+		emptyPrefix,
+		encryptedPrefix,
+		exactPrefix,
+		executablePrefix,
+		oncePrefix,
+		privatePrefix,
+		runPrefix,
+		sopsPrefix,
+		symlinkPrefix,
+	}
+	undefinedFunctionRegexp = regexp.MustCompile(`function "(.*)" not defined`)
+)
+
+// A LintProblem is a single problem found by Lint.
+type LintProblem struct {
+	SourceName string `json:"sourceName" yaml:"sourceName"`
+	Type       string `json:"type" yaml:"type"`
+	Message    string `json:"message" yaml:"message"`
+}
+
+// LintOptions are options to Lint.
+type LintOptions struct {
+	// ConfigTemplateFuncs are additional functions only available in the
+	// config file template.
+	ConfigTemplateFuncs template.FuncMap
+}
+
+// A linter accumulates problems and template references while linting.
+type linter struct {
+	ts                 *TargetState
+	options            *LintOptions
+	problems           []*LintProblem
+	targetSourceNames  map[string]string
+	partialSourceNames map[string]string
+	referencedPartials map[string]bool
+}
+
+// Lint statically checks the source state in ts.SourceDir without executing
+// any templates and returns all problems found. Templates in encrypted files
+// and the contents of encrypted archives are not checked. ts must not already
+// be populated.
+func (ts *TargetState) Lint(fs vfs.FS, options *LintOptions) ([]*LintProblem, error) {
+	if options == nil {
+		options = &LintOptions{}
+	}
+	l := &linter{
+		ts:                 ts,
+		options:            options,
+		targetSourceNames:  make(map[string]string),
+		partialSourceNames: make(map[string]string),
+		referencedPartials: make(map[string]bool),
+	}
+	if err := l.lintTemplatesDir(fs); err != nil {
+		return nil, err
+	}
+	if err := vfs.Walk(fs, ts.SourceDir, l.walkFunc(fs)); err != nil {
+		return nil, err
+	}
+
+	partialNames := make([]string, 0, len(l.partialSourceNames))
+	for name := range l.partialSourceNames {
+		partialNames = append(partialNames, name)
+	}
+	sort.Strings(partialNames)
+	for _, name := range partialNames {
+		if !l.referencedPartials[name] {
+			l.addProblem(l.partialSourceNames[name], LintUnusedTemplate, fmt.Sprintf("template %q is never used", name))
+		}
+	}
+
+	// Only populate the target state if the static checks passed, as any
+	// errors will already have been reported in more detail.
+	if len(l.problems) == 0 {
+		if err := ts.Populate(fs, &PopulateOptions{
+			ExecuteTemplates:    false,
+			SkipArchives:        true,
+			SkipIgnoreAndRemove: true,
+		}); err != nil {
+			l.addProblem("", LintError, err.Error())
+		}
+	}
+
+	return l.problems, nil
+}
+
+// addProblem adds a problem to l.
+func (l *linter) addProblem(sourceName, problemType, message string) {
+	l.problems = append(l.problems, &LintProblem{
+		SourceName: sourceName,
+		Type:       problemType,
+		Message:    message,
+	})
+}
+
+// addTarget records that targetName is managed by sourceName, reporting a
+// problem if targetName is already managed by a different source name.
+func (l *linter) addTarget(targetName, sourceName string) {
+	if otherSourceName, ok := l.targetSourceNames[targetName]; ok {
+		l.addProblem(sourceName, LintDuplicateTarget, fmt.Sprintf("%s is also managed by %s", targetName, otherSourceName))
+		return
+	}
+	l.targetSourceNames[targetName] = sourceName
+}
+
+// checkName reports a problem if name, the name remaining after parsing
+// sourceName's attributes, still starts with an attribute prefix, which
+// indicates that the attributes are invalid or in the wrong order.
+func (l *linter) checkName(sourceName, name string) {
+	if strings.HasPrefix(name, ".") {
+		name = dotPrefix + strings.TrimPrefix(name, ".")
+	}
+	for _, prefix := range attributePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			l.addProblem(sourceName, LintInvalidAttributes, fmt.Sprintf("%s: invalid or misordered attribute", strings.TrimSuffix(prefix, "_")))
+			return
+		}
+	}
+}
+
+// lintTemplate parses the template in the file at path and checks its
+// references to data and partial templates. If data is nil then references to
+// data are not checked.
+func (l *linter) lintTemplate(fs vfs.FS, path string, data map[string]interface{}, funcs template.FuncMap) {
+	sourceName := l.sourceName(path)
+	contents, err := fs.ReadFile(path)
+	if err != nil {
+		l.addProblem(sourceName, LintError, err.Error())
+		return
+	}
+	td, contents, err := parseTemplateDirectives(contents)
+	if err != nil {
+		l.addProblem(sourceName, LintSyntaxError, err.Error())
+		return
+	}
+	tmpl, err := l.ts.newTemplate(sourceName, td).Funcs(funcs).Parse(string(contents))
+	if err != nil {
+		if undefinedFunctionRegexp.MatchString(err.Error()) {
+			l.addProblem(sourceName, LintUndefinedFunction, err.Error())
+		} else {
+			l.addProblem(sourceName, LintSyntaxError, err.Error())
+		}
+		return
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		walker := &templateWalker{
+			referencedTemplates: make(map[string]bool),
+		}
+		// Templates defined within the file may be executed with any data.
+		var dot map[string]interface{}
+		if t.Name() == tmpl.Name() {
+			dot = data
+		}
+		walker.walk(t.Tree.Root, dot, dot)
+		for _, key := range walker.undefinedKeys {
+			l.addProblem(sourceName, LintUndefinedKey, fmt.Sprintf("%s: undefined data key", key))
+		}
+		for name := range walker.referencedTemplates {
+			if name != t.Name() {
+				l.referencedPartials[name] = true
+			}
+		}
+	}
+}
+
+// lintTemplatesDir lints all partial templates in the .chezmoitemplates
+// directory.
+func (l *linter) lintTemplatesDir(fs vfs.FS) error {
+	templatesDir := filepath.Join(l.ts.SourceDir, templatesDirName)
+	switch info, err := fs.Stat(templatesDir); {
+	case err == nil && info.IsDir():
+	case err == nil || os.IsNotExist(err):
+		return nil
+	default:
+		return err
+	}
+	prefix := filepath.ToSlash(templatesDir) + "/"
+	return vfs.Walk(fs, templatesDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		switch {
+		case info.Mode().IsRegular():
+			name := strings.TrimPrefix(filepath.ToSlash(path), prefix)
+			l.partialSourceNames[name] = l.sourceName(path)
+			// Partials may be executed with any data, so references to data
+			// cannot be checked.
+			l.lintTemplate(fs, path, nil, nil)
+			return nil
+		case info.IsDir():
+			return nil
+		default:
+			l.addProblem(l.sourceName(path), LintError, fmt.Sprintf("unsupported file in %s", templatesDirName))
+			return nil
+		}
+	})
+}
+
+// sourceName returns the source name of path.
+func (l *linter) sourceName(path string) string {
+	relPath, err := filepath.Rel(l.ts.SourceDir, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(relPath)
+}
+
+// walkFunc returns a vfs.WalkFunc that lints each file in the source state.
+func (l *linter) walkFunc(fs vfs.FS) filepath.WalkFunc {
+	return func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(l.ts.SourceDir, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		sourceName := filepath.ToSlash(relPath)
+		if _, name := filepath.Split(relPath); strings.HasPrefix(name, ".") {
+			switch {
+			case name == ignoreName || name == removeName:
+				l.lintTemplate(fs, path, l.ts.TemplateData, nil)
+			case name == templatesDirName && info.IsDir():
+				// Already linted by lintTemplatesDir.
+				return filepath.SkipDir
+			case name == ArchiveName:
+				// Encrypted archives cannot be checked without decrypting them.
+				return nil
+			case relPath == name && strings.HasPrefix(name, ".chezmoi.") && strings.HasSuffix(name, TemplateSuffix):
+				data := map[string]interface{}{
+					"chezmoi": l.ts.TemplateData["chezmoi"],
+				}
+				l.lintTemplate(fs, path, data, l.options.ConfigTemplateFuncs)
+			case info.IsDir():
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch {
+		case info.IsDir():
+			das := parseDirNameComponents(splitPathList(relPath))
+			l.checkName(sourceName, das[len(das)-1].Name)
+			l.addTarget(filepath.ToSlash(filepath.Join(dirNames(das)...)), sourceName)
+		case info.Mode().IsRegular():
+			psfp := parseSourceFilePath(relPath)
+			dns := dirNames(psfp.dirAttributes)
+			var name string
+			var encrypted, isTemplate bool
+			switch {
+			case psfp.blockAttributes != nil:
+				name = psfp.blockAttributes.Name
+				isTemplate = psfp.blockAttributes.Template
+			case psfp.scriptAttributes != nil:
+				name = psfp.scriptAttributes.Name
+				isTemplate = psfp.scriptAttributes.Template
+			default:
+				name = psfp.fileAttributes.Name
+				encrypted = psfp.fileAttributes.Encrypted || psfp.fileAttributes.Sops
+				isTemplate = psfp.fileAttributes.Template
+			}
+			l.checkName(sourceName, name)
+			l.addTarget(filepath.ToSlash(filepath.Join(append(dns, name)...)), sourceName)
+			if isTemplate && !encrypted {
+				l.lintTemplate(fs, path, l.ts.TemplateData, nil)
+			}
+		default:
+			l.addProblem(sourceName, LintError, "unsupported file type")
+		}
+		return nil
+	}
+}
+
+// A templateWalker walks a template's parse tree recording references to
+// undefined data keys and to other templates.
+type templateWalker struct {
+	undefinedKeys       []string
+	referencedTemplates map[string]bool
+}
+
+// walk walks node. dot is the current value of dot, or nil if it is not
+// known, and root is the value of $, or nil if it is not known.
+func (w *templateWalker) walk(node parse.Node, dot, root map[string]interface{}) {
+	switch node := node.(type) {
+	case *parse.ActionNode:
+		w.walk(node.Pipe, dot, root)
+	case *parse.ChainNode:
+		w.walk(node.Node, dot, root)
+	case *parse.CommandNode:
+		for i, arg := range node.Args {
+			if identifier, ok := arg.(*parse.IdentifierNode); ok && identifier.Ident == "includeTemplate" && i+1 < len(node.Args) {
+				if name, ok := node.Args[i+1].(*parse.StringNode); ok {
+					w.referencedTemplates[name.Text] = true
+				}
+			}
+			w.walk(arg, dot, root)
+		}
+	case *parse.FieldNode:
+		w.checkKey(dot, node.Ident)
+	case *parse.IfNode:
+		w.walk(node.Pipe, dot, root)
+		w.walk(node.List, dot, root)
+		w.walk(node.ElseList, dot, root)
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, n := range node.Nodes {
+			w.walk(n, dot, root)
+		}
+	case *parse.PipeNode:
+		if node == nil {
+			return
+		}
+		for _, cmd := range node.Cmds {
+			w.walk(cmd, dot, root)
+		}
+	case *parse.RangeNode:
+		// The value of dot inside range is not known.
+		w.walk(node.Pipe, dot, root)
+		w.walk(node.List, nil, root)
+		w.walk(node.ElseList, dot, root)
+	case *parse.TemplateNode:
+		w.referencedTemplates[node.Name] = true
+		w.walk(node.Pipe, dot, root)
+	case *parse.VariableNode:
+		if len(node.Ident) > 1 && node.Ident[0] == "$" {
+			w.checkKey(root, node.Ident[1:])
+		}
+	case *parse.WithNode:
+		// The value of dot inside with is not known.
+		w.walk(node.Pipe, dot, root)
+		w.walk(node.List, nil, root)
+		w.walk(node.ElseList, dot, root)
+	}
+}
+
+// checkKey records key as undefined if it is not defined in data. Keys are
+// only checked while the values are maps.
+func (w *templateWalker) checkKey(data map[string]interface{}, key []string) {
+	if data == nil {
+		return
+	}
+	for i, k := range key {
+		value, ok := data[k]
+		if !ok {
+			w.undefinedKeys = append(w.undefinedKeys, "."+strings.Join(key[:i+1], "."))
+			return
+		}
+		if data, ok = value.(map[string]interface{}); !ok {
+			return
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package chezmoi
+
+import (
+	"errors"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestLint(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		root             interface{}
+		expectedProblems []*LintProblem
+	}{
+		{
+			name: "empty",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": &vfst.Dir{Perm: 0o755},
+			},
+		},
+		{
+			name: "valid",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoiignore":          "{{ if ne .chezmoi.os \"linux\" }}.bashrc{{ end }}\n",
+					".chezmoiremove":          "{{ secret \"remove\" }}\n",
+					".chezmoitemplates/part":  "{{ .missing }}",
+					".chezmoi.toml.tmpl":      "[data]\n  email = {{ promptString \"email\" | quote }}\n",
+					"dot_bashrc.tmpl":         "{{ includeTemplate \"part\" }}{{ .email }}{{ range .list }}{{ .missing }}{{ end }}",
+					"symlink_dot_link.tmpl":   "{{ .chezmoi.os }}",
+					"run_once_install.sh":     "{{ .ignored }}",
+					"private_dot_ssh/config":  "# config\n",
+					"exact_dot_vim/dot_vimrc": "",
+					"sops_secrets.yaml.tmpl":  "{{ .missing }}",
+					"dot_aws/.chezmoiarchive": "not encrypted",
+				},
+			},
+		},
+		{
+			name: "syntax_error",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"dot_bashrc.tmpl": "{{ if }}",
+				},
+			},
+			expectedProblems: []*LintProblem{
+				{
+					SourceName: "dot_bashrc.tmpl",
+					Type:       LintSyntaxError,
+					Message:    "template: dot_bashrc.tmpl:1: missing value for if",
+				},
+			},
+		},
+		{
+			name: "undefined_function",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoiremove": "{{ undefined }}",
+				},
+			},
+			expectedProblems: []*LintProblem{
+				{
+					SourceName: ".chezmoiremove",
+					Type:       LintUndefinedFunction,
+					Message:    "template: .chezmoiremove:1: function \"undefined\" not defined",
+				},
+			},
+		},
+		{
+			name: "undefined_key",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"dot_bashrc.tmpl": "{{ .chezmoi.os }}{{ .chezmoi.missing }}{{ with .email }}{{ .missing }}{{ $.emial }}{{ end }}",
+				},
+			},
+			expectedProblems: []*LintProblem{
+				{
+					SourceName: "dot_bashrc.tmpl",
+					Type:       LintUndefinedKey,
+					Message:    ".chezmoi.missing: undefined data key",
+				},
+				{
+					SourceName: "dot_bashrc.tmpl",
+					Type:       LintUndefinedKey,
+					Message:    ".emial: undefined data key",
+				},
+			},
+		},
+		{
+			name: "unused_template",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoitemplates/used":   "used",
+					".chezmoitemplates/unused": "unused",
+					"dot_bashrc.tmpl":          "{{ template \"used\" . }}",
+				},
+			},
+			expectedProblems: []*LintProblem{
+				{
+					SourceName: ".chezmoitemplates/unused",
+					Type:       LintUnusedTemplate,
+					Message:    "template \"unused\" is never used",
+				},
+			},
+		},
+		{
+			name: "invalid_attributes",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"executable_private_dot_bashrc": "",
+					"private_exact_dot_vim":         &vfst.Dir{Perm: 0o755},
+					"private_sops_secrets.yaml":     "",
+				},
+			},
+			expectedProblems: []*LintProblem{
+				{
+					SourceName: "executable_private_dot_bashrc",
+					Type:       LintInvalidAttributes,
+					Message:    "private: invalid or misordered attribute",
+				},
+				{
+					SourceName: "private_exact_dot_vim",
+					Type:       LintInvalidAttributes,
+					Message:    "exact: invalid or misordered attribute",
+				},
+				{
+					SourceName: "private_sops_secrets.yaml",
+					Type:       LintInvalidAttributes,
+					Message:    "sops: invalid or misordered attribute",
+				},
+			},
+		},
+		{
+			name: "duplicate_target",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"dot_bashrc":         "",
+					"private_dot_bashrc": "",
+				},
+			},
+			expectedProblems: []*LintProblem{
+				{
+					SourceName: "private_dot_bashrc",
+					Type:       LintDuplicateTarget,
+					Message:    ".bashrc is also managed by dot_bashrc",
+				},
+			},
+		},
+		{
+			name: "populate_error",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoiversion": "not a version",
+				},
+			},
+			expectedProblems: []*LintProblem{
+				{
+					Type:    LintError,
+					Message: "not a version is not in dotted-tri format",
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			ts := NewTargetState(
+				WithSourceDir("/home/user/.local/share/chezmoi"),
+				WithTemplateData(map[string]interface{}{
+					"chezmoi": map[string]interface{}{
+						"os": "linux",
+					},
+					"email": "user@example.com",
+					"list":  []interface{}{},
+				}),
+				WithTemplateFuncs(template.FuncMap{
+					"secret": func(string) (string, error) {
+						return "", errors.New("secret: executed")
+					},
+				}),
+				WithTemplateOptions([]string{"missingkey=error"}),
+			)
+			problems, err := ts.Lint(fs, &LintOptions{
+				ConfigTemplateFuncs: template.FuncMap{
+					"promptString": func(string) string { return "" },
+					"quote":        func(string) string { return "" },
+				},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedProblems, problems)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package chezmoi
+
+// A NullPersistentState is a PersistentState that stores nothing.
+type NullPersistentState struct{}
+
+// Close implements PersistentState.Close.
+func (NullPersistentState) Close() error {
+	return nil
+}
+
+// Delete implements PersistentState.Delete.
+func (NullPersistentState) Delete(bucket, key []byte) error {
+	return nil
+}
+
+// DeleteBucket implements PersistentState.DeleteBucket.
+func (NullPersistentState) DeleteBucket(bucket []byte) error {
+	return nil
+}
+
+// Get implements PersistentState.Get.
+func (NullPersistentState) Get(bucket, key []byte) ([]byte, error) {
+	return nil, nil
+}
+
+// Set implements PersistentState.Set.
+func (NullPersistentState) Set(bucket, key, value []byte) error {
+	return nil
+}
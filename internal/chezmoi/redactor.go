@@ -0,0 +1,177 @@
+package chezmoi
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Redacted is the value recorded in place of secrets.
+const Redacted = "<redacted>"
+
+// Minimum lengths of secrets that are redacted. Shorter strings are too likely
+// to occur in normal output. Strings nested in structured values, which often
+// contain metadata, must be longer.
+const (
+	redactMinLength       = 4
+	redactMinNestedLength = 8
+)
+
+// A Redactor records secrets and replaces them with Redacted. A nil *Redactor
+// redacts nothing.
+type Redactor struct {
+	secrets map[string]bool
+	sorted  []string
+}
+
+// NewRedactor returns a new Redactor.
+func NewRedactor() *Redactor {
+	return &Redactor{
+		secrets: make(map[string]bool),
+	}
+}
+
+// Add records the strings in value, except those in ignore, as secrets.
+func (r *Redactor) Add(value interface{}, ignore ...string) {
+	if r == nil {
+		return
+	}
+	ignoreSet := make(map[string]bool, len(ignore))
+	for _, s := range ignore {
+		ignoreSet[s] = true
+	}
+	r.add(reflect.ValueOf(value), redactMinLength, ignoreSet)
+}
+
+// Redact returns data with all secrets replaced with Redacted.
+func (r *Redactor) Redact(data []byte) []byte {
+	if r == nil || len(r.secrets) == 0 {
+		return data
+	}
+	for _, secret := range r.sortedSecrets() {
+		data = bytes.ReplaceAll(data, []byte(secret), []byte(Redacted))
+	}
+	return data
+}
+
+// RedactString returns s with all secrets replaced with Redacted.
+func (r *Redactor) RedactString(s string) string {
+	if r == nil || len(r.secrets) == 0 {
+		return s
+	}
+	for _, secret := range r.sortedSecrets() {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	return s
+}
+
+// RedactValue returns a copy of value with all secrets in strings replaced
+// with Redacted. Maps and slices are converted to map[string]interface{} and
+// []interface{}. The exported fields of pointers to structs are redacted.
+func (r *Redactor) RedactValue(value interface{}) interface{} {
+	if r == nil || len(r.secrets) == 0 || value == nil {
+		return value
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return value
+		}
+		result := reflect.New(v.Elem().Type())
+		result.Elem().Set(v.Elem())
+		for i := 0; i < result.Elem().NumField(); i++ {
+			field := result.Elem().Field(i)
+			if !field.CanSet() || field.Kind() == reflect.Interface && field.IsNil() {
+				continue
+			}
+			redactedField := reflect.ValueOf(r.RedactValue(field.Interface()))
+			if redactedField.Type().AssignableTo(field.Type()) {
+				field.Set(redactedField)
+			}
+		}
+		return result.Interface()
+	case reflect.String:
+		return r.RedactString(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			return value
+		}
+		if data, ok := value.([]byte); ok {
+			return r.Redact(data)
+		}
+		result := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			result = append(result, r.RedactValue(v.Index(i).Interface()))
+		}
+		return result
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return value
+		}
+		result := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			result[key.String()] = r.RedactValue(v.MapIndex(key).Interface())
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// add records the strings of at least minLength in v as secrets.
+func (r *Redactor) add(v reflect.Value, minLength int, ignore map[string]bool) {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if !v.IsNil() {
+			r.add(v.Elem(), minLength, ignore)
+		}
+	case reflect.String:
+		r.addString(v.String(), minLength, ignore)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			r.addString(string(v.Bytes()), minLength, ignore)
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			r.add(v.Index(i), redactMinNestedLength, ignore)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			r.add(v.MapIndex(key), redactMinNestedLength, ignore)
+		}
+	}
+}
+
+// addString records s, and each line of s, as secrets if they are at least
+// minLength long. Individual lines are recorded so that multi-line secrets
+// are redacted in line-oriented output like diffs.
+func (r *Redactor) addString(s string, minLength int, ignore map[string]bool) {
+	for _, secret := range append([]string{s}, strings.Split(s, "\n")...) {
+		secret = strings.TrimSpace(secret)
+		if len(secret) < minLength || ignore[secret] || r.secrets[secret] {
+			continue
+		}
+		r.secrets[secret] = true
+		r.sorted = nil
+	}
+}
+
+// sortedSecrets returns the secrets, longest first, so that secrets that
+// contain other secrets are redacted completely.
+func (r *Redactor) sortedSecrets() []string {
+	if r.sorted == nil {
+		r.sorted = make([]string, 0, len(r.secrets))
+		for secret := range r.secrets {
+			r.sorted = append(r.sorted, secret)
+		}
+		sort.Slice(r.sorted, func(i, j int) bool {
+			if len(r.sorted[i]) != len(r.sorted[j]) {
+				return len(r.sorted[i]) > len(r.sorted[j])
+			}
+			return r.sorted[i] < r.sorted[j]
+		})
+	}
+	return r.sorted
+}
@@ -0,0 +1,73 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor(t *testing.T) {
+	r := NewRedactor()
+	r.Add("secret")
+	r.Add("abc")
+	r.Add("line one\nline two\n")
+	r.Add(map[string]interface{}{
+		"name":     "item",
+		"password": "longpassword",
+		"fields": []interface{}{
+			"nested-secret",
+			"short",
+		},
+	})
+	r.Add("github-token-value", "github-token-value")
+
+	for _, tc := range []struct {
+		s    string
+		want string
+	}{
+		{
+			s:    "password = secret\n",
+			want: "password = <redacted>\n",
+		},
+		{
+			s:    "abc",
+			want: "abc",
+		},
+		{
+			s:    "+line one\n+line two\n",
+			want: "+<redacted>\n+<redacted>\n",
+		},
+		{
+			s:    "item longpassword short nested-secret",
+			want: "item <redacted> short <redacted>",
+		},
+		{
+			s:    "github-token-value",
+			want: "github-token-value",
+		},
+	} {
+		assert.Equal(t, tc.want, r.RedactString(tc.s))
+		assert.Equal(t, tc.want, string(r.Redact([]byte(tc.s))))
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"a": []interface{}{"<redacted>", 1},
+	}, r.RedactValue(map[string]interface{}{
+		"a": []interface{}{"secret", 1},
+	}))
+	assert.Equal(t, &fileConcreteValue{
+		Type:     "file",
+		Contents: "key = <redacted>\n",
+	}, r.RedactValue(&fileConcreteValue{
+		Type:     "file",
+		Contents: "key = secret\n",
+	}))
+}
+
+func TestNilRedactor(t *testing.T) {
+	var r *Redactor
+	r.Add("secret")
+	assert.Equal(t, "secret", r.RedactString("secret"))
+	assert.Equal(t, []byte("secret"), r.Redact([]byte("secret")))
+	assert.Equal(t, "secret", r.RedactValue("secret"))
+}
@@ -92,7 +92,7 @@ func (s *Script) AppendAllEntries(allEntries []Entry) []Entry {
 
 // Apply runs s.
 func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
-	if applyOptions.Ignore(s.targetName) {
+	if applyOptions.SkipScripts || applyOptions.Ignore(s.targetName) {
 		return nil
 	}
 	contents, err := s.Contents()
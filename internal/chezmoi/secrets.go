@@ -0,0 +1,136 @@
+package chezmoi
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Minimum lengths and entropies, in bits per character, of possible secrets.
+// Values assigned to keys that look like they hold secrets are held to a lower
+// standard than other strings.
+const (
+	highEntropyMinEntropy = 4.5
+	secretValueMinLength  = 16
+	secretValueMinEntropy = 3.5
+)
+
+// A SecretFinding is a possible secret found in some contents.
+type SecretFinding struct {
+	Line        int
+	Description string
+}
+
+var (
+	secretPatterns = []struct {
+		description string
+		regexp      *regexp.Regexp
+	}{
+		{"private key", regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)},
+		{"AWS access key ID", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+		{"GitHub token", regexp.MustCompile(`\bgh[oprsu]_[0-9A-Za-z]{36}\b`)},
+		{"GitLab token", regexp.MustCompile(`\bglpat-[0-9A-Za-z_\-]{20}\b`)},
+		{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+		{"Slack token", regexp.MustCompile(`\bxox[abeoprs]-[0-9A-Za-z\-]{10,}`)},
+		{"Stripe key", regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{24,}\b`)},
+	}
+	secretAssignmentRegexp = regexp.MustCompile(`(?i)(?:api[_\-]?key|passw(?:or)?d|private[_\-]?key|secret|token)[A-Za-z0-9_\-]*["']?\s*[:=]\s*["']?([^\s"']+)`)
+	highEntropyRegexp      = regexp.MustCompile(`[A-Za-z0-9+/_\-]{32,}={0,2}`)
+)
+
+// FindSecrets returns the possible secrets in contents, at most one per line.
+// It finds private keys, well-known API token formats, high-entropy values
+// assigned to keys that look like they hold secrets, and other high-entropy
+// strings. Binary contents are not scanned.
+func FindSecrets(contents []byte) []SecretFinding {
+	if isBinary(contents) {
+		return nil
+	}
+	var findings []SecretFinding
+	s := bufio.NewScanner(bytes.NewReader(contents))
+	s.Buffer(nil, len(contents)+1)
+	for line := 1; s.Scan(); line++ {
+		if description := findSecret(s.Text()); description != "" {
+			findings = append(findings, SecretFinding{
+				Line:        line,
+				Description: description,
+			})
+		}
+	}
+	return findings
+}
+
+// findSecret returns a description of a possible secret in line, or the empty
+// string if there is none.
+func findSecret(line string) string {
+	for _, pattern := range secretPatterns {
+		if pattern.regexp.MatchString(line) {
+			return pattern.description
+		}
+	}
+	for _, m := range secretAssignmentRegexp.FindAllStringSubmatch(line, -1) {
+		value := m[1]
+		if len(value) < secretValueMinLength || isReference(value) {
+			continue
+		}
+		if shannonEntropy(value) >= secretValueMinEntropy {
+			return "secret value"
+		}
+	}
+	for _, token := range highEntropyRegexp.FindAllString(line, -1) {
+		// Skip the base64-encoded keys in SSH public keys.
+		if strings.HasPrefix(token, "AAAA") {
+			continue
+		}
+		if !containsAll(token, isDigit, isLower, isUpper) {
+			continue
+		}
+		if shannonEntropy(token) >= highEntropyMinEntropy {
+			return "high-entropy string"
+		}
+	}
+	return ""
+}
+
+// containsAll returns true if s contains at least one rune matching each of
+// fs.
+func containsAll(s string, fs ...func(rune) bool) bool {
+FUNC:
+	for _, f := range fs {
+		for _, r := range s {
+			if f(r) {
+				continue FUNC
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// isReference returns true if value looks like a path, URL, template, or
+// variable reference rather than a secret.
+func isReference(value string) bool {
+	return strings.ContainsAny(value[:1], "$%/<~") || strings.Contains(value, "://") || strings.Contains(value, "{{")
+}
+
+func isDigit(r rune) bool { return '0' <= r && r <= '9' }
+func isLower(r rune) bool { return 'a' <= r && r <= 'z' }
+func isUpper(r rune) bool { return 'A' <= r && r <= 'Z' }
+
+// shannonEntropy returns the Shannon entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(n)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,47 @@
+package chezmoi
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Sops interfaces with sops.
+type Sops struct {
+	Command string
+	Args    []string
+}
+
+// Decrypt decrypts ciphertext, which was encrypted by sops. The format of
+// ciphertext is determined by sops from filename's extension.
+func (s *Sops) Decrypt(filename string, ciphertext []byte) ([]byte, error) {
+	return s.DecryptOutputType(filename, ciphertext, "")
+}
+
+// DecryptOutputType decrypts ciphertext like Decrypt and, if outputType is not
+// empty, converts the plaintext to outputType, e.g. json.
+func (s *Sops) DecryptOutputType(filename string, ciphertext []byte, outputType string) ([]byte, error) {
+	tempDir, err := ioutil.TempDir("", "chezmoi-sops")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	inputFilename := filepath.Join(tempDir, filepath.Base(filename))
+	if err := ioutil.WriteFile(inputFilename, ciphertext, 0o600); err != nil {
+		return nil, err
+	}
+
+	args := append([]string{}, s.Args...)
+	if outputType != "" {
+		args = append(args, "--output-type", outputType)
+	}
+	args = append(args, "--decrypt", inputFilename)
+
+	//nolint:gosec
+	cmd := exec.Command(s.Command, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	return cmd.Output()
+}
@@ -36,6 +36,18 @@ type AddOptions struct {
 	Recursive    bool
 	Template     bool
 	AutoTemplate bool
+	Archive      bool
+	// AutoTemplateMinLength is the minimum length of values replaced when
+	// automatically generating templates.
+	AutoTemplateMinLength int
+	// AutoTemplateConfirm, if set, is called with the substitutions made when
+	// automatically generating a template for targetName. The file is only
+	// added if it returns true.
+	AutoTemplateConfirm func(targetName string, substitutions []AutoTemplateSubstitution) (bool, error)
+	// SecretsFound, if set, is called with the possible secrets found in the
+	// contents of targetName when it is added without encryption. The file is
+	// not added if it returns an error.
+	SecretsFound func(targetName string, findings []SecretFinding) error
 }
 
 // An ImportTAROptions contains options for TargetState.ImportTAR.
@@ -47,23 +59,36 @@ type ImportTAROptions struct {
 
 // A PopulateOptions contains options for TargetState.Populate.
 type PopulateOptions struct {
+	// ExecuteTemplates controls whether templates are executed.
 	ExecuteTemplates bool
+	// SkipArchives controls whether encrypted archives are skipped instead of
+	// being decrypted.
+	SkipArchives bool
+	// SkipIgnoreAndRemove controls whether .chezmoiignore and .chezmoiremove,
+	// which are always executed as templates, are skipped.
+	SkipIgnoreAndRemove bool
 }
 
 // A TargetState represents the root target state.
 type TargetState struct {
 	DestDir         string
 	Entries         map[string]Entry
-	GPG             *GPG
+	Encryption      Encryption
 	MinVersion      *semver.Version
+	Sops            *Sops
 	SourceDir       string
 	TargetIgnore    *PatternSet
 	TargetRemove    *PatternSet
 	TemplateData    map[string]interface{}
 	TemplateFuncs   template.FuncMap
 	TemplateOptions []string
+	TemplateTracer  *TemplateTracer
 	Templates       map[string]*template.Template
 	Umask           os.FileMode
+
+	// templateOptions contains the options set by each partial template's
+	// chezmoi:template: directives.
+	templateOptions map[string][]string
 }
 
 // A TargetStateOption sets an option on a TargeState.
@@ -83,10 +108,10 @@ func WithEntries(entries map[string]Entry) TargetStateOption {
 	}
 }
 
-// WithGPG sets the GPG options.
-func WithGPG(gpg *GPG) TargetStateOption {
+// WithEncryption sets the encryption.
+func WithEncryption(encryption Encryption) TargetStateOption {
 	return func(ts *TargetState) {
-		ts.GPG = gpg
+		ts.Encryption = encryption
 	}
 }
 
@@ -97,6 +122,13 @@ func WithMinVersion(minVersion *semver.Version) TargetStateOption {
 	}
 }
 
+// WithSops sets the sops decrypter.
+func WithSops(sops *Sops) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.Sops = sops
+	}
+}
+
 // WithSourceDir sets the source directory.
 func WithSourceDir(sourceDir string) TargetStateOption {
 	return func(ts *TargetState) {
@@ -139,6 +171,13 @@ func WithTemplateOptions(templateOptions []string) TargetStateOption {
 	}
 }
 
+// WithTemplateTracer sets the template tracer.
+func WithTemplateTracer(templateTracer *TemplateTracer) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.TemplateTracer = templateTracer
+	}
+}
+
 // WithTemplates sets the templates.
 func WithTemplates(templates map[string]*template.Template) TargetStateOption {
 	return func(ts *TargetState) {
@@ -215,6 +254,8 @@ func (ts *TargetState) Add(fs vfs.FS, addOptions AddOptions, targetPath string,
 			}
 		} else if _, ok := parentEntry.(*Dir); !ok {
 			return fmt.Errorf("%s: not a directory", parentDirName)
+		} else if InArchive(parentEntry) || HasArchive(parentEntry) {
+			return fmt.Errorf("%s: in encrypted archive", parentDirName)
 		}
 		parentDir := parentEntry.(*Dir)
 		parentDirSourceName = parentDir.sourceName
@@ -235,6 +276,9 @@ func (ts *TargetState) Add(fs vfs.FS, addOptions AddOptions, targetPath string,
 		if private {
 			perm &^= 0o77
 		}
+		if addOptions.Archive {
+			return ts.addArchiveDir(fs, targetName, targetPath, entries, parentDirSourceName, perm, mutator)
+		}
 		// If the directory is empty, or the directory was not added
 		// recursively, add a .keep file so the directory is managed by git.
 		// chezmoi will ignore the .keep file as it begins with a dot.
@@ -257,13 +301,29 @@ func (ts *TargetState) Add(fs vfs.FS, addOptions AddOptions, targetPath string,
 			return err
 		}
 		if addOptions.Template && addOptions.AutoTemplate {
-			contents = autoTemplate(contents, ts.TemplateData)
+			var substitutions []AutoTemplateSubstitution
+			contents, substitutions = autoTemplate(contents, ts.TemplateData, addOptions.AutoTemplateMinLength)
+			if addOptions.AutoTemplateConfirm != nil {
+				ok, err := addOptions.AutoTemplateConfirm(targetName, substitutions)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return nil
+				}
+			}
 		}
 		if addOptions.Encrypt {
-			contents, err = ts.GPG.Encrypt(targetPath, contents)
+			contents, err = ts.Encryption.Encrypt(targetPath, contents)
 			if err != nil {
 				return err
 			}
+		} else if addOptions.SecretsFound != nil {
+			if findings := FindSecrets(contents); len(findings) > 0 {
+				if err := addOptions.SecretsFound(targetName, findings); err != nil {
+					return err
+				}
+			}
 		}
 		perm := info.Mode().Perm()
 		private, err := IsPrivate(fs, targetPath, perm&0o77 == 0)
@@ -389,16 +449,40 @@ func (ts *TargetState) Evaluate() error {
 
 // ExecuteTemplateData returns the result of executing template data.
 func (ts *TargetState) ExecuteTemplateData(name string, data []byte) ([]byte, error) {
-	tmpl, err := template.New(name).Option(ts.TemplateOptions...).Funcs(ts.TemplateFuncs).Parse(string(data))
+	td, data, err := parseTemplateDirectives(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	tmpl, err := ts.newTemplate(name, td).Parse(string(data))
 	if err != nil {
 		return nil, err
 	}
 	for name, t := range ts.Templates {
-		tmpl, err = tmpl.AddParseTree(name, t.Tree)
+		tree := t.Tree
+		if ts.TemplateTracer != nil {
+			tree = tree.Copy()
+		}
+		tmpl, err = tmpl.AddParseTree(name, tree)
 		if err != nil {
 			return nil, err
 		}
 	}
+	funcs := template.FuncMap{
+		"includeTemplate": func(name string, data ...interface{}) (string, error) {
+			return includeTemplate(tmpl, name, ts.templateOptions[name], ts.TemplateData, data...)
+		},
+	}
+	if ts.TemplateTracer != nil {
+		for _, t := range tmpl.Templates() {
+			traceTree(t.Tree)
+		}
+		trace := ts.TemplateTracer.newTrace()
+		funcs = trace.funcs(ts.TemplateFuncs)
+		funcs["includeTemplate"] = trace.wrapFunc("includeTemplate", func(name string, data ...interface{}) (string, error) {
+			return includeTemplate(tmpl, name, ts.templateOptions[name], ts.TemplateData, data...)
+		})
+	}
+	tmpl.Funcs(funcs)
 	sb := &strings.Builder{}
 	if err = tmpl.ExecuteTemplate(sb, name, ts.TemplateData); err != nil {
 		return nil, err
@@ -444,8 +528,27 @@ func (ts *TargetState) ImportTAR(r *tar.Reader, importTAROptions ImportTAROption
 	return nil
 }
 
+// LoadTemplates loads the templates in the .chezmoitemplates directory in
+// ts.SourceDir, if it exists.
+func (ts *TargetState) LoadTemplates(fs vfs.FS) error {
+	templatesDir := filepath.Join(ts.SourceDir, templatesDirName)
+	switch info, err := fs.Stat(templatesDir); {
+	case err == nil && info.IsDir():
+		return ts.addTemplatesDir(fs, templatesDir)
+	case err == nil || os.IsNotExist(err):
+		return nil
+	default:
+		return err
+	}
+}
+
 // Populate walks fs from ts.SourceDir to populate ts.
 func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
+	// Load templates first so that they are available to all other files,
+	// including .chezmoiignore and .chezmoiremove.
+	if err := ts.LoadTemplates(fs); err != nil {
+		return err
+	}
 	return vfs.Walk(fs, ts.SourceDir, func(path string, info os.FileInfo, _ error) error {
 		relPath, err := filepath.Rel(ts.SourceDir, path)
 		if err != nil {
@@ -454,9 +557,15 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 		if relPath == "." {
 			return nil
 		}
+		if relPath == templatesDirName {
+			// Already loaded by LoadTemplates.
+			return filepath.SkipDir
+		}
 		// Treat all files and directories beginning with "." specially.
 		if _, name := filepath.Split(relPath); strings.HasPrefix(name, ".") {
 			switch {
+			case (info.Name() == ignoreName || info.Name() == removeName) && options != nil && options.SkipIgnoreAndRemove:
+				return nil
 			case info.Name() == ignoreName:
 				dns := dirNames(parseDirNameComponents(splitPathList(relPath)))
 				return ts.addPatterns(fs, ts.TargetIgnore, path, filepath.Join(dns...))
@@ -468,6 +577,14 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 					return err
 				}
 				return filepath.SkipDir
+			case info.Name() == ArchiveName && options != nil && options.SkipArchives:
+				return nil
+			case info.Name() == ArchiveName:
+				var dns []string
+				if dir := filepath.Dir(relPath); dir != "." {
+					dns = dirNames(parseDirNameComponents(splitPathList(dir)))
+				}
+				return ts.addArchive(fs, path, relPath, dns)
 			case info.Name() == versionName:
 				data, err := fs.ReadFile(path)
 				if err != nil {
@@ -499,7 +616,7 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 				return err
 			}
 			da := das[len(das)-1]
-			entries[da.Name] = newDir(relPath, targetName, da.Exact, da.Perm)
+			return addEntry(entries, da.Name, newDir(relPath, targetName, da.Exact, da.Perm))
 		case info.Mode().IsRegular():
 			psfp := parseSourceFilePath(relPath)
 			dns := dirNames(psfp.dirAttributes)
@@ -508,6 +625,22 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 				return err
 			}
 			switch {
+			case psfp.blockAttributes != nil:
+				evaluateContents := func() ([]byte, error) {
+					return fs.ReadFile(path)
+				}
+				if psfp.blockAttributes.Template && (options == nil || options.ExecuteTemplates) {
+					evaluateContents = func() ([]byte, error) {
+						return ts.executeTemplate(fs, path)
+					}
+				}
+				entry := &Block{
+					sourceName:       relPath,
+					targetName:       filepath.Join(append(dns, psfp.blockAttributes.Name)...),
+					Template:         psfp.blockAttributes.Template,
+					evaluateContents: evaluateContents,
+				}
+				return addEntry(entries, psfp.blockAttributes.Name, entry)
 			case psfp.fileAttributes != nil && psfp.fileAttributes.Mode&os.ModeType == 0 || psfp.scriptAttributes != nil:
 				readFile := func() ([]byte, error) {
 					return fs.ReadFile(path)
@@ -520,7 +653,24 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 						if err != nil {
 							return nil, err
 						}
-						return ts.GPG.Decrypt(path, ciphertext)
+						return ts.Encryption.Decrypt(path, ciphertext)
+					}
+				}
+				if psfp.fileAttributes != nil && psfp.fileAttributes.Sops {
+					// sops determines the format of the file from its
+					// extension, so pass the target name without any
+					// template suffix.
+					sopsFilename := filepath.Join(filepath.Dir(path), psfp.fileAttributes.Name)
+					prevEvaluateContents := evaluateContents
+					evaluateContents = func() ([]byte, error) {
+						ciphertext, err := prevEvaluateContents()
+						if err != nil {
+							return nil, err
+						}
+						if ts.Sops == nil {
+							return nil, fmt.Errorf("%s: sops not configured", path)
+						}
+						return ts.Sops.Decrypt(sopsFilename, ciphertext)
 					}
 				}
 				if psfp.fileAttributes != nil && psfp.fileAttributes.Template || psfp.scriptAttributes != nil && psfp.scriptAttributes.Template {
@@ -543,10 +693,11 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 						Empty:            psfp.fileAttributes.Empty,
 						Encrypted:        psfp.fileAttributes.Encrypted,
 						Perm:             psfp.fileAttributes.Mode.Perm(),
+						Sops:             psfp.fileAttributes.Sops,
 						Template:         psfp.fileAttributes.Template,
 						evaluateContents: evaluateContents,
 					}
-					entries[psfp.fileAttributes.Name] = entry
+					return addEntry(entries, psfp.fileAttributes.Name, entry)
 				case psfp.scriptAttributes != nil:
 					entry := &Script{
 						sourceName:       relPath,
@@ -555,7 +706,7 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 						Template:         psfp.scriptAttributes.Template,
 						evaluateContents: evaluateContents,
 					}
-					entries[psfp.scriptAttributes.Name] = entry
+					return addEntry(entries, psfp.scriptAttributes.Name, entry)
 				}
 			case psfp.fileAttributes != nil && psfp.fileAttributes.Mode&os.ModeType == os.ModeSymlink:
 				evaluateLinkname := func() (string, error) {
@@ -574,7 +725,7 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 					Template:         psfp.fileAttributes.Template,
 					evaluateLinkname: evaluateLinkname,
 				}
-				entries[psfp.fileAttributes.Name] = entry
+				return addEntry(entries, psfp.fileAttributes.Name, entry)
 			default:
 				return fmt.Errorf("%s: unsupported file type", path)
 			}
@@ -585,6 +736,22 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 	})
 }
 
+// addEntry adds entry to entries as name. A block manages only part of its
+// target, and an entry in an encrypted archive must not be silently replaced by
+// or replace a plain source entry, so it is an error for a block or an archived
+// entry and any other entry to have the same target.
+func addEntry(entries map[string]Entry, name string, entry Entry) error {
+	if existingEntry, ok := entries[name]; ok {
+		_, isBlock := entry.(*Block)
+		_, existingIsBlock := existingEntry.(*Block)
+		if isBlock || existingIsBlock || InArchive(entry) || InArchive(existingEntry) {
+			return fmt.Errorf("%s: duplicate target (%s, %s)", entry.TargetName(), existingEntry.SourceName(), entry.SourceName())
+		}
+	}
+	entries[name] = entry
+	return nil
+}
+
 func (ts *TargetState) addDir(targetName string, entries map[string]Entry, parentDirSourceName string, exact bool, perm os.FileMode, createKeepFile bool, mutator Mutator) error {
 	name := filepath.Base(targetName)
 	if entry, ok := entries[name]; ok {
@@ -623,6 +790,9 @@ func (ts *TargetState) addFile(targetName string, entries map[string]Entry, pare
 		if !ok {
 			return fmt.Errorf("%s: already added and not a regular file", targetName)
 		}
+		if existingFile.Sops {
+			return fmt.Errorf("%s: encrypted with sops, edit %s with sops instead", targetName, filepath.Join(ts.SourceDir, existingFile.sourceName))
+		}
 		var err error
 		existingContents, err = existingFile.Contents()
 		if err != nil {
@@ -752,7 +922,11 @@ func (ts *TargetState) addTemplatesDir(fs vfs.FS, path string) error {
 				return err
 			}
 			name := strings.TrimPrefix(filepath.ToSlash(path), prefix)
-			tmpl, err := template.New(name).Parse(string(contents))
+			td, contents, err := parseTemplateDirectives(contents)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			tmpl, err := ts.newTemplate(name, td).Parse(string(contents))
 			if err != nil {
 				return err
 			}
@@ -760,6 +934,12 @@ func (ts *TargetState) addTemplatesDir(fs vfs.FS, path string) error {
 				ts.Templates = make(map[string]*template.Template)
 			}
 			ts.Templates[name] = tmpl
+			if len(td.options) > 0 {
+				if ts.templateOptions == nil {
+					ts.templateOptions = make(map[string][]string)
+				}
+				ts.templateOptions[name] = td.options
+			}
 			return nil
 		case info.IsDir():
 			return nil
@@ -777,6 +957,22 @@ func (ts *TargetState) executeTemplate(fs vfs.FS, path string) ([]byte, error) {
 	return ts.ExecuteTemplateData(path, data)
 }
 
+// newTemplate returns a new template with ts's options and functions and any
+// delimiters and options from td. includeTemplate is defined so that templates
+// that use it can be parsed, but returns an error unless it is replaced before
+// the template is executed.
+func (ts *TargetState) newTemplate(name string, td *templateDirective) *template.Template {
+	tmpl := template.New(name).Option(ts.TemplateOptions...)
+	if td != nil {
+		tmpl = tmpl.Delims(td.leftDelimiter, td.rightDelimiter).Option(td.options...)
+	}
+	return tmpl.Funcs(ts.TemplateFuncs).Funcs(template.FuncMap{
+		"includeTemplate": func(name string, _ ...interface{}) (string, error) {
+			return "", fmt.Errorf("includeTemplate: %s: not available in this template", name)
+		},
+	})
+}
+
 func (ts *TargetState) findEntries(dirNames []string) (map[string]Entry, error) {
 	entries := ts.Entries
 	for i, dirName := range dirNames {
@@ -851,3 +1047,31 @@ func (ts *TargetState) importHeader(r io.Reader, importTAROptions ImportTAROptio
 		return fmt.Errorf("%s: unspported typeflag '%c'", header.Name, header.Typeflag)
 	}
 }
+
+// includeTemplate executes the template called name associated with tmpl with
+// data, or defaultData if no data is given, and returns the result. If options
+// is not empty then the template is executed with options.
+func includeTemplate(tmpl *template.Template, name string, options []string, defaultData interface{}, data ...interface{}) (string, error) {
+	var templateData interface{}
+	switch len(data) {
+	case 0:
+		templateData = defaultData
+	case 1:
+		templateData = data[0]
+	default:
+		return "", fmt.Errorf("includeTemplate: %s: expected at most one data argument, got %d", name, len(data))
+	}
+	if len(options) > 0 {
+		var err error
+		tmpl, err = tmpl.Clone()
+		if err != nil {
+			return "", err
+		}
+		tmpl.Option(options...)
+	}
+	sb := &strings.Builder{}
+	if err := tmpl.ExecuteTemplate(sb, name, templateData); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -3,6 +3,8 @@ package chezmoi
 import (
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"text/template"
 
@@ -114,7 +116,7 @@ func TestEndToEnd(t *testing.T) {
 				Stdout:            os.Stdout,
 				Umask:             0o22,
 			}
-			assert.NoError(t, ts.Apply(fs, NewVerboseMutator(os.Stderr, NewFSMutator(fs), false, 0), tc.follow, applyOptions))
+			assert.NoError(t, ts.Apply(fs, NewVerboseMutator(os.Stderr, NewFSMutator(fs), false, 0, nil), tc.follow, applyOptions))
 			vfst.RunTests(t, fs, "", tc.tests)
 		})
 	}
@@ -128,6 +130,7 @@ func TestTargetStatePopulate(t *testing.T) {
 		data          map[string]interface{}
 		templateFuncs template.FuncMap
 		want          *TargetState
+		wantTemplates map[string]string
 	}{
 		{
 			name: "simple_file",
@@ -447,28 +450,28 @@ func TestTargetStatePopulate(t *testing.T) {
 			),
 		},
 		{
-			name: "empty_template_dir",
+			name: "template_dir",
 			root: map[string]interface{}{
-				"/.chezmoitemplates": &vfst.Dir{Perm: 0o755},
+				"/.chezmoitemplates/foo": "bar",
 			},
 			sourceDir: "/",
 			want: NewTargetState(
 				WithDestDir("/"),
 				WithSourceDir("/"),
 			),
+			wantTemplates: map[string]string{
+				"foo": "bar",
+			},
 		},
 		{
-			name: "template_dir",
+			name: "empty_template_dir",
 			root: map[string]interface{}{
-				"/.chezmoitemplates/foo": "bar",
+				"/.chezmoitemplates": &vfst.Dir{Perm: 0o755},
 			},
 			sourceDir: "/",
 			want: NewTargetState(
 				WithDestDir("/"),
 				WithSourceDir("/"),
-				WithTemplates(map[string]*template.Template{
-					"foo": template.Must(template.New("foo").Parse("bar")),
-				}),
 			),
 		},
 	} {
@@ -484,7 +487,125 @@ func TestTargetStatePopulate(t *testing.T) {
 			)
 			assert.NoError(t, ts.Populate(fs, nil))
 			assert.NoError(t, ts.Evaluate())
+			// Templates contain functions, which cannot be compared, so
+			// compare their output instead.
+			assert.Len(t, ts.Templates, len(tc.wantTemplates))
+			for name, want := range tc.wantTemplates {
+				require.Contains(t, ts.Templates, name)
+				sb := &strings.Builder{}
+				require.NoError(t, ts.Templates[name].Execute(sb, nil))
+				assert.Equal(t, want, sb.String())
+			}
+			ts.Templates = nil
 			assert.Equal(t, tc.want, ts)
 		})
 	}
 }
+
+func TestTargetStatePopulateIgnoreAndRemove(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		options *PopulateOptions
+		want    bool
+	}{
+		{
+			name: "nil",
+			want: true,
+		},
+		{
+			name: "no_execute_templates",
+			options: &PopulateOptions{
+				ExecuteTemplates: false,
+			},
+			want: true,
+		},
+		{
+			name: "skip_ignore_and_remove",
+			options: &PopulateOptions{
+				SkipIgnoreAndRemove: true,
+			},
+			want: false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoiignore": "foo\n",
+					".chezmoiremove": "bar\n",
+				},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+			ts := NewTargetState(
+				WithDestDir("/home/user"),
+				WithSourceDir("/home/user/.local/share/chezmoi"),
+			)
+			require.NoError(t, ts.Populate(fs, tc.options))
+			assert.Equal(t, tc.want, ts.TargetIgnore.Match("foo"))
+			assert.Equal(t, tc.want, ts.TargetRemove.Match("bar"))
+		})
+	}
+}
+
+func TestTargetStateTemplates(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			".chezmoitemplates": map[string]interface{}{
+				"greeting": "{{ .greeting | upper }}, {{ .name }}",
+				"ignore":   "{{ .ignore }}",
+				"nested":   "{{ includeTemplate \"greeting\" }}",
+			},
+			".chezmoiignore":    "{{ template \"ignore\" . }}\n",
+			"dot_bashrc.tmpl":   "# {{ template \"greeting\" . }}\n",
+			"dot_profile.tmpl":  "{{ includeTemplate \"greeting\" (dict \"greeting\" \"bye\" \"name\" \"Jane\") | quote }}\n",
+			"symlink_link.tmpl": "{{ includeTemplate \"greeting\" | lower }}",
+			"ignored":           "ignored",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+		WithTemplateData(map[string]interface{}{
+			"greeting": "hello",
+			"ignore":   "ignored",
+			"name":     "John",
+		}),
+		WithTemplateFuncs(template.FuncMap{
+			"dict": func(kvs ...string) map[string]interface{} {
+				m := make(map[string]interface{})
+				for i := 0; i+1 < len(kvs); i += 2 {
+					m[kvs[i]] = kvs[i+1]
+				}
+				return m
+			},
+			"lower": strings.ToLower,
+			"quote": strconv.Quote,
+			"upper": strings.ToUpper,
+		}),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+	assert.True(t, ts.TargetIgnore.Match("ignored"))
+
+	bashrc, err := ts.findEntry(".bashrc")
+	require.NoError(t, err)
+	bashrcContents, err := bashrc.(*File).Contents()
+	require.NoError(t, err)
+	assert.Equal(t, "# HELLO, John\n", string(bashrcContents))
+
+	profile, err := ts.findEntry(".profile")
+	require.NoError(t, err)
+	profileContents, err := profile.(*File).Contents()
+	require.NoError(t, err)
+	assert.Equal(t, "\"BYE, Jane\"\n", string(profileContents))
+
+	link, err := ts.findEntry("link")
+	require.NoError(t, err)
+	linkname, err := link.(*Symlink).Linkname()
+	require.NoError(t, err)
+	assert.Equal(t, "hello, john", linkname)
+
+	// includeTemplate is only available when executing source files.
+	assert.Error(t, ts.Templates["nested"].Execute(&strings.Builder{}, ts.TemplateData))
+}
@@ -0,0 +1,82 @@
+package chezmoi
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const templateDirectivePrefix = "chezmoi:template:"
+
+var (
+	templateDirectiveRegexp         = regexp.MustCompile(`\A.*` + regexp.QuoteMeta(templateDirectivePrefix) + `(.*?)\r?(?:\n|\z)`)
+	templateDirectiveKeyValueRegexp = regexp.MustCompile(`\s*([a-z-]+)=("(?:[^"\\]|\\.)*"|\S*)`)
+)
+
+// A templateDirective contains per-template options parsed from
+// chezmoi:template: directives in the template itself.
+type templateDirective struct {
+	leftDelimiter  string
+	rightDelimiter string
+	options        []string
+}
+
+// templateDirectivesLen returns the length of the chezmoi:template: directive
+// lines at the start of data. Lines containing chezmoi:template: elsewhere in
+// data are not directives.
+func templateDirectivesLen(data []byte) int {
+	n := 0
+	for {
+		match := templateDirectiveRegexp.FindIndex(data[n:])
+		if match == nil {
+			return n
+		}
+		n += match[1]
+	}
+}
+
+// parseTemplateDirectives parses the chezmoi:template: directives at the start
+// of data and returns the parsed directive and data with the directive lines
+// removed.
+func parseTemplateDirectives(data []byte) (*templateDirective, []byte, error) {
+	td := &templateDirective{
+		leftDelimiter:  "{{",
+		rightDelimiter: "}}",
+	}
+	n := templateDirectivesLen(data)
+	for directives := data[:n]; len(directives) > 0; {
+		match := templateDirectiveRegexp.FindSubmatchIndex(directives)
+		directive := strings.TrimSpace(string(directives[match[2]:match[3]]))
+		directives = directives[match[1]:]
+		for _, kv := range templateDirectiveKeyValueRegexp.FindAllStringSubmatch(directive, -1) {
+			key, value := kv[1], kv[2]
+			if strings.HasPrefix(value, `"`) {
+				var err error
+				value, err = strconv.Unquote(value)
+				if err != nil {
+					return nil, nil, fmt.Errorf("%s%s: %w", templateDirectivePrefix, key, err)
+				}
+			}
+			switch key {
+			case "left-delimiter":
+				td.leftDelimiter = value
+			case "right-delimiter":
+				td.rightDelimiter = value
+			case "missingkey":
+				switch value {
+				case "default", "invalid", "zero", "error":
+				default:
+					return nil, nil, fmt.Errorf("%s%s: %s: invalid value", templateDirectivePrefix, key, value)
+				}
+				td.options = append(td.options, "missingkey="+value)
+			default:
+				return nil, nil, fmt.Errorf("%s%s: unknown directive", templateDirectivePrefix, key)
+			}
+		}
+	}
+	if td.leftDelimiter == "" || td.rightDelimiter == "" {
+		return nil, nil, fmt.Errorf("%sleft-delimiter and right-delimiter must not be empty", templateDirectivePrefix)
+	}
+	return td, data[n:], nil
+}
@@ -0,0 +1,168 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestParseTemplateDirectives(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		dataStr     string
+		expectedTD  *templateDirective
+		expectedStr string
+		expectErr   bool
+	}{
+		{
+			name:        "empty",
+			dataStr:     "",
+			expectedTD:  &templateDirective{leftDelimiter: "{{", rightDelimiter: "}}"},
+			expectedStr: "",
+		},
+		{
+			name:        "no_directives",
+			dataStr:     "{{ .foo }}\n",
+			expectedTD:  &templateDirective{leftDelimiter: "{{", rightDelimiter: "}}"},
+			expectedStr: "{{ .foo }}\n",
+		},
+		{
+			name:        "delimiters",
+			dataStr:     "# chezmoi:template:left-delimiter=[[ right-delimiter=]]\n[[ .foo ]]\n",
+			expectedTD:  &templateDirective{leftDelimiter: "[[", rightDelimiter: "]]"},
+			expectedStr: "[[ .foo ]]\n",
+		},
+		{
+			name:        "quoted_delimiters",
+			dataStr:     "# chezmoi:template:left-delimiter=\"<< \" right-delimiter=\" >>\"\n<< .foo >>\n",
+			expectedTD:  &templateDirective{leftDelimiter: "<< ", rightDelimiter: " >>"},
+			expectedStr: "<< .foo >>\n",
+		},
+		{
+			name:    "multiple_directives",
+			dataStr: "# chezmoi:template:left-delimiter=[[\r\n# chezmoi:template:right-delimiter=]] missingkey=zero\na\nb",
+			expectedTD: &templateDirective{
+				leftDelimiter:  "[[",
+				rightDelimiter: "]]",
+				options:        []string{"missingkey=zero"},
+			},
+			expectedStr: "a\nb",
+		},
+		{
+			name:        "directive_not_at_start",
+			dataStr:     "a\n# chezmoi:template:left-delimiter=[[\nb\n",
+			expectedTD:  &templateDirective{leftDelimiter: "{{", rightDelimiter: "}}"},
+			expectedStr: "a\n# chezmoi:template:left-delimiter=[[\nb\n",
+		},
+		{
+			name:        "only_directive",
+			dataStr:     "# chezmoi:template:missingkey=default",
+			expectedTD:  &templateDirective{leftDelimiter: "{{", rightDelimiter: "}}", options: []string{"missingkey=default"}},
+			expectedStr: "",
+		},
+		{
+			name:      "invalid_missingkey",
+			dataStr:   "# chezmoi:template:missingkey=bogus\n",
+			expectErr: true,
+		},
+		{
+			name:      "unknown_directive",
+			dataStr:   "# chezmoi:template:unknown=value\n",
+			expectErr: true,
+		},
+		{
+			name:      "empty_delimiter",
+			dataStr:   "# chezmoi:template:left-delimiter=\n",
+			expectErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			td, data, err := parseTemplateDirectives([]byte(tc.dataStr))
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedTD, td)
+			assert.Equal(t, tc.expectedStr, string(data))
+		})
+	}
+}
+
+func TestExecuteTemplateDataWithDirectives(t *testing.T) {
+	ts := NewTargetState(
+		WithTemplateData(map[string]interface{}{
+			"foo": "bar",
+		}),
+		WithTemplateOptions([]string{"missingkey=error"}),
+	)
+	for _, tc := range []struct {
+		name        string
+		dataStr     string
+		expectedStr string
+		expectErr   bool
+	}{
+		{
+			name:        "default",
+			dataStr:     "{{ .foo }}",
+			expectedStr: "bar",
+		},
+		{
+			name:        "delimiters",
+			dataStr:     "# chezmoi:template:left-delimiter=[[ right-delimiter=]]\n[[ .foo ]] {{ .foo }}",
+			expectedStr: "bar {{ .foo }}",
+		},
+		{
+			name:      "missingkey_error",
+			dataStr:   "{{ .missing }}",
+			expectErr: true,
+		},
+		{
+			name:        "missingkey_override",
+			dataStr:     "# chezmoi:template:missingkey=zero\n{{ .missing }}",
+			expectedStr: "<no value>",
+		},
+		{
+			name:      "invalid_directive",
+			dataStr:   "# chezmoi:template:unknown=value\n",
+			expectErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := ts.ExecuteTemplateData(tc.name, []byte(tc.dataStr))
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedStr, string(actual))
+		})
+	}
+}
+
+func TestIncludeTemplateWithDirectives(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi/.chezmoitemplates": map[string]interface{}{
+			"default": "{{ .missing }}",
+			"zero":    "# chezmoi:template:missingkey=zero\n{{ .missing }}",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+		WithTemplateData(map[string]interface{}{}),
+		WithTemplateOptions([]string{"missingkey=error"}),
+	)
+	require.NoError(t, ts.LoadTemplates(fs))
+
+	actual, err := ts.ExecuteTemplateData("include_zero", []byte(`{{ includeTemplate "zero" }} {{ includeTemplate "zero" | len }}`))
+	require.NoError(t, err)
+	assert.Equal(t, "<no value> 10", string(actual))
+
+	_, err = ts.ExecuteTemplateData("include_default", []byte(`{{ includeTemplate "default" }}`))
+	assert.Error(t, err)
+}
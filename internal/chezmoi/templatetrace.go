@@ -0,0 +1,282 @@
+package chezmoi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Template trace event types.
+const (
+	TemplateTraceData     = "data"
+	TemplateTraceFunc     = "func"
+	TemplateTraceTemplate = "template"
+)
+
+// Names of the functions inserted in to templates when tracing.
+const (
+	traceDataFuncName     = "chezmoiTraceData"
+	traceEnterFuncName    = "chezmoiTraceEnter"
+	traceLeaveFuncName    = "chezmoiTraceLeave"
+	traceTemplateFuncName = "chezmoiTraceTemplate"
+)
+
+// A TemplateTraceEvent is a data access, function call, or template inclusion
+// made while executing a template.
+type TemplateTraceEvent struct {
+	Template string        `json:"template" yaml:"template"`
+	Type     string        `json:"type" yaml:"type"`
+	Name     string        `json:"name" yaml:"name"`
+	Args     []interface{} `json:"args,omitempty" yaml:"args,omitempty"`
+	Value    interface{}   `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// A TemplateTracer records TemplateTraceEvents.
+type TemplateTracer struct {
+	Events      []*TemplateTraceEvent
+	RedactFuncs map[string]bool
+}
+
+// A templateTrace traces a single template execution.
+type templateTrace struct {
+	tracer *TemplateTracer
+	stack  []string
+}
+
+// NewTemplateTracer returns a new TemplateTracer that redacts the results of
+// the functions in redactFuncs.
+func NewTemplateTracer(redactFuncs map[string]bool) *TemplateTracer {
+	return &TemplateTracer{
+		RedactFuncs: redactFuncs,
+	}
+}
+
+// newTrace returns a new templateTrace that records events in t.
+func (t *TemplateTracer) newTrace() *templateTrace {
+	return &templateTrace{
+		tracer: t,
+	}
+}
+
+// funcs returns the tracing functions and wrapped versions of funcs that
+// record each call.
+func (tt *templateTrace) funcs(funcs template.FuncMap) template.FuncMap {
+	result := template.FuncMap{
+		traceDataFuncName: func(name string, value interface{}) interface{} {
+			tt.record(TemplateTraceData, name, nil, value)
+			return value
+		},
+		traceEnterFuncName: func(name string) string {
+			tt.stack = append(tt.stack, name)
+			return ""
+		},
+		traceLeaveFuncName: func() string {
+			tt.leave()
+			return ""
+		},
+		traceTemplateFuncName: func(name string) string {
+			tt.record(TemplateTraceTemplate, name, nil, nil)
+			return ""
+		},
+	}
+	for name, f := range funcs {
+		result[name] = tt.wrapFunc(name, f)
+	}
+	return result
+}
+
+// leave leaves the current template.
+func (tt *templateTrace) leave() {
+	if len(tt.stack) > 0 {
+		tt.stack = tt.stack[:len(tt.stack)-1]
+	}
+}
+
+// record records an event in the current template.
+func (tt *templateTrace) record(eventType, name string, args []interface{}, value interface{}) {
+	templateName := ""
+	if len(tt.stack) > 0 {
+		templateName = tt.stack[len(tt.stack)-1]
+	}
+	tt.tracer.Events = append(tt.tracer.Events, &TemplateTraceEvent{
+		Template: templateName,
+		Type:     eventType,
+		Name:     name,
+		Args:     args,
+		Value:    value,
+	})
+}
+
+// wrapFunc returns a function with the same signature as f that records each
+// call to f.
+func (tt *templateTrace) wrapFunc(name string, f interface{}) interface{} {
+	fValue := reflect.ValueOf(f)
+	if fValue.Kind() != reflect.Func {
+		return f
+	}
+	fType := fValue.Type()
+	return reflect.MakeFunc(fType, func(args []reflect.Value) []reflect.Value {
+		var argValues []interface{}
+		for i, arg := range args {
+			if fType.IsVariadic() && i == len(args)-1 {
+				for j := 0; j < arg.Len(); j++ {
+					argValues = append(argValues, arg.Index(j).Interface())
+				}
+			} else {
+				argValues = append(argValues, arg.Interface())
+			}
+		}
+		var results []reflect.Value
+		if fType.IsVariadic() {
+			results = fValue.CallSlice(args)
+		} else {
+			results = fValue.Call(args)
+		}
+		var value interface{}
+		if len(results) > 0 {
+			value = results[0].Interface()
+		}
+		if tt.tracer.RedactFuncs[name] {
+			value = Redacted
+		}
+		if name == "includeTemplate" && len(argValues) > 0 {
+			// The included template was entered when it was executed.
+			tt.leave()
+			if templateName, ok := argValues[0].(string); ok {
+				tt.record(TemplateTraceTemplate, templateName, nil, nil)
+			}
+		}
+		tt.record(TemplateTraceFunc, name, argValues, value)
+		return results
+	}).Interface()
+}
+
+// traceTree modifies tree to record data accesses and template inclusions.
+func traceTree(tree *parse.Tree) {
+	if tree.Root == nil {
+		return
+	}
+	traceList(tree, tree.Root)
+	enter := newTraceAction(tree, tree.Root.Pos, traceEnterFuncName, newStringNode(tree.Root.Pos, tree.Name))
+	tree.Root.Nodes = append([]parse.Node{enter}, tree.Root.Nodes...)
+}
+
+// traceList modifies list to record data accesses and template inclusions.
+func traceList(tree *parse.Tree, list *parse.ListNode) {
+	if list == nil {
+		return
+	}
+	nodes := make([]parse.Node, 0, len(list.Nodes))
+	for _, node := range list.Nodes {
+		switch node := node.(type) {
+		case *parse.ActionNode:
+			tracePipe(tree, node.Pipe)
+		case *parse.IfNode:
+			tracePipe(tree, node.Pipe)
+			traceList(tree, node.List)
+			traceList(tree, node.ElseList)
+		case *parse.RangeNode:
+			tracePipe(tree, node.Pipe)
+			traceList(tree, node.List)
+			traceList(tree, node.ElseList)
+		case *parse.WithNode:
+			tracePipe(tree, node.Pipe)
+			traceList(tree, node.List)
+			traceList(tree, node.ElseList)
+		case *parse.TemplateNode:
+			tracePipe(tree, node.Pipe)
+			// Record the inclusion before executing the template and leave it
+			// afterwards.
+			name := newStringNode(node.Pos, node.Name)
+			nodes = append(nodes, newTraceAction(tree, node.Pos, traceTemplateFuncName, name))
+			nodes = append(nodes, node)
+			nodes = append(nodes, newTraceAction(tree, node.Pos, traceLeaveFuncName))
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	list.Nodes = nodes
+}
+
+// tracePipe modifies pipe to record data accesses.
+func tracePipe(tree *parse.Tree, pipe *parse.PipeNode) {
+	if pipe == nil {
+		return
+	}
+	for i, cmd := range pipe.Cmds {
+		for j, arg := range cmd.Args {
+			// A field as the first argument of a command is a method call if
+			// there are other arguments or if it receives the output of a
+			// previous command, and so cannot be wrapped.
+			if j == 0 && (len(cmd.Args) > 1 || i > 0) {
+				continue
+			}
+			cmd.Args[j] = traceArg(tree, arg)
+		}
+	}
+}
+
+// traceArg returns a node that records access to arg and evaluates to arg.
+func traceArg(tree *parse.Tree, arg parse.Node) parse.Node {
+	var name string
+	switch arg := arg.(type) {
+	case *parse.FieldNode:
+		name = "." + strings.Join(arg.Ident, ".")
+	case *parse.VariableNode:
+		if len(arg.Ident) < 2 {
+			return arg
+		}
+		name = strings.Join(arg.Ident, ".")
+	case *parse.PipeNode:
+		tracePipe(tree, arg)
+		return arg
+	default:
+		return arg
+	}
+	pos := arg.Position()
+	cmd := &parse.CommandNode{
+		NodeType: parse.NodeCommand,
+		Pos:      pos,
+		Args: []parse.Node{
+			parse.NewIdentifier(traceDataFuncName).SetTree(tree).SetPos(pos),
+			newStringNode(pos, name),
+			arg,
+		},
+	}
+	return &parse.PipeNode{
+		NodeType: parse.NodePipe,
+		Pos:      pos,
+		Cmds:     []*parse.CommandNode{cmd},
+	}
+}
+
+// newStringNode returns a new string node with value s.
+func newStringNode(pos parse.Pos, s string) *parse.StringNode {
+	return &parse.StringNode{
+		NodeType: parse.NodeString,
+		Pos:      pos,
+		Quoted:   strconv.Quote(s),
+		Text:     s,
+	}
+}
+
+// newTraceAction returns a new action node that calls the trace function
+// funcName with args.
+func newTraceAction(tree *parse.Tree, pos parse.Pos, funcName string, args ...parse.Node) *parse.ActionNode {
+	cmd := &parse.CommandNode{
+		NodeType: parse.NodeCommand,
+		Pos:      pos,
+		Args:     append([]parse.Node{parse.NewIdentifier(funcName).SetTree(tree).SetPos(pos)}, args...),
+	}
+	return &parse.ActionNode{
+		NodeType: parse.NodeAction,
+		Pos:      pos,
+		Pipe: &parse.PipeNode{
+			NodeType: parse.NodePipe,
+			Pos:      pos,
+			Cmds:     []*parse.CommandNode{cmd},
+		},
+	}
+}
@@ -0,0 +1,100 @@
+package chezmoi
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateTrace(t *testing.T) {
+	partial, err := template.New("partial").Parse(`{{ .email }}`)
+	require.NoError(t, err)
+	for _, tc := range []struct {
+		name           string
+		dataStr        string
+		expectedStr    string
+		expectedEvents []*TemplateTraceEvent
+	}{
+		{
+			name:        "data",
+			dataStr:     `{{ .chezmoi.os }} {{ if .email }}{{ .email | upper }}{{ end }}`,
+			expectedStr: "linux USER@EXAMPLE.COM",
+			expectedEvents: []*TemplateTraceEvent{
+				{Template: "test", Type: TemplateTraceData, Name: ".chezmoi.os", Value: "linux"},
+				{Template: "test", Type: TemplateTraceData, Name: ".email", Value: "user@example.com"},
+				{Template: "test", Type: TemplateTraceData, Name: ".email", Value: "user@example.com"},
+				{Template: "test", Type: TemplateTraceFunc, Name: "upper", Args: []interface{}{"user@example.com"}, Value: "USER@EXAMPLE.COM"},
+			},
+		},
+		{
+			name:        "variable",
+			dataStr:     `{{ range .list }}{{ $.email }}{{ end }}`,
+			expectedStr: "user@example.com",
+			expectedEvents: []*TemplateTraceEvent{
+				{Template: "test", Type: TemplateTraceData, Name: ".list", Value: []interface{}{"a"}},
+				{Template: "test", Type: TemplateTraceData, Name: "$.email", Value: "user@example.com"},
+			},
+		},
+		{
+			name:        "redact",
+			dataStr:     `{{ secret "key" }}`,
+			expectedStr: "password",
+			expectedEvents: []*TemplateTraceEvent{
+				{Template: "test", Type: TemplateTraceFunc, Name: "secret", Args: []interface{}{"key"}, Value: Redacted},
+			},
+		},
+		{
+			name:        "template",
+			dataStr:     `{{ template "partial" . }}`,
+			expectedStr: "user@example.com",
+			expectedEvents: []*TemplateTraceEvent{
+				{Template: "test", Type: TemplateTraceTemplate, Name: "partial"},
+				{Template: "partial", Type: TemplateTraceData, Name: ".email", Value: "user@example.com"},
+			},
+		},
+		{
+			name:        "include_template",
+			dataStr:     `{{ includeTemplate "partial" | upper }}`,
+			expectedStr: "USER@EXAMPLE.COM",
+			expectedEvents: []*TemplateTraceEvent{
+				{Template: "partial", Type: TemplateTraceData, Name: ".email", Value: "user@example.com"},
+				{Template: "test", Type: TemplateTraceTemplate, Name: "partial"},
+				{Template: "test", Type: TemplateTraceFunc, Name: "includeTemplate", Args: []interface{}{"partial"}, Value: "user@example.com"},
+				{Template: "test", Type: TemplateTraceFunc, Name: "upper", Args: []interface{}{"user@example.com"}, Value: "USER@EXAMPLE.COM"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tracer := NewTemplateTracer(map[string]bool{
+				"secret": true,
+			})
+			ts := NewTargetState(
+				WithTemplateData(map[string]interface{}{
+					"chezmoi": map[string]interface{}{
+						"os": "linux",
+					},
+					"email": "user@example.com",
+					"list":  []interface{}{"a"},
+				}),
+				WithTemplateFuncs(template.FuncMap{
+					"secret": func(string) string { return "password" },
+					"upper":  strings.ToUpper,
+				}),
+				WithTemplates(map[string]*template.Template{
+					"partial": partial,
+				}),
+				WithTemplateTracer(tracer),
+			)
+			actual, err := ts.ExecuteTemplateData("test", []byte(tc.dataStr))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedStr, string(actual))
+			assert.Equal(t, tc.expectedEvents, tracer.Events)
+
+			// Check that tracing does not modify the shared templates.
+			assert.Equal(t, "{{.email}}", partial.Tree.Root.String())
+		})
+	}
+}
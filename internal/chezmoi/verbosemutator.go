@@ -22,15 +22,18 @@ type VerboseMutator struct {
 	w               io.Writer
 	colored         bool
 	maxDiffDataSize int
+	redactor        *Redactor
 }
 
-// NewVerboseMutator returns a new VerboseMutator.
-func NewVerboseMutator(w io.Writer, m Mutator, colored bool, maxDiffDataSize int) *VerboseMutator {
+// NewVerboseMutator returns a new VerboseMutator. Secrets recorded by redactor
+// are redacted from diffs.
+func NewVerboseMutator(w io.Writer, m Mutator, colored bool, maxDiffDataSize int, redactor *Redactor) *VerboseMutator {
 	return &VerboseMutator{
 		m:               m,
 		w:               w,
 		colored:         colored,
 		maxDiffDataSize: maxDiffDataSize,
+		redactor:        redactor,
 	}
 }
 
@@ -48,7 +51,7 @@ func (m *VerboseMutator) Chmod(name string, mode os.FileMode) error {
 
 // IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
 func (m *VerboseMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
-	action := cmdString(cmd)
+	action := m.redactor.RedactString(cmdString(cmd))
 	output, err := m.m.IdempotentCmdOutput(cmd)
 	if err != nil {
 		_, _ = fmt.Fprintf(m.w, "%s: %v\n", action, err)
@@ -94,7 +97,7 @@ func (m *VerboseMutator) Rename(oldpath, newpath string) error {
 
 // RunCmd implements Mutator.RunCmd.
 func (m *VerboseMutator) RunCmd(cmd *exec.Cmd) error {
-	action := cmdString(cmd)
+	action := m.redactor.RedactString(cmdString(cmd))
 	err := m.m.RunCmd(cmd)
 	if err == nil {
 		_, _ = fmt.Fprintln(m.w, action)
@@ -125,11 +128,11 @@ func (m *VerboseMutator) WriteFile(name string, data []byte, perm os.FileMode, c
 				return nil
 			}
 		}
-		aLines, err := splitLines(currData)
+		aLines, err := splitLines(m.redactor.Redact(currData))
 		if err != nil {
 			return err
 		}
-		bLines, err := splitLines(data)
+		bLines, err := splitLines(m.redactor.Redact(data))
 		if err != nil {
 			return err
 		}